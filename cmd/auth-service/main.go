@@ -15,8 +15,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/config"
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/cache"
@@ -67,12 +72,31 @@ func main() {
 	)
 
 	// Initialize router
-	router := internalhttp.NewRouter(sugar, redisClient)
+	router, flush := internalhttp.NewRouter(sugar, redisClient)
 
 	// Start server with config
 	addr := ":" + cfg.Server.Port
-	sugar.Infof("server started on port %s", addr)
-	if err := http.ListenAndServe(addr, router); err != nil {
-		sugar.Fatalw("Error starting server", "addr", addr, "error", err)
+	srv := &http.Server{Addr: addr, Handler: router}
+	go func() {
+		sugar.Infof("server started on port %s", addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			sugar.Fatalw("Error starting server", "addr", addr, "error", err)
+		}
+	}()
+
+	// Stop on SIGINT/SIGTERM: finish in-flight requests, then write the
+	// buffered audit events.
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	<-stop.Done()
+	sugar.Info("shutting down")
+
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancelShutdown()
+	if err := srv.Shutdown(ctx); err != nil {
+		sugar.Errorw("server shutdown", "error", err)
+	}
+	if err := flush(ctx); err != nil {
+		sugar.Errorw("flush on shutdown", "error", err)
 	}
 }
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -9,12 +11,20 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	Cache    CacheConfig
-	Log      LogConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Redis     RedisConfig
+	JWT       JWTConfig
+	OAuth     OAuthConfig
+	Mail      MailConfig
+	Password  PasswordConfig
+	Lockout   LockoutConfig
+	RateLimit RateLimitConfig
+	MFA       MFAConfig
+	WebAuthn  WebAuthnConfig
+	Audit     AuditConfig
+	Cache     CacheConfig
+	Log       LogConfig
 }
 
 type ServerConfig struct {
@@ -43,6 +53,81 @@ type JWTConfig struct {
 	RefreshTTL      time.Duration
 	Issuer          string
 	DefaultAudience []string
+	SigningAlg      string
+	SigningKeyFiles []string
+	KeyRotation     time.Duration // 0 = rotate only via the admin endpoint
+
+	// KeyEncryptionKey seals the signing keys stored in Redis (32 bytes).
+	KeyEncryptionKey []byte
+}
+
+type OAuthConfig struct {
+	AuthCodeTTL       time.Duration
+	ClientSecretGrace time.Duration // how long a rotated client secret keeps working
+}
+
+type MailConfig struct {
+	Mailer                   string // "log" (default) or "smtp"
+	From                     string
+	OutboxDir                string // log mailer: also write .eml files here
+	SMTPHost                 string
+	SMTPPort                 string
+	SMTPUser                 string
+	SMTPPass                 string
+	VerificationTTL          time.Duration
+	VerificationURL          string
+	RequireEmailVerification bool
+}
+
+type PasswordConfig struct {
+	MinLength int
+	ResetTTL  time.Duration
+	ResetURL  string
+}
+
+type LockoutConfig struct {
+	MaxFailures   int // per account within FailureWindow; 0 disables
+	IPMaxFailures int // per client IP within FailureWindow; 0 disables
+	FailureWindow time.Duration
+	Duration      time.Duration // first lockout; doubles for each repeat
+	MaxDuration   time.Duration
+}
+
+// RateLimit is read from "<requests>/<window>" (e.g. "10/1m"); "0" disables it.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+type RateLimitConfig struct {
+	Signup     RateLimit // per IP
+	Login      RateLimit // per IP: login, MFA, passkeys, OAuth login form
+	Email      RateLimit // per IP: routes that send emails
+	Auth       RateLimit // per IP: the other public /auth routes
+	Refresh    RateLimit // per IP
+	Token      RateLimit // per client and IP: /auth/token, /oauth/token
+	Introspect RateLimit // per client and IP: introspection and revocation
+	ClientIP   RateLimit // per IP: all of the above together
+	User       RateLimit // per subject: authenticated /auth routes
+	Authz      RateLimit // per subject: /authz decisions
+}
+
+type MFAConfig struct {
+	Issuer       string // label in authenticator apps; defaults to the JWT_ISSUER host
+	ChallengeTTL time.Duration
+	MaxAttempts  int
+}
+
+type WebAuthnConfig struct {
+	RPID    string   // effective domain the credentials are scoped to
+	RPName  string   // defaults to the MFA issuer
+	Origins []string // accepted origins of the browser ceremonies
+	Timeout time.Duration
+}
+
+type AuditConfig struct {
+	HMACKey   []byte // keys the hash chain; never stored with the log
+	QueueSize int    // events buffered for the background writer
 }
 
 type CacheConfig struct {
@@ -79,8 +164,65 @@ func Load() (*Config, error) {
 			RefreshSecret:   getenv("REFRESH_SECRET", ""),
 			AccessTTL:       getenvDuration("ACCESS_TOKEN_TTL", "15m"),
 			RefreshTTL:      getenvDuration("REFRESH_TOKEN_TTL", "168h"),
-			Issuer:          getenv("JWT_ISSUER", "auth-microservice"),
+			Issuer:          getenv("JWT_ISSUER", "http://localhost:8080"),
 			DefaultAudience: splitCSV(getenv("JWT_AUDIENCE", "")),
+			SigningAlg:      getenv("JWT_SIGNING_ALG", "HS256"),
+			SigningKeyFiles: splitCSV(getenv("JWT_SIGNING_KEY_FILES", "")),
+			KeyRotation:     getenvDuration("KEY_ROTATION_INTERVAL", "0"),
+		},
+		OAuth: OAuthConfig{
+			AuthCodeTTL:       getenvDuration("AUTH_CODE_TTL", "1m"),
+			ClientSecretGrace: getenvDuration("CLIENT_SECRET_GRACE_PERIOD", "24h"),
+		},
+		Mail: MailConfig{
+			Mailer:                   getenv("MAILER", "log"),
+			From:                     getenv("MAIL_FROM", "no-reply@localhost"),
+			OutboxDir:                getenv("MAIL_OUTBOX_DIR", ""),
+			SMTPHost:                 getenv("SMTP_HOST", ""),
+			SMTPPort:                 getenv("SMTP_PORT", "587"),
+			SMTPUser:                 getenv("SMTP_USER", ""),
+			SMTPPass:                 getenv("SMTP_PASS", ""),
+			VerificationTTL:          getenvDuration("EMAIL_VERIFICATION_TTL", "24h"),
+			VerificationURL:          getenv("EMAIL_VERIFICATION_URL", ""),
+			RequireEmailVerification: getenv("REQUIRE_EMAIL_VERIFICATION", "false") == "true",
+		},
+		Password: PasswordConfig{
+			MinLength: getenvInt("PASSWORD_MIN_LENGTH", 6),
+			ResetTTL:  getenvDuration("PASSWORD_RESET_TTL", "30m"),
+			ResetURL:  getenv("PASSWORD_RESET_URL", ""),
+		},
+		Lockout: LockoutConfig{
+			MaxFailures:   getenvInt("LOGIN_MAX_FAILURES", 5),
+			IPMaxFailures: getenvInt("LOGIN_IP_MAX_FAILURES", 20),
+			FailureWindow: getenvDuration("LOGIN_FAILURE_WINDOW", "15m"),
+			Duration:      getenvDuration("LOGIN_LOCKOUT_DURATION", "5m"),
+			MaxDuration:   getenvDuration("LOGIN_LOCKOUT_MAX", "24h"),
+		},
+		RateLimit: RateLimitConfig{
+			Signup:     getenvRateLimit("RATE_LIMIT_SIGNUP", "10/1h"),
+			Login:      getenvRateLimit("RATE_LIMIT_LOGIN", "30/1m"),
+			Email:      getenvRateLimit("RATE_LIMIT_EMAIL", "5/15m"),
+			Auth:       getenvRateLimit("RATE_LIMIT_AUTH", "60/1m"),
+			Refresh:    getenvRateLimit("RATE_LIMIT_REFRESH", "30/1m"),
+			Token:      getenvRateLimit("RATE_LIMIT_TOKEN", "60/1m"),
+			Introspect: getenvRateLimit("RATE_LIMIT_INTROSPECT", "600/1m"),
+			ClientIP:   getenvRateLimit("RATE_LIMIT_CLIENT_IP", "1200/1m"),
+			User:       getenvRateLimit("RATE_LIMIT_USER", "120/1m"),
+			Authz:      getenvRateLimit("RATE_LIMIT_AUTHZ", "600/1m"),
+		},
+		MFA: MFAConfig{
+			Issuer:       getenv("MFA_ISSUER", ""),
+			ChallengeTTL: getenvDuration("MFA_CHALLENGE_TTL", "5m"),
+			MaxAttempts:  getenvInt("MFA_MAX_ATTEMPTS", 5),
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:    getenv("WEBAUTHN_RP_ID", "localhost"),
+			RPName:  getenv("WEBAUTHN_RP_NAME", ""),
+			Origins: splitCSV(getenv("WEBAUTHN_ORIGINS", "http://localhost:8080")),
+			Timeout: getenvDuration("WEBAUTHN_TIMEOUT", "2m"),
+		},
+		Audit: AuditConfig{
+			QueueSize: getenvInt("AUDIT_QUEUE_SIZE", 1024),
 		},
 		Cache: CacheConfig{
 			ProfileTTL:    getenvDuration("CACHE_PROFILE_TTL", "5m"),
@@ -100,6 +242,41 @@ func Load() (*Config, error) {
 	if cfg.JWT.RefreshSecret == "" {
 		return nil, fmt.Errorf("REFRESH_SECRET is required")
 	}
+	kek, err := base64.StdEncoding.DecodeString(getenv("JWT_KEY_ENCRYPTION_KEY", ""))
+	if err != nil || len(kek) != 32 {
+		return nil, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY must be 32 bytes, base64-encoded")
+	}
+	cfg.JWT.KeyEncryptionKey = kek
+	auditKey, err := base64.StdEncoding.DecodeString(getenv("AUDIT_HMAC_KEY", ""))
+	if err != nil || len(auditKey) < 32 {
+		return nil, fmt.Errorf("AUDIT_HMAC_KEY must be at least 32 bytes, base64-encoded")
+	}
+	cfg.Audit.HMACKey = auditKey
+	issuer, err := url.Parse(cfg.JWT.Issuer)
+	if err != nil || (issuer.Scheme != "https" && issuer.Scheme != "http") || issuer.Host == "" || issuer.RawQuery != "" || issuer.Fragment != "" {
+		return nil, fmt.Errorf("JWT_ISSUER must be an absolute http(s) URL without query or fragment")
+	}
+	cfg.JWT.Issuer = strings.TrimSuffix(cfg.JWT.Issuer, "/")
+	if cfg.MFA.Issuer == "" {
+		cfg.MFA.Issuer = issuer.Hostname()
+	}
+	if cfg.WebAuthn.RPName == "" {
+		cfg.WebAuthn.RPName = cfg.MFA.Issuer
+	}
+	switch cfg.JWT.SigningAlg {
+	case "HS256", "RS256", "ES256", "EdDSA":
+	default:
+		return nil, fmt.Errorf("JWT_SIGNING_ALG must be one of HS256, RS256, ES256, EdDSA")
+	}
+	switch cfg.Mail.Mailer {
+	case "log":
+	case "smtp":
+		if cfg.Mail.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required when MAILER=smtp")
+		}
+	default:
+		return nil, fmt.Errorf("MAILER must be log or smtp")
+	}
 	if cfg.Database.Password == "" {
 		return nil, fmt.Errorf("DB_PASSWORD is required")
 	}
@@ -135,6 +312,28 @@ func getenvDuration(key, defaultValue string) time.Duration {
 	return 0
 }
 
+func parseRateLimit(value string) (RateLimit, bool) {
+	if value == "0" {
+		return RateLimit{}, true
+	}
+	n, w, ok := strings.Cut(value, "/")
+	limit, err1 := strconv.Atoi(strings.TrimSpace(n))
+	window, err2 := time.ParseDuration(strings.TrimSpace(w))
+	if !ok || err1 != nil || err2 != nil || limit < 0 || window < time.Millisecond {
+		return RateLimit{}, false
+	}
+	return RateLimit{Limit: limit, Window: window}, true
+}
+
+func getenvRateLimit(key, defaultValue string) RateLimit {
+	if l, ok := parseRateLimit(getenv(key, defaultValue)); ok {
+		return l
+	}
+	// Return default if parsing fails
+	l, _ := parseRateLimit(defaultValue)
+	return l
+}
+
 func splitCSV(s string) []string {
 	s = strings.TrimSpace(s)
 	if s == "" {
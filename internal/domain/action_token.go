@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Action token purposes. A token issued for one purpose is rejected for any other.
+const (
+	PurposeEmailVerification = "email_verification"
+)
+
+var ErrInvalidActionToken = errors.New("invalid or expired token")
+
+// ActionToken is a signed, expiring token that lets a user prove control of
+// their email address, e.g. a verification link.
+type ActionToken struct {
+	ID        string // jti
+	Purpose   string
+	UserID    string
+	Email     string // the address the token was sent to
+	ExpiresAt time.Time
+}
+
+// ActionTokenService issues and verifies action tokens.
+type ActionTokenService interface {
+	IssueActionToken(purpose, userID, email string, ttl time.Duration) (string, error)
+
+	// VerifyActionToken checks signature, expiry and purpose. Any failure is
+	// ErrInvalidActionToken.
+	VerifyActionToken(purpose, token string) (*ActionToken, error)
+}
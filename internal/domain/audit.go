@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// Audit actions.
+const (
+	AuditLogin        = "auth.login"
+	AuditSignup       = "auth.signup"
+	AuditRefresh      = "auth.refresh"
+	AuditLogout       = "auth.logout"
+	AuditClientToken  = "auth.client_token"
+	AuditAuthCode     = "auth.authorization_code"
+	AuditOAuthRefresh = "auth.oauth_refresh"
+
+	AuditRoleCreate         = "admin.role.create"
+	AuditScopeCreate        = "admin.scope.create"
+	AuditRoleScopesAdd      = "admin.role.scopes.add"
+	AuditUserRolesAdd       = "admin.user.roles.add"
+	AuditClientScopesAdd    = "admin.client.scopes.add"
+	AuditUserScopeGrant     = "admin.user.scope.grant"
+	AuditUserScopeRevoke    = "admin.user.scope.revoke"
+	AuditClientInvalidate   = "admin.client.invalidate"
+	AuditUserInvalidate     = "admin.user.invalidate"
+	AuditUserUnlock         = "admin.user.unlock"
+	AuditUserUpdate         = "admin.user.update"
+	AuditUserDisable        = "admin.user.disable"
+	AuditUserEnable         = "admin.user.enable"
+	AuditUserDelete         = "admin.user.delete"
+	AuditUserPasswordReset  = "admin.user.password_reset"
+	AuditClientCreate       = "admin.client.create"
+	AuditClientUpdate       = "admin.client.update"
+	AuditClientDeactivate   = "admin.client.deactivate"
+	AuditClientActivate     = "admin.client.activate"
+	AuditClientSecretRotate = "admin.client.secret.rotate"
+	AuditRoleDelete         = "admin.role.delete"
+	AuditScopeDelete        = "admin.scope.delete"
+	AuditRoleScopesRemove   = "admin.role.scopes.remove"
+	AuditUserRolesRemove    = "admin.user.roles.remove"
+	AuditClientScopesRemove = "admin.client.scopes.remove"
+	AuditRoleParentsAdd     = "admin.role.parents.add"
+	AuditRoleParentsRemove  = "admin.role.parents.remove"
+	AuditGroupCreate        = "admin.group.create"
+	AuditGroupDelete        = "admin.group.delete"
+	AuditGroupMembersAdd    = "admin.group.members.add"
+	AuditGroupMembersRemove = "admin.group.members.remove"
+	AuditGroupRolesAdd      = "admin.group.roles.add"
+	AuditGroupRolesRemove   = "admin.group.roles.remove"
+	AuditGroupScopesAdd     = "admin.group.scopes.add"
+	AuditGroupScopesRemove  = "admin.group.scopes.remove"
+	AuditOrgCreate          = "admin.org.create"
+	AuditPolicyCreate       = "admin.policy.create"
+	AuditPolicyDelete       = "admin.policy.delete"
+	AuditSessionRevoke      = "admin.session.revoke"
+	AuditSessionRevokeAll   = "admin.session.revoke_all"
+	AuditKeyRotate          = "admin.key.rotate"
+	AuditKeyRemove          = "admin.key.remove"
+)
+
+// Actor is who caused an audit event: an authenticated principal, or an
+// anonymous caller known only by its IP.
+type Actor struct {
+	Type PrincipalType // empty when anonymous
+	ID   string
+	IP   string
+}
+
+// AuditEvent is one entry of the audit log. Every entry carries the hash of
+// the previous one, so editing, removing or reordering entries breaks the
+// chain from that point on.
+type AuditEvent struct {
+	Seq        int64             `json:"seq"`
+	Time       time.Time         `json:"time"`
+	Action     string            `json:"action"`
+	Outcome    string            `json:"outcome"`
+	ActorType  string            `json:"actor_type,omitempty"`
+	ActorID    string            `json:"actor_id,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	TargetType string            `json:"target_type,omitempty"`
+	TargetID   string            `json:"target_id,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+	PrevHash   string            `json:"prev_hash"`
+	Hash       string            `json:"hash"`
+}
+
+// ComputeHash returns the chain hash of the event: HMAC-SHA256 under key over
+// every field but Hash itself, PrevHash included. The key never reaches the
+// database, so whoever can write the table still can't rehash a chain they
+// edited.
+func (e AuditEvent) ComputeHash(key []byte) string {
+	e.Hash = ""
+	e.Time = e.Time.UTC()
+	b, _ := json.Marshal(e) // struct fields in order, map keys sorted
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AuditFilter selects audit events, newest first. Zero fields match all.
+type AuditFilter struct {
+	Action   string
+	ActorID  string
+	TargetID string
+	Outcome  string
+	Since    *time.Time
+	Until    *time.Time
+	Before   int64 // cursor: only events with a lower Seq
+	Limit    int
+}
+
+type AuditLog interface {
+	// Append chains e to the last event and stores it, filling Seq, PrevHash
+	// and Hash. Appends are serialized.
+	Append(ctx context.Context, e *AuditEvent) error
+
+	List(ctx context.Context, f AuditFilter) ([]AuditEvent, error)
+
+	// Range returns up to limit events with Seq > after, oldest first.
+	Range(ctx context.Context, after int64, limit int) ([]AuditEvent, error)
+}
@@ -1,16 +1,54 @@
 // internal/domain/client.go
 package domain
 
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrClientNotFound = errors.New("client not found")
+	ErrClientIDInUse  = errors.New("client_id already in use")
+
+	// ErrPublicClient is returned when rotating the secret of a client that
+	// has none.
+	ErrPublicClient = errors.New("public clients have no secret")
+)
+
 type Client struct {
 	ID              string
 	ClientID        string
+	TenantID        string
 	SecretHash      string
 	Name            string
-	AllowedScopes   []string 
-	AllowedAudience []string 
+	AllowedScopes   []string
+	AllowedAudience []string
 	Active          bool
+
+	// Authorization code flow.
+	RedirectURIs []string // exact-match allow list
+	Public       bool     // no secret (browser/mobile apps); relies on PKCE alone
+
+	// Secret rotation: the secret replaced by the last rotation keeps
+	// authenticating the client until PreviousSecretExpiresAt.
+	PreviousSecretHash      string
+	PreviousSecretExpiresAt *time.Time
+
+	CreatedAt time.Time
 }
 
 type ClientRepository interface {
+	// FindByClientID returns nil when there is no such client.
 	FindByClientID(clientID string) (*Client, error)
+
+	// Create stores c and fills in its ID and CreatedAt.
+	Create(c *Client) error
+	// List returns every client, oldest first.
+	List() ([]*Client, error)
+	// Update saves every field of c except ClientID, TenantID and CreatedAt.
+	Update(c *Client) error
+
+	// ForTenant returns a view of the repository restricted to one tenant:
+	// clients of other tenants are not found and new clients join the tenant.
+	ForTenant(tenantID string) ClientRepository
 }
@@ -0,0 +1,21 @@
+package domain
+
+// JWK is the public half of a signing key as published on the JWKS endpoint (RFC 7517).
+// Only the members relevant to the key type are filled.
+type JWK struct {
+	Kty string `json:"kty" example:"RSA"`
+	Use string `json:"use,omitempty" example:"sig"`
+	Alg string `json:"alg,omitempty" example:"RS256"`
+	Kid string `json:"kid,omitempty"`
+
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // EC / OKP curve
+	X   string `json:"x,omitempty"`   // EC / OKP public point
+	Y   string `json:"y,omitempty"`   // EC public point
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
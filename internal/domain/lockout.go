@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrAccountLocked: too many failed logins for the account.
+	ErrAccountLocked = errors.New("account temporarily locked")
+	// ErrTooManyLoginAttempts: too many failed logins from the client IP.
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+)
+
+// LockoutError wraps ErrAccountLocked or ErrTooManyLoginAttempts with the
+// time left until logins are accepted again.
+type LockoutError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string { return e.Err.Error() }
+func (e *LockoutError) Unwrap() error { return e.Err }
+
+// LoginAttemptStore counts failed logins and holds temporary locks. Keys are
+// opaque (an account or an IP).
+type LoginAttemptStore interface {
+	// Locked returns the time left on the lock of key, or 0.
+	Locked(ctx context.Context, key string) (time.Duration, error)
+
+	// Fail counts a failure and returns the failures within window, counted
+	// from the first one.
+	Fail(ctx context.Context, key string, window time.Duration) (int, error)
+
+	// Lock locks key and clears its failures. The lock lasts base, doubled for
+	// every earlier lock of key still remembered (memory), up to max. It
+	// returns the lock duration.
+	Lock(ctx context.Context, key string, base, max, memory time.Duration) (time.Duration, error)
+
+	// Reset clears the failures, lock and lock history of key.
+	Reset(ctx context.Context, key string) error
+}
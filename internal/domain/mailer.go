@@ -0,0 +1,15 @@
+package domain
+
+import "context"
+
+// Email is a plain-text transactional message.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional emails (verification links and the like).
+type Mailer interface {
+	Send(ctx context.Context, msg Email) error
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrMFANotEnabled        = errors.New("multi-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled    = errors.New("multi-factor authentication is already enabled")
+	ErrMFANotEnrolled       = errors.New("no pending totp enrollment")
+	ErrInvalidMFACode       = errors.New("invalid verification code")
+	ErrMFAChallengeNotFound = errors.New("invalid or expired mfa challenge")
+)
+
+// MFASettings is the TOTP enrollment of a user. It exists but is not Enabled
+// between enroll and confirm.
+type MFASettings struct {
+	UserID     string
+	TOTPSecret string // base32
+	Enabled    bool
+	EnabledAt  *time.Time
+
+	// LastUsedStep is the time step of the last accepted code; a code is never
+	// accepted twice.
+	LastUsedStep int64
+}
+
+type MFARepository interface {
+	// FindMFA returns nil, nil when the user never enrolled.
+	FindMFA(userID string) (*MFASettings, error)
+	SaveMFA(s *MFASettings) error
+
+	// DeleteMFA drops the enrollment and the recovery codes of the user.
+	DeleteMFA(userID string) error
+
+	// UseTOTPStep records step as used, unless a step at or after it already
+	// was (replay). It reports whether the step was recorded.
+	UseTOTPStep(userID string, step int64) (bool, error)
+
+	// ReplaceRecoveryCodes swaps every recovery code of the user for the given
+	// hashes.
+	ReplaceRecoveryCodes(userID string, hashes []string) error
+
+	// UseRecoveryCode marks an unused code as used. It reports whether there
+	// was one with that hash.
+	UseRecoveryCode(userID, hash string) (bool, error)
+}
+
+// MFAChallengeStore keeps the pending second step of a login: the password was
+// right, a code is still required.
+type MFAChallengeStore interface {
+	Save(ctx context.Context, token, userID string, ttl time.Duration) error
+
+	// Attempt counts a verification attempt and returns the user of the
+	// challenge with the attempts made so far (including this one). Unknown or
+	// expired challenges yield ErrMFAChallengeNotFound.
+	Attempt(ctx context.Context, token string) (userID string, attempts int, err error)
+
+	Delete(ctx context.Context, token string) error
+}
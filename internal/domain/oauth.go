@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// OAuth 2.0 error codes (RFC 6749 sections 4.1.2.1 and 5.2).
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthAccessDenied            = "access_denied"
+	OAuthServerError             = "server_error"
+	OAuthInvalidTarget           = "invalid_target" // RFC 8707, unknown or disallowed audience
+)
+
+// OAuthError is a protocol error reported to the client with its RFC 6749 code.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func NewOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// CodeChallengeS256 is the only PKCE method accepted (RFC 7636).
+const CodeChallengeS256 = "S256"
+
+var ErrAuthCodeNotFound = errors.New("authorization code not found")
+
+// AuthorizationCode is the grant behind a code issued by /oauth/authorize.
+type AuthorizationCode struct {
+	ClientID      string    `json:"client_id"`
+	UserID        string    `json:"user_id"`
+	Email         string    `json:"email"`
+	TenantID      string    `json:"tenant_id,omitempty"`
+	RedirectURI   string    `json:"redirect_uri"`
+	Scopes        []string  `json:"scopes"`
+	CodeChallenge string    `json:"code_challenge"`  // S256
+	Nonce         string    `json:"nonce,omitempty"` // OIDC, echoed in the ID token
+	AuthTime      time.Time `json:"auth_time"`
+}
+
+// AuthCodeStore keeps authorization codes until they are exchanged.
+type AuthCodeStore interface {
+	Save(ctx context.Context, code string, ac AuthorizationCode, ttl time.Duration) error
+
+	// Consume returns the grant of a code and deletes it, so every code is
+	// single-use. Unknown, expired or already used codes yield ErrAuthCodeNotFound.
+	Consume(ctx context.Context, code string) (*AuthorizationCode, error)
+}
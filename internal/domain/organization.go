@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// PlatformTenant is the tenant of everything outside any organization: the
+// platform administrators and the data from before organizations existed.
+const PlatformTenant = ""
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrSlugInUse            = errors.New("organization slug already in use")
+
+	// ErrCrossTenant is returned when an operation would move data between
+	// tenants.
+	ErrCrossTenant = errors.New("cross-tenant operation")
+)
+
+// Organization is a tenant. Users, clients, roles, scopes and groups belong
+// to exactly one; its ID is their TenantID and the tenant_id claim of their
+// tokens.
+type Organization struct {
+	ID        string
+	Slug      string
+	Name      string
+	CreatedAt time.Time
+}
+
+type OrganizationRepository interface {
+	// Create stores o and fills in its ID and CreatedAt.
+	Create(o *Organization) error
+	// List returns every organization, by slug.
+	List() ([]*Organization, error)
+	// FindByID and FindBySlug return nil when there is no such organization.
+	FindByID(id string) (*Organization, error)
+	FindBySlug(slug string) (*Organization, error)
+}
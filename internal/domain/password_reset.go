@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrPasswordResetNotFound = errors.New("password reset token not found")
+
+// PasswordResetStore keeps pending password reset tokens. A user has at most
+// one: issuing a new token invalidates the previous one.
+type PasswordResetStore interface {
+	Save(ctx context.Context, token, userID string, ttl time.Duration) error
+
+	// Consume returns the user of a token and deletes it, so every token is
+	// single-use. Unknown, expired or already used tokens yield
+	// ErrPasswordResetNotFound.
+	Consume(ctx context.Context, token string) (userID string, err error)
+}
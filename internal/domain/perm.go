@@ -1,17 +1,54 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrRoleNotFound  = errors.New("role not found")
+	ErrScopeNotFound = errors.New("scope not found")
+	ErrGroupNotFound = errors.New("group not found")
+
+	// ErrRoleCycle is returned when a role would inherit from itself.
+	ErrRoleCycle = errors.New("role inheritance cycle")
+)
 
 type Scope struct {
-	ID   string
-	Key  string
-	Desc string
+	ID       string
+	TenantID string
+	Key      string
+	Desc     string
 }
 
 type Role struct {
-	ID   string
-	Key  string
-	Desc string
+	ID       string
+	TenantID string
+	Key      string
+	Desc     string
+}
+
+// Group is a set of users sharing the roles and scopes attached to it.
+type Group struct {
+	ID       string
+	TenantID string
+	Key      string
+	Desc     string
+}
+
+// GroupGrants is a group with its members and what they get from it.
+type GroupGrants struct {
+	Group
+	Members []string // user IDs
+	Roles   []string // role keys
+	Scopes  []string // scope keys
+}
+
+// RoleTree is a role with the roles it inherits from, recursively.
+type RoleTree struct {
+	Role
+	Scopes  []string // keys of the scopes attached to the role itself
+	Parents []RoleTree
 }
 
 type PermissionRepository interface {
@@ -24,8 +61,35 @@ type PermissionRepository interface {
 	AddScopesToClient(clientID string, scopeIDs []string) error
 	ListRoles() ([]Role, error)
 
+	// Remoção (apagar role/scope remove também seus vínculos; vínculo inexistente não é erro)
+	DeleteRole(roleID string) error
+	DeleteScope(scopeID string) error
+	RemoveScopesFromRole(roleID string, scopeIDs []string) error
+	RemoveRolesFromUser(userID string, roleIDs []string) error
+	RemoveScopesFromClient(clientID string, scopeIDs []string) error
+
+	// Herança de roles: um role herda todos os scopes dos seus parents
+	AddParentRoles(roleID string, parentIDs []string) error // ErrRoleCycle se criar um ciclo
+	RemoveParentRoles(roleID string, parentIDs []string) error
+	GetRoleTree(roleID string) (RoleTree, error)
+
+	// Grupos: membros recebem os roles e scopes do grupo
+	CreateGroup(key, desc string) (Group, error)
+	ListGroups() ([]Group, error)
+	GetGroup(groupID string) (GroupGrants, error)
+	DeleteGroup(groupID string) error
+	AddUsersToGroup(groupID string, userIDs []string) error
+	RemoveUsersFromGroup(groupID string, userIDs []string) error
+	AddRolesToGroup(groupID string, roleIDs []string) error
+	RemoveRolesFromGroup(groupID string, roleIDs []string) error
+	AddScopesToGroup(groupID string, scopeIDs []string) error
+	RemoveScopesFromGroup(groupID string, scopeIDs []string) error
+	ListUserGroups(userID string) ([]string, error) // group keys
+
 	// Consulta (para emissão/checagem)
-	ListUserRoles(userID string) ([]string, error)
+	ListUserRoles(userID string) ([]string, error) // só os atribuídos diretamente
+	// Roles atribuídos (ao usuário ou aos seus grupos) e herdados; scopes de
+	// todos eles mais os diretos e os dos grupos
 	ListUserScopesEffective(userID string, now time.Time) (roles []string, scopes []string, err error)
 	ListClientScopes(clientID string) ([]string, error)
 
@@ -37,4 +101,8 @@ type PermissionRepository interface {
 	InvalidateUser(userID string) error
 	InvalidateClient(clientID string) error
 	ListUserScopes(userID string) ([]string, error)
+
+	// Tenants: vínculos nunca cruzam tenants (ErrCrossTenant). A view de um
+	// tenant só enxerga e cria dados dele; o resto dá not found.
+	ForTenant(tenantID string) PermissionRepository
 }
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrPolicyNotFound = errors.New("policy not found")
+
+type PolicyEffect string
+
+const (
+	PolicyAllow PolicyEffect = "allow"
+	PolicyDeny  PolicyEffect = "deny"
+)
+
+// Policy allows or denies actions to the subjects and resources it matches.
+//
+// Subject and Resource map an attribute to its accepted values; every listed
+// attribute must hold one of them, and a multi-valued subject attribute
+// (roles, scopes, audience) matches when any of its values is accepted. A
+// resource value "$subject.<attr>" stands for that attribute of the subject,
+// e.g. {"owner": ["$subject.id"]} only matches the caller's own resources.
+type Policy struct {
+	ID          string
+	TenantID    string
+	Name        string
+	Description string
+	Effect      PolicyEffect
+	Actions     []string            // "documents:read", "documents:*" or "*"
+	Subject     map[string][]string // id, type, email, client_id, tenant_id, roles, scopes, audience
+	Resource    map[string][]string
+	Conditions  PolicyConditions
+	CreatedAt   time.Time
+}
+
+// PolicyConditions restrict when a policy applies. Empty fields don't.
+type PolicyConditions struct {
+	// TimeFrom and TimeTo ("15:04") bound the time of day in TimeZone (UTC
+	// when empty). The window wraps midnight when TimeFrom is after TimeTo.
+	TimeFrom string `json:"time_from,omitempty"`
+	TimeTo   string `json:"time_to,omitempty"`
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// IPRanges are the CIDRs the caller's IP must be in.
+	IPRanges []string `json:"ip_ranges,omitempty"`
+}
+
+// AccessRequest asks whether Subject may perform Action on the resource
+// described by Resource, from IP at Time.
+type AccessRequest struct {
+	Subject  Principal
+	Action   string
+	Resource map[string]string
+	IP       string
+	Time     time.Time
+}
+
+// Decision is the outcome of an AccessRequest. A matching deny policy wins
+// over any allow; with no matching policy at all the request is denied.
+type Decision struct {
+	Allowed bool
+	// PolicyID is the policy that decided, empty for the default deny.
+	PolicyID string
+}
+
+type PolicyRepository interface {
+	// Create stores p and fills in its ID and CreatedAt.
+	Create(p *Policy) error
+	// List returns the policies, oldest first.
+	List() ([]*Policy, error)
+	// FindByID returns nil when there is no such policy.
+	FindByID(id string) (*Policy, error)
+	Delete(id string) error
+
+	// ForTenant returns a view of the repository restricted to the
+	// policies of one tenant; new policies are created in it.
+	ForTenant(tenantID string) PolicyRepository
+}
+
+// PolicyEngine decides access requests against the policies of the
+// subject's tenant.
+type PolicyEngine interface {
+	Evaluate(ctx context.Context, req AccessRequest) (Decision, error)
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type PrincipalType string
 
 const (
@@ -15,4 +17,16 @@ type Principal struct {
     Scopes   []string
     ClientID string
     Audience []string
+
+    // TenantID is the organization the principal belongs to; empty for the
+    // platform itself.
+    TenantID string
+
+    // SessionID is the session (refresh token family) the access token was
+    // issued for. Empty for client credentials.
+    SessionID string
+
+    // AuthTime is when the user actually authenticated (OIDC auth_time). It
+    // survives refresh token rotation.
+    AuthTime time.Time
 }
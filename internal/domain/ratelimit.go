@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit is a token bucket: Limit requests in a burst, refilled at Limit
+// per Window.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // until the next token, when not allowed
+	Reset      time.Duration // until the bucket is full again
+}
+
+type RateLimiter interface {
+	// Allow takes a token from the bucket of key.
+	Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
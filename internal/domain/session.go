@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionMeta describes the device a session was started or last refreshed from.
+type SessionMeta struct {
+	UserAgent string
+	IP        string
+}
+
+// Session is a login: one refresh token family. Its ID is the family ID (fid)
+// carried by every token issued from it.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // the session of the calling access token
+}
+
+// SessionManager lists and revokes the sessions of a user.
+type SessionManager interface {
+	// ListSessions returns the active sessions of a user, most recently used first.
+	ListSessions(ctx context.Context, userID string) ([]Session, error)
+
+	// RevokeSession ends one session of the user: its refresh token stops
+	// rotating and its access tokens stop verifying.
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+
+	// RevokeAllSessions ends every session of the user.
+	RevokeAllSessions(ctx context.Context, userID string) error
+
+	// RevokeOtherSessions ends every session of the user but keepSessionID.
+	RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) error
+}
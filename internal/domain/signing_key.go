@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSigningKeyNotFound = errors.New("signing key not found")
+	ErrActiveSigningKey   = errors.New("the active signing key cannot be removed")
+)
+
+// SigningKeyInfo describes a key in a signing keyring, without key material.
+type SigningKeyInfo struct {
+	ID        string     `json:"kid"`
+	Ring      string     `json:"ring" example:"access"`
+	Algorithm string     `json:"alg" example:"RS256"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+// KeyManager administers the signing keyrings behind the TokenService.
+type KeyManager interface {
+	// ListKeys returns every key of every ring, active keys first.
+	ListKeys() []SigningKeyInfo
+
+	// RotateKeys promotes freshly generated keys and retires the current ones.
+	RotateKeys(ctx context.Context) error
+
+	// RemoveKey drops a retired key immediately, invalidating the tokens it signed.
+	RemoveKey(ctx context.Context, kid string) error
+}
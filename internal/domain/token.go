@@ -1,6 +1,17 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrRefreshReused is returned when an already rotated refresh token is presented
+// again. The whole token family has been revoked by then.
+var ErrRefreshReused = errors.New("refresh token reuse detected")
+
+// ErrTokenClientMismatch is returned when a token is presented by a client
+// other than the one it was issued to.
+var ErrTokenClientMismatch = errors.New("token was issued to another client")
 
 // TokenPair wraps both access and refresh tokens.
 type TokenPair struct {
@@ -8,8 +19,15 @@ type TokenPair struct {
 	RefreshToken string
 	AccessExp    time.Time
 	RefreshExp   time.Time
+	Scopes       []string // granted scopes, echoed in OAuth token responses
 }
 
+// Token type hints (RFC 7009 / RFC 7662).
+const (
+	TokenTypeHintAccess  = "access_token"
+	TokenTypeHintRefresh = "refresh_token"
+)
+
 // TokenClaims are the normalized claims used across the app.
 type TokenClaims struct {
 	SubjectType PrincipalType
@@ -19,30 +37,77 @@ type TokenClaims struct {
 	Scopes      []string
 	ClientID    string
 	Audience    []string
+	TenantID    string // tenant_id, empty for the platform tenant
 
 	// Standard JWT claims we often need to access explicitly.
 	ID        string    // jti
 	IssuedAt  time.Time // iat
 	ExpiresAt time.Time // exp
 	Issuer    string    // iss (optional)
+
+	// FamilyID (fid) ties every token issued from the same login together; it
+	// is also the session ID.
+	// Refresh rotation keeps it; reuse of a rotated refresh token revokes it.
+	FamilyID string
+
+	AuthTime time.Time // auth_time, zero for client credentials
+}
+
+// Principal is the principal the token was issued to, with the grants it
+// carries.
+func (c TokenClaims) Principal() Principal {
+	return Principal{
+		Type:      c.SubjectType,
+		ID:        c.SubjectID,
+		Email:     c.Email,
+		Roles:     c.Roles,
+		Scopes:    c.Scopes,
+		ClientID:  c.ClientID,
+		Audience:  c.Audience,
+		TenantID:  c.TenantID,
+		SessionID: c.FamilyID,
+		AuthTime:  c.AuthTime,
+	}
 }
 
 // TokenService defines the auth core behaviors.
 type TokenService interface {
-	// IssuePair generates a new access+refresh pair for a given principal.
-	IssuePair(p Principal) (TokenPair, error)
+	// IssuePair generates a new access+refresh pair for a given principal,
+	// starting a new session from the device described by meta.
+	IssuePair(p Principal, meta SessionMeta) (TokenPair, error)
 
 	// VerifyAccess validates an access token (signature/exp/blacklist) and returns claims.
 	VerifyAccess(accessToken string) (*TokenClaims, error)
 
-	// Rotate validates a refresh token against Redis and returns a new pair.
-	Rotate(refreshToken string) (TokenPair, error)
+	// RotateForClient validates a refresh token against Redis and returns a new
+	// pair in the same family. The token must have been issued to clientID (""
+	// for first-party tokens), otherwise ErrTokenClientMismatch is returned and
+	// the token is left untouched. Replaying a rotated token revokes the family
+	// and returns ErrRefreshReused.
+	RotateForClient(refreshToken, clientID string, meta SessionMeta) (TokenPair, error)
 
-	// RevokePair blacklists the access token and invalidates the refresh token (Redis).
+	// RevokePair blacklists the access token and ends the session of the refresh token (Redis).
 	RevokePair(accessToken, refreshToken string) error
 
+	// RevokeToken revokes a single access or refresh token on behalf of the
+	// client it was issued to (RFC 7009), trying the hinted type first. Invalid,
+	// expired or already revoked tokens are ignored; tokens of another client
+	// yield ErrTokenClientMismatch.
+	RevokeToken(token, tokenTypeHint, clientID string) error
+
 	// Introspect tells whether the token is active and returns claims if active.
 	Introspect(token string) (active bool, claims *TokenClaims, err error)
 
+	// IntrospectRefresh is Introspect for refresh tokens.
+	IntrospectRefresh(token string) (active bool, claims *TokenClaims, err error)
+
 	IssueAccessOnly(p Principal) (token string, exp time.Time, err error)
+
+	// IssueIDToken mints an OIDC ID token for an access token just issued by
+	// this service, bound to it through at_hash. It returns "" when the access
+	// token was not granted the openid scope.
+	IssueIDToken(accessToken, nonce string) (string, error)
+
+	// JWKS returns the public keys resource servers use to verify access tokens.
+	JWKS() JWKSet
 }
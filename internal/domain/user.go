@@ -1,8 +1,41 @@
 package domain
 
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrEmailNotVerified is returned by login when verified emails are required.
+	ErrEmailNotVerified = errors.New("email not verified")
+
+	// ErrUserDisabled is returned when a disabled user signs in.
+	ErrUserDisabled = errors.New("user disabled")
+
+	// ErrPasswordResetRequired is returned when an administrator forced a
+	// password reset and the user has not reset it yet.
+	ErrPasswordResetRequired = errors.New("password reset required")
+
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailInUse   = errors.New("email already in use")
+)
+
 type User struct {
-	ID       string `json:"id"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Verified bool   `json:"verified"`
-}
\ No newline at end of file
+	ID                    string    `json:"id"`
+	Email                 string    `json:"email"`
+	TenantID              string    `json:"tenant_id"`
+	Password              string    `json:"password"`
+	Verified              bool      `json:"verified"`
+	Disabled              bool      `json:"disabled"`
+	PasswordResetRequired bool      `json:"password_reset_required"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// UserFilter selects users for the admin listing. Nil flags match all.
+type UserFilter struct {
+	Search   string // substring of the email, case-insensitive
+	Verified *bool
+	Disabled *bool
+	Offset   int
+	Limit    int
+}
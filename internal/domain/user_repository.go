@@ -3,5 +3,28 @@ package domain
 type UserRepository interface {
 	Create(user *User) error
 	FindByEmail(email string) (*User, error)
+	FindByID(id string) (*User, error)
+	Update(user *User) error
+
+	// UpdatePassword also clears a forced password reset.
+	UpdatePassword(id, passwordHash string) error
 	GetAll() ([]*User, error)
+
+	// List returns a page of users, newest first, and the number of users
+	// matching the filter.
+	List(f UserFilter) ([]*User, int64, error)
+
+	SetDisabled(id string, disabled bool) error
+
+	// Delete removes the user with its role and scope assignments, second
+	// factors and passkeys.
+	Delete(id string) error
+
+	// SetTenant moves the user to another tenant, dropping its role, scope
+	// and group assignments, which never cross tenants.
+	SetTenant(id, tenantID string) error
+
+	// ForTenant returns a view of the repository restricted to one tenant:
+	// users of other tenants are not found and new users join the tenant.
+	ForTenant(tenantID string) UserRepository
 }
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebAuthnSessionNotFound   = errors.New("invalid or expired webauthn ceremony")
+	ErrWebAuthnCredentialExists  = errors.New("credential already registered")
+	ErrWebAuthnCredentialUnknown = errors.New("unknown credential")
+)
+
+// WebAuthnCredential is a passkey or security key registered by a user.
+type WebAuthnCredential struct {
+	ID           string
+	UserID       string
+	CredentialID []byte
+	PublicKey    []byte // COSE_Key
+	SignCount    uint32
+	AAGUID       []byte
+	Name         string
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+type WebAuthnCredentialRepository interface {
+	CreateCredential(c *WebAuthnCredential) error
+	ListCredentials(userID string) ([]WebAuthnCredential, error)
+
+	// FindCredential returns nil, nil for an unknown credential ID.
+	FindCredential(credentialID []byte) (*WebAuthnCredential, error)
+
+	// UpdateSignCount stores the counter of a successful assertion.
+	UpdateSignCount(id string, count uint32, usedAt time.Time) error
+}
+
+// WebAuthnSession is the state kept between the two calls of a ceremony.
+type WebAuthnSession struct {
+	Ceremony  string `json:"ceremony"` // "registration" or "login"
+	Challenge []byte `json:"challenge"`
+
+	// UserID is the registering user, or the user named at login. Empty for a
+	// discoverable (username-less) login.
+	UserID string `json:"user_id,omitempty"`
+}
+
+// WebAuthnSessionStore keeps ceremonies in flight. Consume is single-use and
+// yields ErrWebAuthnSessionNotFound for unknown or expired sessions.
+type WebAuthnSessionStore interface {
+	Save(ctx context.Context, id string, s WebAuthnSession, ttl time.Duration) error
+	Consume(ctx context.Context, id string) (WebAuthnSession, error)
+}
@@ -15,6 +15,8 @@ const (
 	ErrorTypeConflict       ErrorType = "conflict_error"
 	ErrorTypeInternal       ErrorType = "internal_error"
 	ErrorTypeBadRequest     ErrorType = "bad_request"
+	ErrorTypeLocked         ErrorType = "locked_error"
+	ErrorTypeRateLimit      ErrorType = "rate_limit_error"
 )
 
 type APIError struct {
@@ -78,6 +80,16 @@ func Conflict(w http.ResponseWriter, message string) {
 	WriteError(w, http.StatusConflict, ErrorTypeConflict, message)
 }
 
+// Locked answers 423. Retry-After, when known, is the caller's to set.
+func Locked(w http.ResponseWriter, message string) {
+	WriteError(w, http.StatusLocked, ErrorTypeLocked, message)
+}
+
+// TooManyRequests answers 429. Retry-After, when known, is the caller's to set.
+func TooManyRequests(w http.ResponseWriter, message string) {
+	WriteError(w, http.StatusTooManyRequests, ErrorTypeRateLimit, message)
+}
+
 func InternalError(w http.ResponseWriter, message string) {
 	WriteError(w, http.StatusInternalServerError, ErrorTypeInternal, message)
 }
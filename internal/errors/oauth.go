@@ -0,0 +1,26 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// OAuthErrorResponse is the RFC 6749 (section 5.2) error body used by the OAuth endpoints.
+type OAuthErrorResponse struct {
+	Error            string `json:"error" example:"invalid_grant"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// OAuthError writes an RFC 6749 error response. OAuth clients expect this shape
+// instead of APIError on the token endpoint.
+func OAuthError(w http.ResponseWriter, statusCode int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// AuthCodeStore keeps OAuth authorization codes on Redis. Only a hash of the
+// code is used as key, so a Redis dump does not leak redeemable codes.
+type AuthCodeStore struct {
+	rdb *redis.Client
+}
+
+func NewAuthCodeStore(rdb *redis.Client) *AuthCodeStore {
+	return &AuthCodeStore{rdb: rdb}
+}
+
+func authCodeKey(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return "auth:code:" + hex.EncodeToString(sum[:])
+}
+
+func (s *AuthCodeStore) Save(ctx context.Context, code string, ac domain.AuthorizationCode, ttl time.Duration) error {
+	b, err := json.Marshal(ac)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, authCodeKey(code), b, ttl).Err()
+}
+
+// Consume atomically reads and deletes the code (GETDEL).
+func (s *AuthCodeStore) Consume(ctx context.Context, code string) (*domain.AuthorizationCode, error) {
+	b, err := s.rdb.GetDel(ctx, authCodeKey(code)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrAuthCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ac domain.AuthorizationCode
+	if err := json.Unmarshal(b, &ac); err != nil {
+		return nil, err
+	}
+	return &ac, nil
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LoginAttemptStore keeps failed-login counters and lockouts on Redis:
+//
+//	auth:login:fail:<key>     failures in the current window
+//	auth:login:lock:<key>     present while locked (TTL = time left)
+//	auth:login:lockouts:<key> locks so far, for the exponential backoff
+type LoginAttemptStore struct {
+	rdb *redis.Client
+}
+
+func NewLoginAttemptStore(rdb *redis.Client) *LoginAttemptStore {
+	return &LoginAttemptStore{rdb: rdb}
+}
+
+func loginFailKey(key string) string     { return "auth:login:fail:" + key }
+func loginLockKey(key string) string     { return "auth:login:lock:" + key }
+func loginLockoutsKey(key string) string { return "auth:login:lockouts:" + key }
+
+func (s *LoginAttemptStore) Locked(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.rdb.PTTL(ctx, loginLockKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 { // -2: no lock; -1 can't happen (always set with a TTL)
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *LoginAttemptStore) Fail(ctx context.Context, key string, window time.Duration) (int, error) {
+	var incr *redis.IntCmd
+	_, err := s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		incr = p.Incr(ctx, loginFailKey(key))
+		p.ExpireNX(ctx, loginFailKey(key), window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+func (s *LoginAttemptStore) Lock(ctx context.Context, key string, base, max, memory time.Duration) (time.Duration, error) {
+	var incr *redis.IntCmd
+	_, err := s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		incr = p.Incr(ctx, loginLockoutsKey(key))
+		p.Expire(ctx, loginLockoutsKey(key), memory)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	d := base
+	for n := incr.Val(); n > 1 && d < max; n-- {
+		d *= 2
+	}
+	d = min(d, max)
+
+	_, err = s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, loginLockKey(key), 1, d)
+		p.Del(ctx, loginFailKey(key))
+		return nil
+	})
+	return d, err
+}
+
+func (s *LoginAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, loginFailKey(key), loginLockKey(key), loginLockoutsKey(key)).Err()
+}
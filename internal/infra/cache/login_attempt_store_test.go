@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptStoreBackoff(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	s := NewLoginAttemptStore(rdb)
+	ctx := context.Background()
+	const base, max = time.Minute, 5 * time.Minute
+
+	// Each lockout doubles the previous one, up to max.
+	for i, want := range []time.Duration{base, 2 * base, 4 * base, max, max} {
+		d, err := s.Lock(ctx, "acct:a", base, max, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != want {
+			t.Errorf("lockout %d = %v, want %v", i+1, d, want)
+		}
+		if left, _ := s.Locked(ctx, "acct:a"); left <= 0 || left > want {
+			t.Errorf("lockout %d: Locked() = %v, want up to %v", i+1, left, want)
+		}
+	}
+
+	mr.FastForward(max)
+	if left, _ := s.Locked(ctx, "acct:a"); left != 0 {
+		t.Errorf("Locked() after the lock expired = %v, want 0", left)
+	}
+
+	// The backoff is forgotten once memory passes without a lockout.
+	mr.FastForward(time.Hour)
+	if d, _ := s.Lock(ctx, "acct:a", base, max, time.Hour); d != base {
+		t.Errorf("lockout after memory expired = %v, want %v", d, base)
+	}
+
+	if err := s.Reset(ctx, "acct:a"); err != nil {
+		t.Fatal(err)
+	}
+	if left, _ := s.Locked(ctx, "acct:a"); left != 0 {
+		t.Errorf("Locked() after Reset = %v, want 0", left)
+	}
+	if d, _ := s.Lock(ctx, "acct:a", base, max, time.Hour); d != base {
+		t.Errorf("lockout after Reset = %v, want %v", d, base)
+	}
+}
+
+func TestLoginAttemptStoreFailures(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	s := NewLoginAttemptStore(rdb)
+	ctx := context.Background()
+
+	fail := func(key string) int {
+		t.Helper()
+		n, err := s.Fail(ctx, key, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	for want := 1; want <= 3; want++ {
+		if n := fail("acct:a"); n != want {
+			t.Errorf("Fail() = %d, want %d", n, want)
+		}
+	}
+	if n := fail("ip:10.0.0.1"); n != 1 {
+		t.Errorf("Fail(other key) = %d, want 1", n)
+	}
+
+	// The window starts at the first failure and is not extended by later ones.
+	mr.FastForward(30 * time.Second)
+	fail("acct:a")
+	mr.FastForward(31 * time.Second)
+	if n := fail("acct:a"); n != 1 {
+		t.Errorf("Fail() after the window = %d, want 1", n)
+	}
+
+	// Locking starts the count over.
+	if _, err := s.Lock(ctx, "acct:a", time.Minute, time.Hour, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if n := fail("acct:a"); n != 1 {
+		t.Errorf("Fail() after Lock = %d, want 1", n)
+	}
+}
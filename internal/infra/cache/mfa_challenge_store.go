@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// MFAChallengeStore keeps MFA login challenges on Redis, keyed by their hash.
+// Each challenge is a hash with the user and the attempts made.
+type MFAChallengeStore struct {
+	rdb *redis.Client
+}
+
+func NewMFAChallengeStore(rdb *redis.Client) *MFAChallengeStore {
+	return &MFAChallengeStore{rdb: rdb}
+}
+
+func mfaChallengeKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "auth:mfa:challenge:" + hex.EncodeToString(sum[:])
+}
+
+func (s *MFAChallengeStore) Save(ctx context.Context, token, userID string, ttl time.Duration) error {
+	key := mfaChallengeKey(token)
+	_, err := s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, key, "user", userID, "attempts", 0)
+		p.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (s *MFAChallengeStore) Attempt(ctx context.Context, token string) (string, int, error) {
+	key := mfaChallengeKey(token)
+	var (
+		incr *redis.IntCmd
+		user *redis.StringCmd
+	)
+	_, err := s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		incr = p.HIncrBy(ctx, key, "attempts", 1)
+		user = p.HGet(ctx, key, "user")
+		return nil
+	})
+	if user != nil && errors.Is(user.Err(), redis.Nil) {
+		// HINCRBY recreated an expired challenge: drop it again.
+		_ = s.rdb.Del(ctx, key).Err()
+		return "", 0, domain.ErrMFAChallengeNotFound
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return user.Val(), int(incr.Val()), nil
+}
+
+func (s *MFAChallengeStore) Delete(ctx context.Context, token string) error {
+	return s.rdb.Del(ctx, mfaChallengeKey(token)).Err()
+}
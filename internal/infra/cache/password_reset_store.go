@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// PasswordResetStore keeps password reset tokens on Redis, keyed by their hash
+// like authorization codes.
+type PasswordResetStore struct {
+	rdb *redis.Client
+}
+
+func NewPasswordResetStore(rdb *redis.Client) *PasswordResetStore {
+	return &PasswordResetStore{rdb: rdb}
+}
+
+func passwordResetKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "auth:pwreset:" + hex.EncodeToString(sum[:])
+}
+
+func passwordResetUserKey(userID string) string { return "auth:pwreset:user:" + userID } // value: token key
+
+// Save stores the token and drops the previous pending token of the user.
+func (s *PasswordResetStore) Save(ctx context.Context, token, userID string, ttl time.Duration) error {
+	prev, err := s.rdb.Get(ctx, passwordResetUserKey(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	key := passwordResetKey(token)
+	_, err = s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		if prev != "" {
+			p.Del(ctx, prev)
+		}
+		p.Set(ctx, key, userID, ttl)
+		p.Set(ctx, passwordResetUserKey(userID), key, ttl)
+		return nil
+	})
+	return err
+}
+
+// Consume atomically reads and deletes the token (GETDEL).
+func (s *PasswordResetStore) Consume(ctx context.Context, token string) (string, error) {
+	userID, err := s.rdb.GetDel(ctx, passwordResetKey(token)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", domain.ErrPasswordResetNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	_ = s.rdb.Del(ctx, passwordResetUserKey(userID)).Err()
+	return userID, nil
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// rate returns the refill rate of a bucket in tokens per millisecond.
+func rate(l domain.RateLimit) float64 {
+	return float64(l.Limit) / float64(l.Window.Milliseconds())
+}
+
+// bucketResult describes a bucket left with tokens after a call.
+func bucketResult(l domain.RateLimit, tokens float64, allowed bool) domain.RateLimitResult {
+	perMs := rate(l)
+	res := domain.RateLimitResult{
+		Allowed:   allowed,
+		Limit:     l.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration(math.Ceil((float64(l.Limit)-tokens)/perMs)) * time.Millisecond,
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration(math.Ceil((1-tokens)/perMs)) * time.Millisecond
+	}
+	return res
+}
+
+// takeToken refills a bucket holding tokens since its last use elapsedMs ago
+// and takes one token when there is one.
+func takeToken(l domain.RateLimit, tokens float64, elapsedMs int64) (float64, bool) {
+	tokens = math.Min(float64(l.Limit), tokens+float64(max(elapsedMs, 0))*rate(l))
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+// tokenBucketScript mirrors takeToken on Redis, atomically. The bucket is a
+// hash {t: tokens, ts: last use in ms} that expires once it would be full.
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local b = redis.call('HMGET', KEYS[1], 't', 'ts')
+local tokens = tonumber(b[1])
+local ts = tonumber(b[2])
+if tokens == nil or ts == nil then
+	tokens = limit
+	ts = now
+end
+
+tokens = math.min(limit, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 't', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((limit - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisRateLimiter keeps token buckets on Redis (auth:ratelimit:<key>), shared
+// by every instance. Time comes from the instances, so keep their clocks in
+// sync.
+type RedisRateLimiter struct {
+	rdb *redis.Client
+}
+
+func NewRedisRateLimiter(rdb *redis.Client) *RedisRateLimiter {
+	return &RedisRateLimiter{rdb: rdb}
+}
+
+func rateLimitKey(key string) string { return "auth:ratelimit:" + key }
+
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	args := []any{
+		limit.Limit,
+		strconv.FormatFloat(rate(limit), 'f', -1, 64), // plain decimal: not every Lua reads exponents
+		time.Now().UnixMilli(),
+	}
+	out, err := tokenBucketScript.Run(ctx, l.rdb, []string{rateLimitKey(key)}, args...).Slice()
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+	allowed, _ := out[0].(int64)
+	s, _ := out[1].(string)
+	tokens, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+	return bucketResult(limit, tokens, allowed == 1), nil
+}
+
+type memoryBucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // when the bucket is full again and can be dropped
+}
+
+// MemoryRateLimiter keeps token buckets in process. Each instance counts on
+// its own, so the effective limit is multiplied by the number of instances.
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{buckets: make(map[string]*memoryBucket), lastSweep: time.Now()}
+}
+
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(limit.Limit), last: now}
+		l.buckets[key] = b
+	}
+	tokens, allowed := takeToken(limit, b.tokens, now.Sub(b.last).Milliseconds())
+	res := bucketResult(limit, tokens, allowed)
+	b.tokens, b.last, b.full = tokens, now, now.Add(res.Reset)
+	return res, nil
+}
+
+// sweep drops the buckets that refilled, at most once a minute.
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for k, b := range l.buckets {
+		if now.After(b.full) {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// FallbackRateLimiter uses Primary and switches to Fallback for the calls
+// Primary fails, so an unreachable Redis neither opens nor closes the doors.
+type FallbackRateLimiter struct {
+	Primary  domain.RateLimiter
+	Fallback domain.RateLimiter
+}
+
+func NewFallbackRateLimiter(primary, fallback domain.RateLimiter) *FallbackRateLimiter {
+	return &FallbackRateLimiter{Primary: primary, Fallback: fallback}
+}
+
+func (l *FallbackRateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	res, err := l.Primary.Allow(ctx, key, limit)
+	if err == nil {
+		return res, nil
+	}
+	zap.L().Warn("rate limiter unavailable, using in-memory buckets", zap.Error(err))
+	return l.Fallback.Allow(ctx, key, limit)
+}
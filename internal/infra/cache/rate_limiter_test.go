@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
+
+// runBucketScript calls the Lua script with an explicit clock, as Allow does
+// with time.Now.
+func runBucketScript(t *testing.T, rdb *redis.Client, key string, l domain.RateLimit, nowMs int64) (bool, float64) {
+	t.Helper()
+	out, err := tokenBucketScript.Run(context.Background(), rdb, []string{rateLimitKey(key)},
+		l.Limit, strconv.FormatFloat(rate(l), 'f', -1, 64), nowMs).Slice()
+	if err != nil {
+		t.Fatalf("script: %v", err)
+	}
+	allowed, _ := out[0].(int64)
+	tokens, err := strconv.ParseFloat(out[1].(string), 64)
+	if err != nil {
+		t.Fatalf("tokens: %v", err)
+	}
+	return allowed == 1, tokens
+}
+
+func TestTokenBucketScriptMirrorsTakeToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   domain.RateLimit
+		elapsed []int64 // ms since the previous call; the first call creates the bucket
+		allowed []bool
+	}{
+		{
+			name:    "burst up to the limit",
+			limit:   domain.RateLimit{Limit: 3, Window: time.Minute},
+			elapsed: []int64{0, 0, 0, 0, 0},
+			allowed: []bool{true, true, true, false, false},
+		},
+		{
+			name:    "refills at limit per window",
+			limit:   domain.RateLimit{Limit: 2, Window: 2048 * time.Millisecond}, // a token every 1024ms, exact in floating point
+			elapsed: []int64{0, 0, 0, 1023, 1, 512, 512},
+			allowed: []bool{true, true, false, false, true, false, true},
+		},
+		{
+			name:    "never holds more than the limit",
+			limit:   domain.RateLimit{Limit: 2, Window: time.Second},
+			elapsed: []int64{0, 3600000, 0, 0},
+			allowed: []bool{true, true, true, false},
+		},
+		{
+			name:    "clock going backwards adds nothing",
+			limit:   domain.RateLimit{Limit: 1, Window: time.Second},
+			elapsed: []int64{0, -5000, 0},
+			allowed: []bool{true, false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, rdb := newTestRedis(t)
+			now := int64(1_700_000_000_000)
+			mem := float64(tt.limit.Limit)
+			for i, e := range tt.elapsed {
+				now += e
+				allowed, tokens := runBucketScript(t, rdb, "k", tt.limit, now)
+
+				var memAllowed bool
+				mem, memAllowed = takeToken(tt.limit, mem, e)
+				if allowed != tt.allowed[i] || memAllowed != tt.allowed[i] {
+					t.Fatalf("call %d: redis allowed = %v, memory allowed = %v, want %v", i, allowed, memAllowed, tt.allowed[i])
+				}
+				if math.Abs(tokens-mem) > 1e-9 {
+					t.Fatalf("call %d: redis tokens = %v, memory tokens = %v", i, tokens, mem)
+				}
+			}
+		})
+	}
+}
+
+func TestRedisRateLimiter(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	l := NewRedisRateLimiter(rdb)
+	limit := domain.RateLimit{Limit: 2, Window: time.Minute}
+	ctx := context.Background()
+
+	for i, want := range []int{1, 0} {
+		res, err := l.Allow(ctx, "a", limit)
+		if err != nil || !res.Allowed || res.Remaining != want || res.Limit != 2 {
+			t.Fatalf("call %d: %+v, %v", i, res, err)
+		}
+	}
+	res, err := l.Allow(ctx, "a", limit)
+	if err != nil || res.Allowed {
+		t.Fatalf("over the limit: %+v, %v", res, err)
+	}
+	// One token comes back every 30s.
+	if res.RetryAfter <= 29*time.Second || res.RetryAfter > 30*time.Second || res.Reset > time.Minute {
+		t.Errorf("RetryAfter = %v, Reset = %v", res.RetryAfter, res.Reset)
+	}
+	if ttl := mr.TTL(rateLimitKey("a")); ttl <= 0 || ttl > time.Minute+time.Second {
+		t.Errorf("bucket TTL = %v, want about the time to refill", ttl)
+	}
+
+	if res, _ := l.Allow(ctx, "b", limit); !res.Allowed || res.Remaining != 1 {
+		t.Errorf("other key shares the bucket: %+v", res)
+	}
+}
+
+type failingRateLimiter struct{}
+
+func (failingRateLimiter) Allow(context.Context, string, domain.RateLimit) (domain.RateLimitResult, error) {
+	return domain.RateLimitResult{}, errors.New("redis down")
+}
+
+func TestFallbackRateLimiter(t *testing.T) {
+	l := NewFallbackRateLimiter(failingRateLimiter{}, NewMemoryRateLimiter())
+	limit := domain.RateLimit{Limit: 1, Window: time.Minute}
+
+	if res, err := l.Allow(context.Background(), "k", limit); err != nil || !res.Allowed {
+		t.Fatalf("first call: %+v, %v", res, err)
+	}
+	if res, err := l.Allow(context.Background(), "k", limit); err != nil || res.Allowed {
+		t.Fatalf("second call: %+v, %v", res, err)
+	}
+}
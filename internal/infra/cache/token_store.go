@@ -2,13 +2,24 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
 	"github.com/redis/go-redis/v9"
 )
 
-// TokenStore handles token-related keys on Redis (refresh and blacklist).
+// TokenStore handles token-related keys on Redis: refresh tokens, the access
+// blacklist and sessions.
+//
+// A session is a refresh token family. It holds the single refresh JTI that may
+// currently be rotated; rotating consumes that JTI and remembers it until it
+// would have expired, so a replay can be told apart from an expired token.
+// Revoking a session leaves a marker behind that outlives every token of the
+// family, which also rejects the access tokens issued from it.
 type TokenStore struct {
 	rdb *redis.Client
 }
@@ -18,72 +29,227 @@ func NewTokenStore(rdb *redis.Client) *TokenStore {
 }
 
 // Key patterns. Keep them centralized to avoid typos.
-func refreshKey(jti string) string     { return fmt.Sprintf("auth:refresh:%s", jti) }
-func blacklistKey(jti string) string   { return fmt.Sprintf("auth:blacklist:%s", jti) }
-func userRefreshSet(userID string) string { return fmt.Sprintf("auth:user:%s:refresh", userID) } // optional
-
-// SetRefresh stores a refresh token JTI with TTL, value can be userID or any metadata you need.
-func (s *TokenStore) SetRefresh(ctx context.Context, jti string, userID string, ttl time.Duration) error {
-	key := refreshKey(jti)
-	if err := s.rdb.Set(ctx, key, userID, ttl).Err(); err != nil {
-		return err
+func refreshKey(jti string) string        { return fmt.Sprintf("auth:refresh:%s", jti) } // value: session ID
+func rotatedKey(jti string) string        { return fmt.Sprintf("auth:rotated:%s", jti) } // value: session ID
+func blacklistKey(jti string) string      { return fmt.Sprintf("auth:blacklist:%s", jti) }
+func sessionKey(id string) string         { return fmt.Sprintf("auth:session:%s", id) } // hash
+func sessionRevokedKey(id string) string  { return fmt.Sprintf("auth:session:revoked:%s", id) }
+func userSessionSet(userID string) string { return fmt.Sprintf("auth:user:%s:sessions", userID) }
+
+// Session hash fields.
+const (
+	fieldUserID     = "user_id"
+	fieldRefreshJTI = "refresh_jti"
+	fieldUserAgent  = "user_agent"
+	fieldIP         = "ip"
+	fieldCreatedAt  = "created_at"
+	fieldLastUsedAt = "last_used_at"
+	fieldExpiresAt  = "expires_at"
+)
+
+// SetRefresh stores a refresh token JTI with TTL as the current token of the
+// session, creating the session on first use and refreshing its metadata after.
+func (s *TokenStore) SetRefresh(ctx context.Context, jti string, sess domain.Session, ttl time.Duration) error {
+	now := sess.LastUsedAt
+	_, err := s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, refreshKey(jti), sess.ID, ttl)
+		p.HSetNX(ctx, sessionKey(sess.ID), fieldCreatedAt, now.Unix())
+		p.HSet(ctx, sessionKey(sess.ID),
+			fieldUserID, sess.UserID,
+			fieldRefreshJTI, jti,
+			fieldUserAgent, sess.UserAgent,
+			fieldIP, sess.IP,
+			fieldLastUsedAt, now.Unix(),
+			fieldExpiresAt, now.Add(ttl).Unix(),
+		)
+		p.Expire(ctx, sessionKey(sess.ID), ttl)
+		p.SAdd(ctx, userSessionSet(sess.UserID), sess.ID)
+		p.Expire(ctx, userSessionSet(sess.UserID), ttl)
+		return nil
+	})
+	return err
+}
+
+// ConsumeRefresh deletes the refresh JTI and reports whether it was still
+// active. Only one concurrent rotation can win the delete.
+func (s *TokenStore) ConsumeRefresh(ctx context.Context, jti, sessionID string) (bool, error) {
+	var del, revoked *redis.IntCmd
+	_, err := s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		del = p.Del(ctx, refreshKey(jti))
+		if sessionID != "" {
+			revoked = p.Exists(ctx, sessionRevokedKey(sessionID))
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
 	}
-	// Optional: track all refresh JTIs per user to revoke them all later
-	_ = s.rdb.SAdd(ctx, userRefreshSet(userID), jti).Err()
-	_ = s.rdb.Expire(ctx, userRefreshSet(userID), ttl).Err()
-	return nil
+	if del.Val() != 1 {
+		return false, nil
+	}
+	return revoked == nil || revoked.Val() == 0, nil
 }
 
-// DeleteRefresh removes a refresh token JTI (invalidate the token).
-func (s *TokenStore) DeleteRefresh(ctx context.Context, jti string, userID string) error {
-	if err := s.rdb.Del(ctx, refreshKey(jti)).Err(); err != nil {
-		return err
+// MarkRotated remembers a consumed refresh JTI for the rest of its lifetime.
+func (s *TokenStore) MarkRotated(ctx context.Context, jti, sessionID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
 	}
-	// Optional: keep the set tidy
-	if userID != "" {
-		_ = s.rdb.SRem(ctx, userRefreshSet(userID), jti).Err()
+	return s.rdb.Set(ctx, rotatedKey(jti), sessionID, ttl).Err()
+}
+
+// RotatedSession returns the session of an already rotated refresh JTI, or ""
+// if the JTI was never rotated.
+func (s *TokenStore) RotatedSession(ctx context.Context, jti string) (string, error) {
+	id, err := s.rdb.Get(ctx, rotatedKey(jti)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
 	}
-	return nil
+	return id, err
 }
 
-// IsAccessRevoked checks if access token JTI is blacklisted.
-func (s *TokenStore) IsAccessRevoked(ctx context.Context, jti string) (bool, error) {
-	key := blacklistKey(jti)
-	exists, err := s.rdb.Exists(ctx, key).Result()
+// RefreshActive reports whether a refresh token JTI can still be rotated.
+func (s *TokenStore) RefreshActive(ctx context.Context, jti string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, refreshKey(jti)).Result()
 	if err != nil {
 		return false, err
 	}
-	return exists == 1, nil
+	return n == 1, nil
 }
 
-// BlacklistAccess marks an access token JTI as revoked until its natural expiration.
-func (s *TokenStore) BlacklistAccess(ctx context.Context, jti string, ttl time.Duration) error {
-	return s.rdb.Set(ctx, blacklistKey(jti), "1", ttl).Err()
+// DeleteRefresh removes a refresh token JTI (invalidate the token).
+func (s *TokenStore) DeleteRefresh(ctx context.Context, jti string) error {
+	return s.rdb.Del(ctx, refreshKey(jti)).Err()
 }
 
-// RevokeAllUserRefresh invalidates all refresh tokens for a user (optional bulk).
-func (s *TokenStore) RevokeAllUserRefresh(ctx context.Context, userID string) error {
-	setKey := userRefreshSet(userID)
-	jtis, err := s.rdb.SMembers(ctx, setKey).Result()
+// GetSession loads a session. found is false once it expired or was revoked.
+func (s *TokenStore) GetSession(ctx context.Context, id string) (sess domain.Session, found bool, err error) {
+	m, err := s.rdb.HGetAll(ctx, sessionKey(id)).Result()
 	if err != nil {
-		return err
+		return domain.Session{}, false, err
 	}
-	if len(jtis) == 0 {
-		return nil
+	if len(m) == 0 {
+		return domain.Session{}, false, nil
+	}
+	return sessionFromHash(id, m), true, nil
+}
+
+// ListUserSessions returns the live sessions of a user, most recently used
+// first. Expired sessions are dropped from the user's set on the way.
+func (s *TokenStore) ListUserSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	ids, err := s.rdb.SMembers(ctx, userSessionSet(userID)).Result()
+	if err != nil {
+		return nil, err
 	}
-	// Delete all refresh keys
-	keys := make([]string, 0, len(jtis))
-	for _, j := range jtis {
-		keys = append(keys, refreshKey(j))
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err = s.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = p.HGetAll(ctx, sessionKey(id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if len(keys) > 0 {
-		if err := s.rdb.Del(ctx, keys...).Err(); err != nil {
-			return err
+
+	out := make([]domain.Session, 0, len(ids))
+	var stale []any
+	for i, id := range ids {
+		m := cmds[i].Val()
+		if len(m) == 0 {
+			stale = append(stale, id)
+			continue
 		}
+		out = append(out, sessionFromHash(id, m))
+	}
+	if len(stale) > 0 {
+		_ = s.rdb.SRem(ctx, userSessionSet(userID), stale...).Err()
 	}
-	// Clear the set
-	if err := s.rdb.Del(ctx, setKey).Err(); err != nil {
+
+	sort.Slice(out, func(i, j int) bool { return out[i].LastUsedAt.After(out[j].LastUsedAt) })
+	return out, nil
+}
+
+// RevokeSession deletes the session and its current refresh token and flags it
+// revoked for ttl, which must cover the longest-lived token of the session.
+func (s *TokenStore) RevokeSession(ctx context.Context, id string, ttl time.Duration) error {
+	vals, err := s.rdb.HMGet(ctx, sessionKey(id), fieldUserID, fieldRefreshJTI).Result()
+	if err != nil {
 		return err
 	}
-	return nil
+	userID, _ := vals[0].(string)
+	jti, _ := vals[1].(string)
+
+	_, err = s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, sessionRevokedKey(id), "1", ttl)
+		if jti != "" {
+			p.Del(ctx, refreshKey(jti))
+		}
+		p.Del(ctx, sessionKey(id))
+		if userID != "" {
+			p.SRem(ctx, userSessionSet(userID), id)
+		}
+		return nil
+	})
+	return err
+}
+
+// RevokeAllUserSessions revokes every session of a user but exceptID (if not
+// empty) and returns how many were revoked.
+func (s *TokenStore) RevokeAllUserSessions(ctx context.Context, userID, exceptID string, ttl time.Duration) (int, error) {
+	ids, err := s.rdb.SMembers(ctx, userSessionSet(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, id := range ids {
+		if id == exceptID {
+			continue
+		}
+		if err := s.RevokeSession(ctx, id, ttl); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// IsAccessRevoked checks if access token JTI is blacklisted or, for tokens
+// issued to a session, whether the session has been revoked.
+func (s *TokenStore) IsAccessRevoked(ctx context.Context, jti string, sessionID string) (bool, error) {
+	keys := []string{blacklistKey(jti)}
+	if sessionID != "" {
+		keys = append(keys, sessionRevokedKey(sessionID))
+	}
+	exists, err := s.rdb.Exists(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	return exists > 0, nil
+}
+
+// BlacklistAccess marks an access token JTI as revoked until its natural expiration.
+func (s *TokenStore) BlacklistAccess(ctx context.Context, jti string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, blacklistKey(jti), "1", ttl).Err()
+}
+
+func sessionFromHash(id string, m map[string]string) domain.Session {
+	return domain.Session{
+		ID:         id,
+		UserID:     m[fieldUserID],
+		UserAgent:  m[fieldUserAgent],
+		IP:         m[fieldIP],
+		CreatedAt:  unixField(m[fieldCreatedAt]),
+		LastUsedAt: unixField(m[fieldLastUsedAt]),
+		ExpiresAt:  unixField(m[fieldExpiresAt]),
+	}
+}
+
+func unixField(v string) time.Time {
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
 }
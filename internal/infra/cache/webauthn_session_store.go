@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// WebAuthnSessionStore keeps WebAuthn ceremonies (the challenge and who it
+// was issued for) on Redis between their begin and finish calls.
+type WebAuthnSessionStore struct {
+	rdb *redis.Client
+}
+
+func NewWebAuthnSessionStore(rdb *redis.Client) *WebAuthnSessionStore {
+	return &WebAuthnSessionStore{rdb: rdb}
+}
+
+func webAuthnSessionKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return "auth:webauthn:" + hex.EncodeToString(sum[:])
+}
+
+func (s *WebAuthnSessionStore) Save(ctx context.Context, id string, ws domain.WebAuthnSession, ttl time.Duration) error {
+	b, err := json.Marshal(ws)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, webAuthnSessionKey(id), b, ttl).Err()
+}
+
+// Consume atomically reads and deletes the session (GETDEL): a challenge is
+// answered once.
+func (s *WebAuthnSessionStore) Consume(ctx context.Context, id string) (domain.WebAuthnSession, error) {
+	b, err := s.rdb.GetDel(ctx, webAuthnSessionKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return domain.WebAuthnSession{}, domain.ErrWebAuthnSessionNotFound
+	}
+	if err != nil {
+		return domain.WebAuthnSession{}, err
+	}
+	var ws domain.WebAuthnSession
+	if err := json.Unmarshal(b, &ws); err != nil {
+		return domain.WebAuthnSession{}, err
+	}
+	return ws, nil
+}
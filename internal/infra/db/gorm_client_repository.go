@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
@@ -8,30 +9,110 @@ import (
 	"gorm.io/gorm"
 )
 
-type GormClientRepository struct{ db *gorm.DB }
+type GormClientRepository struct {
+	db     *gorm.DB
+	tenant tenantFilter
+}
 
 func NewGormClientRepository(db *gorm.DB) *GormClientRepository { return &GormClientRepository{db: db} }
 
+// ForTenant implements domain.ClientRepository.
+func (r *GormClientRepository) ForTenant(tenantID string) domain.ClientRepository {
+	return &GormClientRepository{db: r.db, tenant: forTenant(tenantID)}
+}
+
+// clients starts a query on the clients the repository can see.
+func (r *GormClientRepository) clients() *gorm.DB {
+	return r.tenant.apply(r.db.Model(&model.Client{}), "tenant_id")
+}
+
 func (r *GormClientRepository) FindByClientID(clientID string) (*domain.Client, error) {
 	var m model.Client
-	if err := r.db.Where("client_id = ?", clientID).First(&m).Error; err != nil {
+	err := r.clients().Where("client_id = ?", clientID).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
 		return nil, err
 	}
+	return toDomainClient(&m), nil
+}
+
+func (r *GormClientRepository) Create(c *domain.Client) error {
+	if r.tenant.scoped {
+		c.TenantID = r.tenant.id
+	}
+	m := model.Client{
+		ClientID:        c.ClientID,
+		TenantID:        c.TenantID,
+		SecretHash:      c.SecretHash,
+		Name:            c.Name,
+		AllowedScopes:   strings.Join(c.AllowedScopes, ","),
+		AllowedAudience: strings.Join(c.AllowedAudience, ","),
+		Active:          c.Active,
+		RedirectURIs:    strings.Join(c.RedirectURIs, ","),
+		Public:          c.Public,
+	}
+	if err := r.db.Create(&m).Error; err != nil {
+		return err
+	}
+	c.ID, c.CreatedAt = m.ID, m.CreatedAt
+	return nil
+}
+
+func (r *GormClientRepository) List() ([]*domain.Client, error) {
+	var ms []model.Client
+	if err := r.clients().Order("created_at, client_id").Find(&ms).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*domain.Client, len(ms))
+	for i := range ms {
+		out[i] = toDomainClient(&ms[i])
+	}
+	return out, nil
+}
+
+func (r *GormClientRepository) Update(c *domain.Client) error {
+	// A map, so that false and empty values are written too.
+	return r.clients().Where("id = ?", c.ID).Updates(map[string]any{
+		"secret_hash":                c.SecretHash,
+		"name":                       c.Name,
+		"allowed_scopes":             strings.Join(c.AllowedScopes, ","),
+		"allowed_audience":           strings.Join(c.AllowedAudience, ","),
+		"active":                     c.Active,
+		"redirect_uris":              strings.Join(c.RedirectURIs, ","),
+		"public":                     c.Public,
+		"previous_secret_hash":       c.PreviousSecretHash,
+		"previous_secret_expires_at": c.PreviousSecretExpiresAt,
+	}).Error
+}
+
+func toDomainClient(m *model.Client) *domain.Client {
 	return &domain.Client{
-		ID:              m.ID,
-		ClientID:        m.ClientID,
-		SecretHash:      m.SecretHash,
-		Name:            m.Name,
-		AllowedScopes:   splitCSV(m.AllowedScopes),
-		AllowedAudience: splitCSV(m.AllowedAudience),
-		Active:          m.Active,
-	}, nil
+		ID:                      m.ID,
+		ClientID:                m.ClientID,
+		TenantID:                m.TenantID,
+		SecretHash:              m.SecretHash,
+		Name:                    m.Name,
+		AllowedScopes:           splitCSV(m.AllowedScopes),
+		AllowedAudience:         splitCSV(m.AllowedAudience),
+		Active:                  m.Active,
+		RedirectURIs:            splitCSV(m.RedirectURIs),
+		Public:                  m.Public,
+		PreviousSecretHash:      m.PreviousSecretHash,
+		PreviousSecretExpiresAt: m.PreviousSecretExpiresAt,
+		CreatedAt:               m.CreatedAt,
+	}
 }
 
 func splitCSV(s string) []string {
 	s = strings.TrimSpace(s)
-	if s == "" { return nil }
+	if s == "" {
+		return nil
+	}
 	parts := strings.Split(s, ",")
-	for i := range parts { parts[i] = strings.TrimSpace(parts[i]) }
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
 	return parts
 }
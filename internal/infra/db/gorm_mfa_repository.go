@@ -0,0 +1,86 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/db/model"
+	"gorm.io/gorm"
+)
+
+type GormMFARepository struct {
+	db *gorm.DB
+}
+
+func NewGormMFARepository(db *gorm.DB) *GormMFARepository {
+	return &GormMFARepository{db: db}
+}
+
+func (r *GormMFARepository) FindMFA(userID string) (*domain.MFASettings, error) {
+	var m model.UserMFA
+	err := r.db.Where("user_id = ?", userID).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain.MFASettings{
+		UserID:       m.UserID,
+		TOTPSecret:   m.TOTPSecret,
+		Enabled:      m.Enabled,
+		EnabledAt:    m.EnabledAt,
+		LastUsedStep: m.LastUsedStep,
+	}, nil
+}
+
+func (r *GormMFARepository) SaveMFA(s *domain.MFASettings) error {
+	return r.db.Save(&model.UserMFA{
+		UserID:       s.UserID,
+		TOTPSecret:   s.TOTPSecret,
+		Enabled:      s.Enabled,
+		EnabledAt:    s.EnabledAt,
+		LastUsedStep: s.LastUsedStep,
+	}).Error
+}
+
+func (r *GormMFARepository) DeleteMFA(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.UserMFA{}).Error
+	})
+}
+
+func (r *GormMFARepository) UseTOTPStep(userID string, step int64) (bool, error) {
+	// Conditional update: two concurrent logins with the same code can't both win.
+	res := r.db.Model(&model.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *GormMFARepository) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+		codes := make([]model.RecoveryCode, len(hashes))
+		for i, h := range hashes {
+			codes[i] = model.RecoveryCode{UserID: userID, CodeHash: h}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *GormMFARepository) UseRecoveryCode(userID, hash string) (bool, error) {
+	res := r.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
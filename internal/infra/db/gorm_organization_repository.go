@@ -0,0 +1,60 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/db/model"
+	"gorm.io/gorm"
+)
+
+type GormOrganizationRepository struct{ db *gorm.DB }
+
+func NewGormOrganizationRepository(db *gorm.DB) *GormOrganizationRepository {
+	return &GormOrganizationRepository{db: db}
+}
+
+func (r *GormOrganizationRepository) Create(o *domain.Organization) error {
+	m := model.Organization{Slug: o.Slug, Name: o.Name}
+	if err := r.db.Create(&m).Error; err != nil {
+		return err
+	}
+	o.ID, o.CreatedAt = m.ID, m.CreatedAt
+	return nil
+}
+
+func (r *GormOrganizationRepository) List() ([]*domain.Organization, error) {
+	var ms []model.Organization
+	if err := r.db.Order("slug").Find(&ms).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*domain.Organization, len(ms))
+	for i := range ms {
+		out[i] = toDomainOrganization(&ms[i])
+	}
+	return out, nil
+}
+
+func (r *GormOrganizationRepository) FindByID(id string) (*domain.Organization, error) {
+	return r.find("id = ?", id)
+}
+
+func (r *GormOrganizationRepository) FindBySlug(slug string) (*domain.Organization, error) {
+	return r.find("slug = ?", slug)
+}
+
+func (r *GormOrganizationRepository) find(query string, arg string) (*domain.Organization, error) {
+	var m model.Organization
+	err := r.db.Where(query, arg).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toDomainOrganization(&m), nil
+}
+
+func toDomainOrganization(m *model.Organization) *domain.Organization {
+	return &domain.Organization{ID: m.ID, Slug: m.Slug, Name: m.Name, CreatedAt: m.CreatedAt}
+}
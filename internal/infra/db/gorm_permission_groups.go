@@ -0,0 +1,219 @@
+package db
+
+import (
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateGroup implements domain.PermissionRepository.
+func (g *GormPermissionRepository) CreateGroup(key, desc string) (domain.Group, error) {
+	m := model.Group{TenantID: g.tenant.id, Key: key, Description: desc}
+	if err := g.db.Create(&m).Error; err != nil {
+		return domain.Group{}, err
+	}
+	return toDomainGroup(m), nil
+}
+
+// ListGroups implements domain.PermissionRepository.
+func (g *GormPermissionRepository) ListGroups() ([]domain.Group, error) {
+	var rows []model.Group
+	if err := g.tenant.apply(g.db, "tenant_id").Order("key").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]domain.Group, 0, len(rows))
+	for _, m := range rows {
+		out = append(out, toDomainGroup(m))
+	}
+	return out, nil
+}
+
+// GetGroup implements domain.PermissionRepository.
+func (g *GormPermissionRepository) GetGroup(groupID string) (domain.GroupGrants, error) {
+	if err := g.visible(&model.Group{}, "id", domain.ErrGroupNotFound, groupID); err != nil {
+		return domain.GroupGrants{}, err
+	}
+	var m model.Group
+	if err := g.db.Where("id = ?", groupID).Limit(1).Find(&m).Error; err != nil {
+		return domain.GroupGrants{}, err
+	}
+	if m.ID == "" {
+		return domain.GroupGrants{}, domain.ErrGroupNotFound
+	}
+	out := domain.GroupGrants{Group: toDomainGroup(m)}
+
+	if err := g.db.Model(&model.GroupMember{}).Where("group_id = ?", groupID).Order("user_id").
+		Pluck("user_id", &out.Members).Error; err != nil {
+		return domain.GroupGrants{}, err
+	}
+	if err := g.db.Table("roles r").
+		Joins("JOIN group_roles gr ON gr.role_id = r.id").
+		Where("gr.group_id = ?", groupID).
+		Order("r.key").
+		Pluck("r.key", &out.Roles).Error; err != nil {
+		return domain.GroupGrants{}, err
+	}
+	if err := g.db.Table("scopes s").
+		Joins("JOIN group_scopes gs ON gs.scope_id = s.id").
+		Where("gs.group_id = ?", groupID).
+		Order("s.key").
+		Pluck("s.key", &out.Scopes).Error; err != nil {
+		return domain.GroupGrants{}, err
+	}
+	return out, nil
+}
+
+// DeleteGroup implements domain.PermissionRepository.
+func (g *GormPermissionRepository) DeleteGroup(groupID string) error {
+	if err := g.visible(&model.Group{}, "id", domain.ErrGroupNotFound, groupID); err != nil {
+		return err
+	}
+	var members []string
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if members, err = groupMembers(tx, groupID); err != nil {
+			return err
+		}
+		for _, link := range []any{&model.GroupMember{}, &model.GroupRole{}, &model.GroupScope{}} {
+			if err := tx.Delete(link, "group_id = ?", groupID).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Delete(&model.Group{}, "id = ?", groupID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrGroupNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return g.invalidateUsers(members)
+}
+
+// AddUsersToGroup implements domain.PermissionRepository.
+func (g *GormPermissionRepository) AddUsersToGroup(groupID string, userIDs []string) error {
+	tenant, err := g.tenantOf(&model.Group{}, "id", groupID, domain.ErrGroupNotFound)
+	if err != nil {
+		return err
+	}
+	if err := g.inTenant(tenant, &model.User{}, "id", domain.ErrUserNotFound, userIDs); err != nil {
+		return err
+	}
+	gm := make([]model.GroupMember, len(userIDs))
+	for i, userID := range userIDs {
+		gm[i] = model.GroupMember{GroupID: groupID, UserID: userID}
+	}
+	if err := g.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&gm).Error; err != nil {
+		return err
+	}
+	return g.invalidateUsers(userIDs)
+}
+
+// RemoveUsersFromGroup implements domain.PermissionRepository.
+func (g *GormPermissionRepository) RemoveUsersFromGroup(groupID string, userIDs []string) error {
+	if err := g.visible(&model.Group{}, "id", domain.ErrGroupNotFound, groupID); err != nil {
+		return err
+	}
+	if err := g.db.Delete(&model.GroupMember{}, "group_id = ? AND user_id IN ?", groupID, userIDs).Error; err != nil {
+		return err
+	}
+	return g.invalidateUsers(userIDs)
+}
+
+// AddRolesToGroup implements domain.PermissionRepository.
+func (g *GormPermissionRepository) AddRolesToGroup(groupID string, roleIDs []string) error {
+	tenant, err := g.tenantOf(&model.Group{}, "id", groupID, domain.ErrGroupNotFound)
+	if err != nil {
+		return err
+	}
+	if err := g.inTenant(tenant, &model.Role{}, "id", domain.ErrRoleNotFound, roleIDs); err != nil {
+		return err
+	}
+	gr := make([]model.GroupRole, len(roleIDs))
+	for i, roleID := range roleIDs {
+		gr[i] = model.GroupRole{GroupID: groupID, RoleID: roleID}
+	}
+	if err := g.db.Create(&gr).Error; err != nil {
+		return err
+	}
+	return g.invalidateGroup(groupID)
+}
+
+// RemoveRolesFromGroup implements domain.PermissionRepository.
+func (g *GormPermissionRepository) RemoveRolesFromGroup(groupID string, roleIDs []string) error {
+	if err := g.visible(&model.Group{}, "id", domain.ErrGroupNotFound, groupID); err != nil {
+		return err
+	}
+	if err := g.db.Delete(&model.GroupRole{}, "group_id = ? AND role_id IN ?", groupID, roleIDs).Error; err != nil {
+		return err
+	}
+	return g.invalidateGroup(groupID)
+}
+
+// AddScopesToGroup implements domain.PermissionRepository.
+func (g *GormPermissionRepository) AddScopesToGroup(groupID string, scopeIDs []string) error {
+	tenant, err := g.tenantOf(&model.Group{}, "id", groupID, domain.ErrGroupNotFound)
+	if err != nil {
+		return err
+	}
+	if err := g.inTenant(tenant, &model.Scope{}, "id", domain.ErrScopeNotFound, scopeIDs); err != nil {
+		return err
+	}
+	gs := make([]model.GroupScope, len(scopeIDs))
+	for i, scopeID := range scopeIDs {
+		gs[i] = model.GroupScope{GroupID: groupID, ScopeID: scopeID}
+	}
+	if err := g.db.Create(&gs).Error; err != nil {
+		return err
+	}
+	return g.invalidateGroup(groupID)
+}
+
+// RemoveScopesFromGroup implements domain.PermissionRepository.
+func (g *GormPermissionRepository) RemoveScopesFromGroup(groupID string, scopeIDs []string) error {
+	if err := g.visible(&model.Group{}, "id", domain.ErrGroupNotFound, groupID); err != nil {
+		return err
+	}
+	if err := g.db.Delete(&model.GroupScope{}, "group_id = ? AND scope_id IN ?", groupID, scopeIDs).Error; err != nil {
+		return err
+	}
+	return g.invalidateGroup(groupID)
+}
+
+// ListUserGroups implements domain.PermissionRepository.
+func (g *GormPermissionRepository) ListUserGroups(userID string) ([]string, error) {
+	if err := g.visible(&model.User{}, "id", domain.ErrUserNotFound, userID); err != nil {
+		return nil, err
+	}
+	out := []string{}
+	err := g.db.Table("groups g").
+		Joins("JOIN group_members gm ON gm.group_id = g.id").
+		Where("gm.user_id = ?", userID).
+		Order("g.key").
+		Pluck("g.key", &out).Error
+	return out, err
+}
+
+func toDomainGroup(m model.Group) domain.Group {
+	return domain.Group{ID: m.ID, TenantID: m.TenantID, Key: m.Key, Desc: m.Description}
+}
+
+func groupMembers(db *gorm.DB, groupID string) ([]string, error) {
+	var userIDs []string
+	err := db.Model(&model.GroupMember{}).Where("group_id = ?", groupID).Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// invalidateGroup drops the cached grants of every member of the group.
+func (g *GormPermissionRepository) invalidateGroup(groupID string) error {
+	members, err := groupMembers(g.db, groupID)
+	if err != nil {
+		return err
+	}
+	return g.invalidateUsers(members)
+}
@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -17,6 +19,7 @@ type GormPermissionRepository struct {
 	db      *gorm.DB
 	rdb     *redis.Client
 	permTTL time.Duration
+	tenant  tenantFilter
 }
 
 func NewGormPermissionRepository(db *gorm.DB) domain.PermissionRepository {
@@ -30,6 +33,36 @@ func NewGormPermissionRepositoryWithCache(db *gorm.DB, rdb *redis.Client, ttl ti
 func userPermKey(userID string) string     { return fmt.Sprintf("auth:perm:user:%s", userID) }
 func clientPermKey(clientID string) string { return fmt.Sprintf("auth:perm:client:%s", clientID) }
 
+// The role graph is walked with recursive CTEs over role_parents. UNION (not
+// UNION ALL) keeps them finite even if a cycle slipped in.
+const (
+	// userRoleTreeCTE is every role of the user @user: assigned to them or
+	// to one of their groups, or inherited.
+	userRoleTreeCTE = `WITH RECURSIVE role_tree(role_id) AS (
+	SELECT role_id FROM user_roles WHERE user_id = @user
+	UNION
+	SELECT gr.role_id FROM group_roles gr JOIN group_members gm ON gm.group_id = gr.group_id WHERE gm.user_id = @user
+	UNION
+	SELECT rp.parent_id FROM role_parents rp JOIN role_tree t ON rp.role_id = t.role_id
+) `
+	// roleAncestorsCTE is a set of roles and every role they inherit from.
+	roleAncestorsCTE = `WITH RECURSIVE role_tree(role_id) AS (
+	SELECT id FROM roles WHERE id IN ?
+	UNION
+	SELECT rp.parent_id FROM role_parents rp JOIN role_tree t ON rp.role_id = t.role_id
+) `
+	// roleDescendantsCTE is a set of roles and every role inheriting from them.
+	roleDescendantsCTE = `WITH RECURSIVE role_tree(role_id) AS (
+	SELECT id FROM roles WHERE id IN ?
+	UNION
+	SELECT rp.role_id FROM role_parents rp JOIN role_tree t ON rp.parent_id = t.role_id
+) `
+)
+
+// roleGraphLockID is the transaction-level advisory lock serializing changes
+// of role_parents, so that two concurrent changes can't close a cycle.
+const roleGraphLockID = 0x726f6c65 // "role"
+
 type grantsPayload struct {
 	Roles  []string `json:"roles"`
 	Scopes []string `json:"scopes"`
@@ -37,15 +70,26 @@ type grantsPayload struct {
 
 // ListRoles implements domain.PermissionRepository.
 func (g *GormPermissionRepository) ListRoles() ([]domain.Role, error) {
-	var roles []domain.Role
-	if err := g.db.Find(&roles).Error; err != nil {
+	var rows []model.Role
+	if err := g.tenant.apply(g.db, "tenant_id").Order("key").Find(&rows).Error; err != nil {
 		return nil, err
 	}
-	return roles, nil
+	out := make([]domain.Role, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, domain.Role{ID: r.ID, TenantID: r.TenantID, Key: r.Key, Desc: r.Description})
+	}
+	return out, nil
 }
 
 // GrantUserScope implements domain.PermissionRepository.
 func (g *GormPermissionRepository) GrantUserScope(userID, scopeID, grantedBy string, expiresAt *time.Time) error {
+	tenant, err := g.tenantOf(&model.User{}, "id", userID, domain.ErrUserNotFound)
+	if err != nil {
+		return err
+	}
+	if err := g.inTenant(tenant, &model.Scope{}, "id", domain.ErrScopeNotFound, []string{scopeID}); err != nil {
+		return err
+	}
 	rec := model.UserScope{
 		UserID:    userID,
 		ScopeID:   scopeID,
@@ -63,6 +107,9 @@ func (g *GormPermissionRepository) GrantUserScope(userID, scopeID, grantedBy str
 
 // RevokeUserScope implements domain.PermissionRepository.
 func (g *GormPermissionRepository) RevokeUserScope(userID, scopeID string) error {
+	if err := g.visible(&model.User{}, "id", domain.ErrUserNotFound, userID); err != nil {
+		return err
+	}
 	if err := g.db.Delete(&model.UserScope{}, "user_id = ? AND scope_id = ?", userID, scopeID).Error; err != nil {
 		return err
 	}
@@ -71,6 +118,9 @@ func (g *GormPermissionRepository) RevokeUserScope(userID, scopeID string) error
 
 // ListUserRoles implements domain.PermissionRepository.
 func (g *GormPermissionRepository) ListUserRoles(userID string) ([]string, error) {
+	if err := g.visible(&model.User{}, "id", domain.ErrUserNotFound, userID); err != nil {
+		return nil, err
+	}
 	var rows []struct{ Key string }
 	q := g.db.Table("roles r").
 		Select("r.key").
@@ -88,6 +138,9 @@ func (g *GormPermissionRepository) ListUserRoles(userID string) ([]string, error
 }
 
 func (g *GormPermissionRepository) ListUserScopesEffective(userID string, now time.Time) (roles []string, scopes []string, err error) {
+	if err := g.visible(&model.User{}, "id", domain.ErrUserNotFound, userID); err != nil {
+		return nil, nil, err
+	}
 	if g.rdb != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
@@ -100,13 +153,9 @@ func (g *GormPermissionRepository) ListUserScopesEffective(userID string, now ti
 	}
 
 	var roleScopes []struct{ Key string }
-	if err = g.db.Table("scopes s").
-		Select("s.key").
-		Joins("JOIN role_scopes rs ON rs.scope_id = s.id").
-		Joins("JOIN user_roles ur ON ur.role_id = rs.role_id").
-		Where("ur.user_id = ?", userID).
-		Group("s.key").
-		Find(&roleScopes).Error; err != nil {
+	if err = g.db.Raw(userRoleTreeCTE+
+		"SELECT DISTINCT s.key FROM scopes s JOIN role_scopes rs ON rs.scope_id = s.id JOIN role_tree t ON t.role_id = rs.role_id",
+		sql.Named("user", userID)).Scan(&roleScopes).Error; err != nil {
 		return nil, nil, err
 	}
 
@@ -121,18 +170,33 @@ func (g *GormPermissionRepository) ListUserScopesEffective(userID string, now ti
 		return nil, nil, err
 	}
 
-	roles, err = g.ListUserRoles(userID)
-	if err != nil {
+	var groupScopes []struct{ Key string }
+	if err = g.db.Table("scopes s").
+		Select("s.key").
+		Joins("JOIN group_scopes gs ON gs.scope_id = s.id").
+		Joins("JOIN group_members gm ON gm.group_id = gs.group_id").
+		Where("gm.user_id = ?", userID).
+		Group("s.key").
+		Find(&groupScopes).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if err = g.db.Raw(userRoleTreeCTE+
+		"SELECT r.key FROM roles r JOIN role_tree t ON t.role_id = r.id ORDER BY r.key",
+		sql.Named("user", userID)).Scan(&roles).Error; err != nil {
 		return nil, nil, err
 	}
 
-	set := make(map[string]struct{}, len(roleScopes)+len(directScopes))
+	set := make(map[string]struct{}, len(roleScopes)+len(directScopes)+len(groupScopes))
 	for _, s := range roleScopes {
 		set[s.Key] = struct{}{}
 	}
 	for _, s := range directScopes {
 		set[s.Key] = struct{}{}
 	}
+	for _, s := range groupScopes {
+		set[s.Key] = struct{}{}
+	}
 	eff := make([]string, 0, len(set))
 	for k := range set {
 		eff = append(eff, k)
@@ -149,6 +213,13 @@ func (g *GormPermissionRepository) ListUserScopesEffective(userID string, now ti
 
 // AddRolesToUser implements domain.PermissionRepository.
 func (g *GormPermissionRepository) AddRolesToUser(userID string, roleIDs []string) error {
+	tenant, err := g.tenantOf(&model.User{}, "id", userID, domain.ErrUserNotFound)
+	if err != nil {
+		return err
+	}
+	if err := g.inTenant(tenant, &model.Role{}, "id", domain.ErrRoleNotFound, roleIDs); err != nil {
+		return err
+	}
 	ur := make([]model.UserRole, len(roleIDs))
 	for i, roleID := range roleIDs {
 		ur[i] = model.UserRole{UserID: userID, RoleID: roleID}
@@ -161,6 +232,13 @@ func (g *GormPermissionRepository) AddRolesToUser(userID string, roleIDs []strin
 
 // AddScopesToClient implements domain.PermissionRepository.
 func (g *GormPermissionRepository) AddScopesToClient(clientID string, scopeIDs []string) error {
+	tenant, err := g.tenantOf(&model.Client{}, "client_id", clientID, domain.ErrClientNotFound)
+	if err != nil {
+		return err
+	}
+	if err := g.inTenant(tenant, &model.Scope{}, "id", domain.ErrScopeNotFound, scopeIDs); err != nil {
+		return err
+	}
 	sc := make([]model.ClientScope, len(scopeIDs))
 	for i, scopeID := range scopeIDs {
 		sc[i] = model.ClientScope{ClientID: clientID, ScopeID: scopeID}
@@ -173,29 +251,349 @@ func (g *GormPermissionRepository) AddScopesToClient(clientID string, scopeIDs [
 
 // AddScopesToRole implements domain.PermissionRepository.
 func (g *GormPermissionRepository) AddScopesToRole(roleID string, scopeIDs []string) error {
+	tenant, err := g.tenantOf(&model.Role{}, "id", roleID, domain.ErrRoleNotFound)
+	if err != nil {
+		return err
+	}
+	if err := g.inTenant(tenant, &model.Scope{}, "id", domain.ErrScopeNotFound, scopeIDs); err != nil {
+		return err
+	}
 	sr := make([]model.RoleScope, len(scopeIDs))
 	for i, scopeID := range scopeIDs {
 		sr[i] = model.RoleScope{RoleID: roleID, ScopeID: scopeID}
 	}
-	return g.db.Create(&sr).Error
+	if err := g.db.Create(&sr).Error; err != nil {
+		return err
+	}
+	// Every holder of the role gains the scopes.
+	userIDs, err := usersWithRoles(g.db, roleID)
+	if err != nil {
+		return err
+	}
+	return g.invalidateUsers(userIDs)
+}
+
+// DeleteRole implements domain.PermissionRepository.
+func (g *GormPermissionRepository) DeleteRole(roleID string) error {
+	if err := g.visible(&model.Role{}, "id", domain.ErrRoleNotFound, roleID); err != nil {
+		return err
+	}
+	var userIDs []string
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if userIDs, err = usersWithRoles(tx, roleID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.RoleScope{}, "role_id = ?", roleID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.UserRole{}, "role_id = ?", roleID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.GroupRole{}, "role_id = ?", roleID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.RoleParent{}, "role_id = ? OR parent_id = ?", roleID, roleID).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&model.Role{}, "id = ?", roleID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrRoleNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return g.invalidateUsers(userIDs)
+}
+
+// DeleteScope implements domain.PermissionRepository.
+func (g *GormPermissionRepository) DeleteScope(scopeID string) error {
+	if err := g.visible(&model.Scope{}, "id", domain.ErrScopeNotFound, scopeID); err != nil {
+		return err
+	}
+	var userIDs, clientIDs []string
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		// Users get the scope through their roles (held or inherited),
+		// directly or through a group.
+		var roleIDs []string
+		if err := tx.Model(&model.RoleScope{}).Where("scope_id = ?", scopeID).Pluck("role_id", &roleIDs).Error; err != nil {
+			return err
+		}
+		var err error
+		if userIDs, err = usersWithRoles(tx, roleIDs...); err != nil {
+			return err
+		}
+		var direct []string
+		if err := tx.Model(&model.UserScope{}).Where("scope_id = ?", scopeID).Pluck("user_id", &direct).Error; err != nil {
+			return err
+		}
+		userIDs = append(userIDs, direct...)
+		var members []string
+		if err := tx.Table("group_members gm").
+			Joins("JOIN group_scopes gs ON gs.group_id = gm.group_id").
+			Where("gs.scope_id = ?", scopeID).
+			Pluck("gm.user_id", &members).Error; err != nil {
+			return err
+		}
+		userIDs = append(userIDs, members...)
+		if err := tx.Model(&model.ClientScope{}).Where("scope_id = ?", scopeID).Pluck("client_id", &clientIDs).Error; err != nil {
+			return err
+		}
+
+		for _, link := range []any{&model.RoleScope{}, &model.UserScope{}, &model.ClientScope{}, &model.GroupScope{}} {
+			if err := tx.Delete(link, "scope_id = ?", scopeID).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Delete(&model.Scope{}, "id = ?", scopeID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrScopeNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := g.invalidateUsers(userIDs); err != nil {
+		return err
+	}
+	return g.invalidateClients(clientIDs)
+}
+
+// RemoveScopesFromRole implements domain.PermissionRepository.
+func (g *GormPermissionRepository) RemoveScopesFromRole(roleID string, scopeIDs []string) error {
+	if err := g.visible(&model.Role{}, "id", domain.ErrRoleNotFound, roleID); err != nil {
+		return err
+	}
+	if err := g.db.Delete(&model.RoleScope{}, "role_id = ? AND scope_id IN ?", roleID, scopeIDs).Error; err != nil {
+		return err
+	}
+	userIDs, err := usersWithRoles(g.db, roleID)
+	if err != nil {
+		return err
+	}
+	return g.invalidateUsers(userIDs)
+}
+
+// RemoveRolesFromUser implements domain.PermissionRepository.
+func (g *GormPermissionRepository) RemoveRolesFromUser(userID string, roleIDs []string) error {
+	if err := g.visible(&model.User{}, "id", domain.ErrUserNotFound, userID); err != nil {
+		return err
+	}
+	if err := g.db.Delete(&model.UserRole{}, "user_id = ? AND role_id IN ?", userID, roleIDs).Error; err != nil {
+		return err
+	}
+	return g.InvalidateUser(userID)
+}
+
+// RemoveScopesFromClient implements domain.PermissionRepository.
+func (g *GormPermissionRepository) RemoveScopesFromClient(clientID string, scopeIDs []string) error {
+	if err := g.visible(&model.Client{}, "client_id", domain.ErrClientNotFound, clientID); err != nil {
+		return err
+	}
+	if err := g.db.Delete(&model.ClientScope{}, "client_id = ? AND scope_id IN ?", clientID, scopeIDs).Error; err != nil {
+		return err
+	}
+	return g.InvalidateClient(clientID)
+}
+
+// usersWithRoles returns the users holding any of roleIDs or a role
+// inheriting from them, themselves or through a group.
+func usersWithRoles(db *gorm.DB, roleIDs ...string) ([]string, error) {
+	var userIDs []string
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+	err := db.Raw(roleDescendantsCTE+
+		"SELECT ur.user_id FROM user_roles ur JOIN role_tree t ON t.role_id = ur.role_id "+
+		"UNION SELECT gm.user_id FROM group_members gm JOIN group_roles gr ON gr.group_id = gm.group_id JOIN role_tree t ON t.role_id = gr.role_id",
+		roleIDs).Scan(&userIDs).Error
+	return userIDs, err
+}
+
+// AddParentRoles implements domain.PermissionRepository.
+func (g *GormPermissionRepository) AddParentRoles(roleID string, parentIDs []string) error {
+	tenant, err := g.tenantOf(&model.Role{}, "id", roleID, domain.ErrRoleNotFound)
+	if err != nil {
+		return err
+	}
+	if err := g.inTenant(tenant, &model.Role{}, "id", domain.ErrRoleNotFound, parentIDs); err != nil {
+		return err
+	}
+	var userIDs []string
+	err = g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", roleGraphLockID).Error; err != nil {
+			return err
+		}
+
+		ids := []string{roleID}
+		for _, id := range parentIDs {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		var found int64
+		if err := tx.Model(&model.Role{}).Where("id IN ?", ids).Count(&found).Error; err != nil {
+			return err
+		}
+		if found != int64(len(ids)) {
+			return domain.ErrRoleNotFound
+		}
+
+		// roleID would inherit from itself if it is already an ancestor of
+		// one of the new parents (or one of them).
+		var cycles int64
+		if err := tx.Raw(roleAncestorsCTE+"SELECT count(*) FROM role_tree WHERE role_id = ?", parentIDs, roleID).
+			Scan(&cycles).Error; err != nil {
+			return err
+		}
+		if cycles > 0 {
+			return domain.ErrRoleCycle
+		}
+
+		rp := make([]model.RoleParent, len(parentIDs))
+		for i, parentID := range parentIDs {
+			rp[i] = model.RoleParent{RoleID: roleID, ParentID: parentID}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rp).Error; err != nil {
+			return err
+		}
+		var err error
+		userIDs, err = usersWithRoles(tx, roleID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return g.invalidateUsers(userIDs)
+}
+
+// RemoveParentRoles implements domain.PermissionRepository.
+func (g *GormPermissionRepository) RemoveParentRoles(roleID string, parentIDs []string) error {
+	if err := g.visible(&model.Role{}, "id", domain.ErrRoleNotFound, roleID); err != nil {
+		return err
+	}
+	if err := g.db.Delete(&model.RoleParent{}, "role_id = ? AND parent_id IN ?", roleID, parentIDs).Error; err != nil {
+		return err
+	}
+	userIDs, err := usersWithRoles(g.db, roleID)
+	if err != nil {
+		return err
+	}
+	return g.invalidateUsers(userIDs)
+}
+
+// GetRoleTree implements domain.PermissionRepository.
+func (g *GormPermissionRepository) GetRoleTree(roleID string) (domain.RoleTree, error) {
+	if err := g.visible(&model.Role{}, "id", domain.ErrRoleNotFound, roleID); err != nil {
+		return domain.RoleTree{}, err
+	}
+	var ids []string
+	if err := g.db.Raw(roleAncestorsCTE+"SELECT role_id FROM role_tree", []string{roleID}).Scan(&ids).Error; err != nil {
+		return domain.RoleTree{}, err
+	}
+	if len(ids) == 0 {
+		return domain.RoleTree{}, domain.ErrRoleNotFound
+	}
+
+	var roles []model.Role
+	if err := g.db.Where("id IN ?", ids).Find(&roles).Error; err != nil {
+		return domain.RoleTree{}, err
+	}
+	var edges []model.RoleParent
+	if err := g.db.Where("role_id IN ?", ids).Find(&edges).Error; err != nil {
+		return domain.RoleTree{}, err
+	}
+	var scopes []struct{ RoleID, Key string }
+	if err := g.db.Table("role_scopes rs").
+		Select("rs.role_id, s.key").
+		Joins("JOIN scopes s ON s.id = rs.scope_id").
+		Where("rs.role_id IN ?", ids).
+		Order("s.key").
+		Find(&scopes).Error; err != nil {
+		return domain.RoleTree{}, err
+	}
+
+	byID := make(map[string]domain.Role, len(roles))
+	for _, r := range roles {
+		byID[r.ID] = domain.Role{ID: r.ID, TenantID: r.TenantID, Key: r.Key, Desc: r.Description}
+	}
+	parents := map[string][]string{}
+	for _, e := range edges {
+		parents[e.RoleID] = append(parents[e.RoleID], e.ParentID)
+	}
+	own := map[string][]string{}
+	for _, s := range scopes {
+		own[s.RoleID] = append(own[s.RoleID], s.Key)
+	}
+
+	var build func(id string, path map[string]bool) domain.RoleTree
+	build = func(id string, path map[string]bool) domain.RoleTree {
+		t := domain.RoleTree{Role: byID[id], Scopes: own[id]}
+		path[id] = true
+		for _, p := range parents[id] {
+			if !path[p] {
+				t.Parents = append(t.Parents, build(p, path))
+			}
+		}
+		delete(path, id)
+		return t
+	}
+	return build(roleID, map[string]bool{}), nil
+}
+
+// invalidateUsers drops the cached grants of every user in userIDs.
+func (g *GormPermissionRepository) invalidateUsers(userIDs []string) error {
+	if g.rdb == nil || len(userIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		keys[i] = userPermKey(id)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return g.rdb.Del(ctx, keys...).Err()
+}
+
+// invalidateClients drops the cached grants of every client in clientIDs.
+func (g *GormPermissionRepository) invalidateClients(clientIDs []string) error {
+	if g.rdb == nil || len(clientIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, len(clientIDs))
+	for i, id := range clientIDs {
+		keys[i] = clientPermKey(id)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return g.rdb.Del(ctx, keys...).Err()
 }
 
 // CreateRole implements domain.PermissionRepository.
 func (g *GormPermissionRepository) CreateRole(key string, desc string) (domain.Role, error) {
-	r := domain.Role{Key: key, Desc: desc}
+	r := model.Role{TenantID: g.tenant.id, Key: key, Description: desc}
 	if err := g.db.Create(&r).Error; err != nil {
 		return domain.Role{}, err
 	}
-	return r, nil
+	return domain.Role{ID: r.ID, TenantID: r.TenantID, Key: r.Key, Desc: r.Description}, nil
 }
 
 // CreateScope implements domain.PermissionRepository.
 func (g *GormPermissionRepository) CreateScope(key string, desc string) (domain.Scope, error) {
-	s := domain.Scope{Key: key, Desc: desc}
+	s := model.Scope{TenantID: g.tenant.id, Key: key, Description: desc}
 	if err := g.db.Create(&s).Error; err != nil {
 		return domain.Scope{}, err
 	}
-	return s, nil
+	return domain.Scope{ID: s.ID, TenantID: s.TenantID, Key: s.Key, Desc: s.Description}, nil
 }
 
 // InvalidateClient implements domain.PermissionRepository.
@@ -220,6 +618,9 @@ func (g *GormPermissionRepository) InvalidateUser(userID string) error {
 
 // ListClientScopes implements domain.PermissionRepository.
 func (g *GormPermissionRepository) ListClientScopes(clientID string) ([]string, error) {
+	if err := g.visible(&model.Client{}, "client_id", domain.ErrClientNotFound, clientID); err != nil {
+		return nil, err
+	}
 	if g.rdb != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
@@ -260,26 +661,25 @@ func (g *GormPermissionRepository) ListClientScopes(clientID string) ([]string,
 // ListScopes implements domain.PermissionRepository.
 func (g *GormPermissionRepository) ListScopes() ([]domain.Scope, error) {
 	var rows []model.Scope
-	if err := g.db.Order("key").Find(&rows).Error; err != nil {
+	if err := g.tenant.apply(g.db, "tenant_id").Order("key").Find(&rows).Error; err != nil {
 		return nil, err
 	}
 	out := make([]domain.Scope, 0, len(rows))
 	for _, s := range rows {
-		out = append(out, domain.Scope{ID: s.ID, Key: s.Key, Desc: s.Description})
+		out = append(out, domain.Scope{ID: s.ID, TenantID: s.TenantID, Key: s.Key, Desc: s.Description})
 	}
 	return out, nil
 }
 
 // ListUserScopes implements domain.PermissionRepository.
 func (g *GormPermissionRepository) ListUserScopes(userID string) ([]string, error) {
+	if err := g.visible(&model.User{}, "id", domain.ErrUserNotFound, userID); err != nil {
+		return nil, err
+	}
 	var rows []struct{ Key string }
-	q := g.db.Table("scopes s").
-		Select("s.key").
-		Joins("JOIN role_scopes rs ON rs.scope_id = s.id").
-		Joins("JOIN user_roles ur ON ur.role_id = rs.role_id").
-		Where("ur.user_id = ?", userID).
-		Group("s.key")
-	if err := q.Find(&rows).Error; err != nil {
+	if err := g.db.Raw(userRoleTreeCTE+
+		"SELECT DISTINCT s.key FROM scopes s JOIN role_scopes rs ON rs.scope_id = s.id JOIN role_tree t ON t.role_id = rs.role_id",
+		sql.Named("user", userID)).Scan(&rows).Error; err != nil {
 		return nil, err
 	}
 	out := make([]string, 0, len(rows))
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/db/model"
+	"gorm.io/gorm"
+)
+
+type GormPolicyRepository struct {
+	db     *gorm.DB
+	tenant tenantFilter
+}
+
+func NewGormPolicyRepository(db *gorm.DB) *GormPolicyRepository {
+	return &GormPolicyRepository{db: db}
+}
+
+// ForTenant implements domain.PolicyRepository.
+func (r *GormPolicyRepository) ForTenant(tenantID string) domain.PolicyRepository {
+	return &GormPolicyRepository{db: r.db, tenant: forTenant(tenantID)}
+}
+
+// policies starts a query on the policies the repository can see.
+func (r *GormPolicyRepository) policies() *gorm.DB {
+	return r.tenant.apply(r.db.Model(&model.Policy{}), "tenant_id")
+}
+
+func (r *GormPolicyRepository) Create(p *domain.Policy) error {
+	if r.tenant.scoped {
+		p.TenantID = r.tenant.id
+	}
+	m, err := toPolicyModel(p)
+	if err != nil {
+		return err
+	}
+	if err := r.db.Create(&m).Error; err != nil {
+		return err
+	}
+	p.ID, p.CreatedAt = m.ID, m.CreatedAt
+	return nil
+}
+
+func (r *GormPolicyRepository) List() ([]*domain.Policy, error) {
+	var ms []model.Policy
+	if err := r.policies().Order("created_at, id").Find(&ms).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*domain.Policy, len(ms))
+	for i := range ms {
+		p, err := toDomainPolicy(&ms[i])
+		if err != nil {
+			return nil, err
+		}
+		out[i] = p
+	}
+	return out, nil
+}
+
+func (r *GormPolicyRepository) FindByID(id string) (*domain.Policy, error) {
+	var m model.Policy
+	err := r.policies().Where("id = ?", id).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toDomainPolicy(&m)
+}
+
+func (r *GormPolicyRepository) Delete(id string) error {
+	res := r.tenant.apply(r.db, "tenant_id").Delete(&model.Policy{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrPolicyNotFound
+	}
+	return nil
+}
+
+func toPolicyModel(p *domain.Policy) (model.Policy, error) {
+	m := model.Policy{
+		TenantID:    p.TenantID,
+		Name:        p.Name,
+		Description: p.Description,
+		Effect:      string(p.Effect),
+	}
+	for _, f := range []struct {
+		dst *string
+		v   any
+	}{
+		{&m.Actions, p.Actions},
+		{&m.Subject, p.Subject},
+		{&m.Resource, p.Resource},
+		{&m.Conditions, p.Conditions},
+	} {
+		b, err := json.Marshal(f.v)
+		if err != nil {
+			return model.Policy{}, err
+		}
+		*f.dst = string(b)
+	}
+	return m, nil
+}
+
+func toDomainPolicy(m *model.Policy) (*domain.Policy, error) {
+	p := &domain.Policy{
+		ID:          m.ID,
+		TenantID:    m.TenantID,
+		Name:        m.Name,
+		Description: m.Description,
+		Effect:      domain.PolicyEffect(m.Effect),
+		CreatedAt:   m.CreatedAt,
+	}
+	for _, f := range []struct {
+		src string
+		dst any
+	}{
+		{m.Actions, &p.Actions},
+		{m.Subject, &p.Subject},
+		{m.Resource, &p.Resource},
+		{m.Conditions, &p.Conditions},
+	} {
+		if err := json.Unmarshal([]byte(f.src), f.dst); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/db/model"
@@ -9,35 +10,84 @@ import (
 )
 
 type GormUserRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	tenant tenantFilter
 }
 
 func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
 	return &GormUserRepository{db: db}
 }
 
+// ForTenant implements domain.UserRepository.
+func (r *GormUserRepository) ForTenant(tenantID string) domain.UserRepository {
+	return &GormUserRepository{db: r.db, tenant: forTenant(tenantID)}
+}
+
+// users starts a query on the users the repository can see.
+func (r *GormUserRepository) users() *gorm.DB {
+	return r.tenant.apply(r.db.Model(&model.User{}), "tenant_id")
+}
+
 func (r *GormUserRepository) Create(user *domain.User) error {
+	if r.tenant.scoped {
+		user.TenantID = r.tenant.id
+	}
 	return r.db.Create(fromDomainUser(user)).Error
 }
 
 func (r *GormUserRepository) FindByEmail(email string) (*domain.User, error) {
 	var user model.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.users().Where("email = ?", email).First(&user).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
 	return toDomainUser(&user), err
 }
 
+func (r *GormUserRepository) FindByID(id string) (*domain.User, error) {
+	var user model.User
+	err := r.users().Where("id = ?", id).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return toDomainUser(&user), err
+}
+
+func (r *GormUserRepository) Update(user *domain.User) error {
+	if r.tenant.scoped {
+		// Save inserts missing rows, so make sure this one is ours first.
+		existing, err := r.FindByID(user.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return domain.ErrUserNotFound
+		}
+		user.TenantID = r.tenant.id
+	}
+	return r.db.Save(fromDomainUser(user)).Error
+}
+
+func (r *GormUserRepository) UpdatePassword(id, passwordHash string) error {
+	return r.users().Where("id = ?", id).Updates(map[string]any{
+		"password":                passwordHash,
+		"password_reset_required": false,
+	}).Error
+}
+
 func toDomainUser(m *model.User) *domain.User {
 	if m == nil {
 		return nil
 	}
 	return &domain.User{
-		ID:       m.ID,
-		Email:    m.Email,
-		Password: m.Password,
-		Verified: m.Verified,
+		ID:                    m.ID,
+		Email:                 m.Email,
+		TenantID:              m.TenantID,
+		Password:              m.Password,
+		Verified:              m.Verified,
+		Disabled:              m.Disabled,
+		PasswordResetRequired: m.PasswordResetRequired,
+		CreatedAt:             m.CreatedAt,
 	}
 }
 
@@ -46,16 +96,20 @@ func fromDomainUser(u *domain.User) *model.User {
 		return nil
 	}
 	return &model.User{
-		ID:       u.ID,
-		Email:    u.Email,
-		Password: u.Password,
-		Verified: u.Verified,
+		ID:                    u.ID,
+		Email:                 u.Email,
+		TenantID:              u.TenantID,
+		Password:              u.Password,
+		Verified:              u.Verified,
+		Disabled:              u.Disabled,
+		PasswordResetRequired: u.PasswordResetRequired,
+		CreatedAt:             u.CreatedAt,
 	}
 }
 
 func (r *GormUserRepository) GetAll() ([]*domain.User, error) {
 	var users []model.User
-	if err := r.db.Find(&users).Error; err != nil {
+	if err := r.users().Find(&users).Error; err != nil {
 		return nil, err
 	}
 
@@ -66,3 +120,91 @@ func (r *GormUserRepository) GetAll() ([]*domain.User, error) {
 	return domainUsers, nil
 }
 
+// likePattern escapes the LIKE wildcards of a search term.
+func likePattern(term string) string {
+	term = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+	return "%" + term + "%"
+}
+
+func (r *GormUserRepository) List(f domain.UserFilter) ([]*domain.User, int64, error) {
+	q := r.users()
+	if f.Search != "" {
+		q = q.Where("email ILIKE ?", likePattern(f.Search))
+	}
+	if f.Verified != nil {
+		q = q.Where("verified = ?", *f.Verified)
+	}
+	if f.Disabled != nil {
+		q = q.Where("disabled = ?", *f.Disabled)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []model.User
+	err := q.Order("created_at DESC, id").Offset(f.Offset).Limit(f.Limit).Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]*domain.User, len(users))
+	for i := range users {
+		out[i] = toDomainUser(&users[i])
+	}
+	return out, total, nil
+}
+
+func (r *GormUserRepository) SetDisabled(id string, disabled bool) error {
+	return r.users().Where("id = ?", id).Update("disabled", disabled).Error
+}
+
+// visible reports whether the repository can see the user.
+func (r *GormUserRepository) visible(id string) (bool, error) {
+	var n int64
+	err := r.users().Where("id = ?", id).Count(&n).Error
+	return n > 0, err
+}
+
+func (r *GormUserRepository) Delete(id string) error {
+	if ok, err := r.visible(id); err != nil || !ok {
+		return err
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range []any{
+			&model.UserRole{},
+			&model.UserScope{},
+			&model.GroupMember{},
+			&model.RecoveryCode{},
+			&model.UserMFA{},
+			&model.WebAuthnCredential{},
+		} {
+			if err := tx.Where("user_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", id).Delete(&model.User{}).Error
+	})
+}
+
+func (r *GormUserRepository) SetTenant(id, tenantID string) error {
+	if r.tenant.scoped && tenantID != r.tenant.id {
+		return domain.ErrCrossTenant
+	}
+	ok, err := r.visible(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	// Assignments belong to the old tenant.
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range []any{&model.UserRole{}, &model.UserScope{}, &model.GroupMember{}} {
+			if err := tx.Where("user_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.User{}).Where("id = ?", id).Update("tenant_id", tenantID).Error
+	})
+}
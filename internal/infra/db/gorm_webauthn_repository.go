@@ -0,0 +1,81 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/db/model"
+	"gorm.io/gorm"
+)
+
+type GormWebAuthnRepository struct {
+	db *gorm.DB
+}
+
+func NewGormWebAuthnRepository(db *gorm.DB) *GormWebAuthnRepository {
+	return &GormWebAuthnRepository{db: db}
+}
+
+func (r *GormWebAuthnRepository) CreateCredential(c *domain.WebAuthnCredential) error {
+	m := &model.WebAuthnCredential{
+		ID:           c.ID,
+		UserID:       c.UserID,
+		CredentialID: c.CredentialID,
+		PublicKey:    c.PublicKey,
+		SignCount:    int64(c.SignCount),
+		AAGUID:       c.AAGUID,
+		Name:         c.Name,
+	}
+	if err := r.db.Create(m).Error; err != nil {
+		return err
+	}
+	c.ID = m.ID
+	c.CreatedAt = m.CreatedAt
+	return nil
+}
+
+func (r *GormWebAuthnRepository) ListCredentials(userID string) ([]domain.WebAuthnCredential, error) {
+	var rows []model.WebAuthnCredential
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]domain.WebAuthnCredential, len(rows))
+	for i := range rows {
+		out[i] = *toDomainWebAuthnCredential(&rows[i])
+	}
+	return out, nil
+}
+
+func (r *GormWebAuthnRepository) FindCredential(credentialID []byte) (*domain.WebAuthnCredential, error) {
+	var m model.WebAuthnCredential
+	err := r.db.Where("credential_id = ?", credentialID).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toDomainWebAuthnCredential(&m), nil
+}
+
+func (r *GormWebAuthnRepository) UpdateSignCount(id string, count uint32, usedAt time.Time) error {
+	return r.db.Model(&model.WebAuthnCredential{}).Where("id = ?", id).Updates(map[string]any{
+		"sign_count":   int64(count),
+		"last_used_at": usedAt,
+	}).Error
+}
+
+func toDomainWebAuthnCredential(m *model.WebAuthnCredential) *domain.WebAuthnCredential {
+	return &domain.WebAuthnCredential{
+		ID:           m.ID,
+		UserID:       m.UserID,
+		CredentialID: m.CredentialID,
+		PublicKey:    m.PublicKey,
+		SignCount:    uint32(m.SignCount),
+		AAGUID:       m.AAGUID,
+		Name:         m.Name,
+		CreatedAt:    m.CreatedAt,
+		LastUsedAt:   m.LastUsedAt,
+	}
+}
@@ -10,6 +10,9 @@ func runMigrations(db *gorm.DB) {
 	if err := db.AutoMigrate(allModels()...); err != nil {
 		zap.L().Fatal("failed to run migrations", zap.Error(err))
 	}
+	if err := dropGlobalKeyIndexes(db); err != nil {
+		zap.L().Fatal("failed to run migrations", zap.Error(err))
+	}
 	if err := SeedInitialData(db); err != nil {
 		zap.L().Fatal("failed to seed initial data", zap.Error(err))
 	}
@@ -18,13 +21,45 @@ func runMigrations(db *gorm.DB) {
 // (Opcional) Centralize os models aqui se preferir.
 func allModels() []any {
 	return []any{
+		&model.Organization{},
 		&model.User{},
 		&model.Client{},
 		&model.Scope{},
 		&model.Role{},
 		&model.RoleScope{},
+		&model.RoleParent{},
 		&model.UserRole{},
 		&model.ClientScope{},
 		&model.UserScope{},
+		&model.Group{},
+		&model.GroupMember{},
+		&model.GroupRole{},
+		&model.GroupScope{},
+		&model.UserMFA{},
+		&model.RecoveryCode{},
+		&model.WebAuthnCredential{},
+		&model.AuditEvent{},
+		&model.Policy{},
+	}
+}
+
+// dropGlobalKeyIndexes removes the unique indexes that made role, scope and
+// group keys unique across tenants; keys are now unique per tenant.
+func dropGlobalKeyIndexes(db *gorm.DB) error {
+	m := db.Migrator()
+	for _, idx := range []struct {
+		model any
+		name  string
+	}{
+		{&model.Role{}, "idx_roles_key"},
+		{&model.Scope{}, "idx_scopes_key"},
+		{&model.Group{}, "idx_groups_key"},
+	} {
+		if m.HasIndex(idx.model, idx.name) {
+			if err := m.DropIndex(idx.model, idx.name); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
 }
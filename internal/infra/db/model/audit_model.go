@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// AuditEvent rows are only ever inserted. Seq is assigned by the repository
+// under a lock, so the chain has no gaps or forks.
+type AuditEvent struct {
+	Seq        int64     `gorm:"primaryKey;autoIncrement:false"`
+	OccurredAt time.Time `gorm:"not null;index"`
+	Action     string    `gorm:"type:varchar(64);not null;index"`
+	Outcome    string    `gorm:"type:varchar(16);not null"`
+	ActorType  string    `gorm:"type:varchar(16)"`
+	ActorID    string    `gorm:"type:varchar(255);index"`
+	IP         string    `gorm:"type:varchar(64)"`
+	TargetType string    `gorm:"type:varchar(32)"`
+	TargetID   string    `gorm:"type:varchar(255);index"`
+	Details    string    `gorm:"type:text"` // JSON, kept verbatim
+	PrevHash   string    `gorm:"type:varchar(64);not null"`
+	Hash       string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+}
@@ -10,13 +10,20 @@ import (
 type Client struct {
 	ID              string `gorm:"primaryKey"`
 	ClientID        string `gorm:"uniqueIndex;not null"`
+	TenantID        string `gorm:"type:varchar(36);not null;default:'';index"`
 	SecretHash      string `gorm:"not null"`
 	Name            string
 	AllowedScopes   string `gorm:"not null;default:''"`
 	AllowedAudience string `gorm:"not null;default:''"`
 	Active          bool   `gorm:"not null;default:true"`
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	RedirectURIs    string `gorm:"not null;default:''"`
+	Public          bool   `gorm:"not null;default:false"`
+
+	PreviousSecretHash      string `gorm:"not null;default:''"`
+	PreviousSecretExpiresAt *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 // Ensure ID is set when creating (AutoMigrate não cria default DB aqui)
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Organization is a tenant. Its ID is the tenant_id of everything it owns.
+type Organization struct {
+	ID        string `gorm:"type:uuid;primaryKey"`
+	Slug      string `gorm:"type:varchar(63);uniqueIndex;not null"`
+	Name      string `gorm:"type:varchar(200);not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == "" {
+		o.ID = uuid.NewString()
+	}
+	return nil
+}
@@ -9,7 +9,8 @@ import (
 
 type Scope struct {
 	ID          string    `gorm:"type:uuid"`
-	Key         string    `gorm:"type:varchar(100);uniqueIndex"`
+	TenantID    string    `gorm:"type:varchar(36);not null;default:'';uniqueIndex:idx_scopes_tenant_key"`
+	Key         string    `gorm:"type:varchar(100);uniqueIndex:idx_scopes_tenant_key"`
 	Description string    `gorm:"type:varchar(255)"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
@@ -22,7 +23,8 @@ func (s *Scope) BeforeCreate(tx *gorm.DB) error {
 
 type Role struct {
 	ID          string    `gorm:"type:uuid"`
-	Key         string    `gorm:"type:varchar(100);uniqueIndex"`
+	TenantID    string    `gorm:"type:varchar(36);not null;default:'';uniqueIndex:idx_roles_tenant_key"`
+	Key         string    `gorm:"type:varchar(100);uniqueIndex:idx_roles_tenant_key"`
 	Description string    `gorm:"type:varchar(255)"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
@@ -33,6 +35,43 @@ func (r *Role) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+type Group struct {
+	ID          string `gorm:"type:uuid"`
+	TenantID    string `gorm:"type:varchar(36);not null;default:'';uniqueIndex:idx_groups_tenant_key"`
+	Key         string `gorm:"type:varchar(100);uniqueIndex:idx_groups_tenant_key"`
+	Description string `gorm:"type:varchar(255)"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (g *Group) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == "" {
+		g.ID = uuid.NewString()
+	}
+	return nil
+}
+
+type GroupMember struct {
+	GroupID string `gorm:"type:uuid;primaryKey"`
+	UserID  string `gorm:"type:uuid;primaryKey;index"`
+}
+
+type GroupRole struct {
+	GroupID string `gorm:"type:uuid;primaryKey"`
+	RoleID  string `gorm:"type:uuid;primaryKey;index"`
+}
+
+type GroupScope struct {
+	GroupID string `gorm:"type:uuid;primaryKey"`
+	ScopeID string `gorm:"type:uuid;primaryKey;index"`
+}
+
+// RoleParent makes RoleID inherit every scope of ParentID.
+type RoleParent struct {
+	RoleID   string `gorm:"type:uuid;primaryKey"`
+	ParentID string `gorm:"type:uuid;primaryKey;index"`
+}
+
 type RoleScope struct {
 	RoleID  string `gorm:"type:uuid;primaryKey"`
 	ScopeID string `gorm:"type:uuid;primaryKey"`
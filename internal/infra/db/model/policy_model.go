@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Policy is an attribute-based access rule. Actions, Subject, Resource and
+// Conditions are JSON, read and written only by the repository.
+type Policy struct {
+	ID          string `gorm:"type:uuid;primaryKey"`
+	TenantID    string `gorm:"type:varchar(36);not null;default:'';index"`
+	Name        string `gorm:"type:varchar(200);not null"`
+	Description string `gorm:"type:text"`
+	Effect      string `gorm:"type:varchar(8);not null"`
+	Actions     string `gorm:"type:text;not null"`
+	Subject     string `gorm:"type:text;not null;default:'{}'"`
+	Resource    string `gorm:"type:text;not null;default:'{}'"`
+	Conditions  string `gorm:"type:text;not null;default:'{}'"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (p *Policy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.NewString()
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -10,6 +12,12 @@ type User struct {
 	Email    string `gorm:"type:varchar(180);uniqueIndex;not null"`
 	Password string `gorm:"type:varchar(255);not null"`
 	Verified bool   `gorm:"not null;default:false"`
+	TenantID string `gorm:"type:varchar(36);not null;default:'';index"`
+
+	Disabled              bool      `gorm:"not null;default:false"`
+	PasswordResetRequired bool      `gorm:"not null;default:false"`
+	CreatedAt             time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"` // backfills existing rows
+	UpdatedAt             time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
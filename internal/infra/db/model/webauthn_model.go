@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebAuthnCredential struct {
+	ID           string `gorm:"type:uuid;primaryKey"`
+	UserID       string `gorm:"type:uuid;index;not null"`
+	CredentialID []byte `gorm:"type:bytea;uniqueIndex;not null"`
+	PublicKey    []byte `gorm:"type:bytea;not null"`
+	SignCount    int64  `gorm:"not null;default:0"`
+	AAGUID       []byte `gorm:"type:bytea"`
+	Name         string `gorm:"type:varchar(100)"`
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+func (c *WebAuthnCredential) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.NewString()
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+//go:build cgo
+
+package db
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/db/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newRoleGraphDB holds viewer <- editor <- admin (each role inherits from
+// the one on its left), an unrelated auditor role, and the tables the role
+// CTEs read. The CTEs are plain SQL, so SQLite runs them as Postgres does.
+func newRoleGraphDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.Role{}, &model.RoleParent{}, &model.UserRole{}, &model.GroupRole{}, &model.GroupMember{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"viewer", "editor", "admin", "auditor"} {
+		if err := db.Create(&model.Role{ID: key, Key: key}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&[]model.RoleParent{
+		{RoleID: "editor", ParentID: "viewer"},
+		{RoleID: "admin", ParentID: "editor"},
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// TestRoleCycleCheck runs the query AddParentRoles refuses ErrRoleCycle on.
+func TestRoleCycleCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		role      string
+		parents   []string
+		wantCycle bool
+	}{
+		{name: "role as its own parent", role: "viewer", parents: []string{"viewer"}, wantCycle: true},
+		{name: "direct cycle", role: "editor", parents: []string{"admin"}, wantCycle: true},
+		{name: "indirect cycle", role: "viewer", parents: []string{"admin"}, wantCycle: true},
+		{name: "cycle through one of several parents", role: "viewer", parents: []string{"auditor", "editor"}, wantCycle: true},
+		{name: "new branch", role: "auditor", parents: []string{"viewer"}},
+		{name: "inheriting from the top of a chain", role: "auditor", parents: []string{"admin"}},
+		{name: "redundant ancestor", role: "admin", parents: []string{"viewer"}},
+		{name: "existing parent", role: "admin", parents: []string{"editor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newRoleGraphDB(t)
+			var cycles int64
+			if err := db.Raw(roleAncestorsCTE+"SELECT count(*) FROM role_tree WHERE role_id = ?", tt.parents, tt.role).
+				Scan(&cycles).Error; err != nil {
+				t.Fatal(err)
+			}
+			if got := cycles > 0; got != tt.wantCycle {
+				t.Errorf("cycle = %v, want %v", got, tt.wantCycle)
+			}
+		})
+	}
+}
+
+// TestRoleCTEsOnACycle makes sure the walks stay finite, and list every role
+// once, should a cycle ever be stored.
+func TestRoleCTEsOnACycle(t *testing.T) {
+	db := newRoleGraphDB(t)
+	if err := db.Create(&model.RoleParent{RoleID: "viewer", ParentID: "admin"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&[]model.UserRole{{UserID: "u1", RoleID: "editor"}, {UserID: "u2", RoleID: "auditor"}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.GroupRole{GroupID: "g1", RoleID: "viewer"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.GroupMember{GroupID: "g1", UserID: "u3"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	cycle := []string{"admin", "editor", "viewer"}
+
+	tests := []struct {
+		name  string
+		query string
+		args  []any
+		want  []string
+	}{
+		{name: "ancestors", query: roleAncestorsCTE + "SELECT role_id FROM role_tree", args: []any{[]string{"editor"}}, want: cycle},
+		{name: "descendants", query: roleDescendantsCTE + "SELECT role_id FROM role_tree", args: []any{[]string{"viewer"}}, want: cycle},
+		{name: "roles of a user", query: userRoleTreeCTE + "SELECT role_id FROM role_tree", args: []any{sql.Named("user", "u1")}, want: cycle},
+		{name: "roles through a group", query: userRoleTreeCTE + "SELECT role_id FROM role_tree", args: []any{sql.Named("user", "u3")}, want: cycle},
+		{name: "roles outside the cycle", query: userRoleTreeCTE + "SELECT role_id FROM role_tree", args: []any{sql.Named("user", "u2")}, want: []string{"auditor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			if err := db.Raw(tt.query, tt.args...).Scan(&got).Error; err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("roles = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
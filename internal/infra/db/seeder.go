@@ -18,7 +18,7 @@ func SeedInitialData(db *gorm.DB) error {
 		}
 		for _, s := range scopes {
 			if err := tx.Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "key"}},
+				Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "key"}},
 				DoNothing: true,
 			}).Create(&s).Error; err != nil {
 				return err
@@ -31,19 +31,20 @@ func SeedInitialData(db *gorm.DB) error {
 		}
 		for _, r := range roles {
 			if err := tx.Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "key"}},
+				Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "key"}},
 				DoNothing: true,
 			}).Create(&r).Error; err != nil {
 				return err
 			}
 		}
 
+		// The seed belongs to the platform tenant.
 		var adminRole model.Role
-		if err := tx.Where("key = ?", "admin").First(&adminRole).Error; err != nil {
+		if err := tx.Where("tenant_id = '' AND key = ?", "admin").First(&adminRole).Error; err != nil {
 			return err
 		}
 		var allScopes []model.Scope
-		if err := tx.Find(&allScopes).Error; err != nil {
+		if err := tx.Where("tenant_id = ''").Find(&allScopes).Error; err != nil {
 			return err
 		}
 		for _, s := range allScopes {
@@ -68,6 +69,7 @@ func SeedInitialData(db *gorm.DB) error {
 			adminUser = model.User{
 				Email:    adminEmail,
 				Password: string(hash),
+				Verified: true,
 			}
 			if err := tx.Create(&adminUser).Error; err != nil {
 				return err
@@ -78,6 +80,11 @@ func SeedInitialData(db *gorm.DB) error {
 					return err
 				}
 			}
+			if !adminUser.Verified {
+				if err := tx.Model(&adminUser).Update("verified", true).Error; err != nil {
+					return err
+				}
+			}
 		}
 
 		ur := model.UserRole{UserID: adminUser.ID, RoleID: adminRole.ID}
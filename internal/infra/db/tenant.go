@@ -0,0 +1,84 @@
+package db
+
+import (
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"gorm.io/gorm"
+)
+
+// tenantFilter restricts a repository to one tenant. The zero value is the
+// unrestricted, platform-wide repository, whose new records belong to the
+// platform tenant.
+type tenantFilter struct {
+	id     string
+	scoped bool
+}
+
+func forTenant(id string) tenantFilter { return tenantFilter{id: id, scoped: true} }
+
+// apply adds the tenant condition on column to q.
+func (f tenantFilter) apply(q *gorm.DB, column string) *gorm.DB {
+	if !f.scoped {
+		return q
+	}
+	return q.Where(column+" = ?", f.id)
+}
+
+// tenantOf returns the tenant of the row of m whose column is ref. Rows the
+// view can't see yield notFound.
+func (g *GormPermissionRepository) tenantOf(m any, column, ref string, notFound error) (string, error) {
+	var tenants []string
+	q := g.db.Model(m).Where(column+" = ?", ref)
+	if err := g.tenant.apply(q, "tenant_id").Limit(1).Pluck("tenant_id", &tenants).Error; err != nil {
+		return "", err
+	}
+	if len(tenants) == 0 {
+		return "", notFound
+	}
+	return tenants[0], nil
+}
+
+// inTenant checks that the rows of m whose column is in refs exist and belong
+// to tenant. Missing rows, and rows the view can't see, yield notFound; rows
+// of another tenant on the platform-wide repository yield
+// domain.ErrCrossTenant.
+func (g *GormPermissionRepository) inTenant(tenant string, m any, column string, notFound error, refs []string) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	var rows []struct{ Ref, TenantID string }
+	if err := g.db.Model(m).Select(column+" AS ref, tenant_id").Where(column+" IN ?", refs).Scan(&rows).Error; err != nil {
+		return err
+	}
+	found := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		if r.TenantID != tenant {
+			if g.tenant.scoped {
+				return notFound
+			}
+			return domain.ErrCrossTenant
+		}
+		found[r.Ref] = true
+	}
+	for _, ref := range refs {
+		if !found[ref] {
+			return notFound
+		}
+	}
+	return nil
+}
+
+// visible checks that the view can see the rows of m whose column is in refs.
+// The platform-wide repository sees everything.
+func (g *GormPermissionRepository) visible(m any, column string, notFound error, refs ...string) error {
+	if !g.tenant.scoped {
+		return nil
+	}
+	return g.inTenant(g.tenant.id, m, column, notFound, refs)
+}
+
+// ForTenant implements domain.PermissionRepository.
+func (g *GormPermissionRepository) ForTenant(tenantID string) domain.PermissionRepository {
+	v := *g
+	v.tenant = forTenant(tenantID)
+	return &v
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// LogMailer is the local development mailer: it logs every message and, when
+// Dir is set, also drops it there as an .eml file so links can be opened.
+type LogMailer struct {
+	Dir  string
+	From string
+}
+
+func NewLogMailer(dir, from string) *LogMailer {
+	return &LogMailer{Dir: dir, From: from}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg domain.Email) error {
+	zap.L().Info("email sent",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	if m.Dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("create outbox: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.NewString()[:8])
+	if err := os.WriteFile(filepath.Join(m.Dir, name), message(m.From, msg), 0o644); err != nil {
+		return fmt.Errorf("write email: %w", err)
+	}
+	return nil
+}
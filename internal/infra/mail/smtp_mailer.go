@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+// SMTPMailer delivers emails through an SMTP relay (STARTTLS when offered).
+type SMTPMailer struct {
+	Addr string // host:port
+	User string
+	Pass string
+	From string
+}
+
+func NewSMTPMailer(host, port, user, pass, from string) *SMTPMailer {
+	return &SMTPMailer{Addr: net.JoinHostPort(host, port), User: user, Pass: pass, From: from}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg domain.Email) error {
+	var auth smtp.Auth
+	if m.User != "" {
+		host, _, _ := net.SplitHostPort(m.Addr)
+		auth = smtp.PlainAuth("", m.User, m.Pass, host)
+	}
+	if err := smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, message(m.From, msg)); err != nil {
+		return fmt.Errorf("smtp send: %w", err)
+	}
+	return nil
+}
+
+// message renders msg as an RFC 5322 plain-text message.
+func message(from string, msg domain.Email) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
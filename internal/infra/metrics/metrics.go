@@ -14,6 +14,10 @@ var (
 
 	authTokensIssuedTotal *prometheus.CounterVec
 	authTokensRevokedTotal *prometheus.CounterVec
+	authRefreshReuseTotal prometheus.Counter
+	authLoginFailedTotal prometheus.Counter
+	authLockoutsTotal *prometheus.CounterVec
+	authRateLimitedTotal *prometheus.CounterVec
 )
 
 func MustRegister(){
@@ -51,11 +55,45 @@ func MustRegister(){
 			[]string{"user_id"},
 		)
 
+		authRefreshReuseTotal = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "auth_refresh_token_reuse_total",
+				Help: "Total number of replayed refresh tokens (each one revokes its token family)",
+			},
+		)
+
+		authLoginFailedTotal = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "auth_login_failed_total",
+				Help: "Total number of logins rejected for a wrong email or password",
+			},
+		)
+
+		authLockoutsTotal = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "auth_login_lockouts_total",
+				Help: "Total number of temporary login lockouts",
+			},
+			[]string{"scope"}, // account or ip
+		)
+
+		authRateLimitedTotal = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "auth_rate_limited_total",
+				Help: "Total number of requests rejected by a rate limit",
+			},
+			[]string{"rule"},
+		)
+
 		prometheus.MustRegister(
 			httpRequestTotal,
 			httpRequestDuration,
 			authTokensIssuedTotal,
 			authTokensRevokedTotal,
+			authRefreshReuseTotal,
+			authLoginFailedTotal,
+			authLockoutsTotal,
+			authRateLimitedTotal,
 		)
 	})
 }
@@ -71,4 +109,20 @@ func IncAuthTokensIssued(userID string) {
 
 func IncAuthTokensRevoked(userID string) {
 	authTokensRevokedTotal.WithLabelValues(userID).Inc()
-}
\ No newline at end of file
+}
+
+func IncAuthRefreshReuse() {
+	authRefreshReuseTotal.Inc()
+}
+
+func IncAuthLoginFailed() {
+	authLoginFailedTotal.Inc()
+}
+
+func IncAuthLockout(scope string) {
+	authLockoutsTotal.WithLabelValues(scope).Inc()
+}
+
+func IncRateLimited(rule string) {
+	authRateLimitedTotal.WithLabelValues(rule).Inc()
+}
@@ -0,0 +1,56 @@
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// IssueActionToken signs a single-purpose token (email verification and the
+// like) with the refresh keyring: like refresh tokens, it is only ever verified
+// by this service.
+func (s *Service) IssueActionToken(purpose, userID, email string, ttl time.Duration) (string, error) {
+	now := s.now()
+	claims := jwt.MapClaims{
+		"iss":     s.cfg.Issuer,
+		"sub":     userID,
+		"email":   email,
+		"purpose": purpose,
+		"jti":     uuid.NewString(),
+		"iat":     now.Unix(),
+		"exp":     now.Add(ttl).Unix(),
+	}
+	tok, err := s.refresh.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("sign %s token: %w", purpose, err)
+	}
+	return tok, nil
+}
+
+// VerifyActionToken validates an action token issued for purpose.
+func (s *Service) VerifyActionToken(purpose, token string) (*domain.ActionToken, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{AlgHS256}))
+	var mc jwt.MapClaims
+	tok, err := parser.ParseWithClaims(token, &mc, s.refresh.keyFunc)
+	if err != nil || !tok.Valid {
+		return nil, domain.ErrInvalidActionToken
+	}
+	if p, _ := mc["purpose"].(string); p != purpose {
+		return nil, domain.ErrInvalidActionToken
+	}
+
+	c := claimsFromMap(mc)
+	if c.SubjectID == "" {
+		return nil, domain.ErrInvalidActionToken
+	}
+	return &domain.ActionToken{
+		ID:        c.ID,
+		Purpose:   purpose,
+		UserID:    c.SubjectID,
+		Email:     c.Email,
+		ExpiresAt: c.ExpiresAt,
+	}, nil
+}
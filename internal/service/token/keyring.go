@@ -0,0 +1,359 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// resyncCooldown bounds how often an unknown kid may trigger a reload from Redis.
+const resyncCooldown = 5 * time.Second
+
+func keyringKey(name string) string { return "auth:keyring:" + name }
+
+// ringDoc is the Redis representation of a Keyring.
+type ringDoc struct {
+	Keys  []storedKey `json:"keys"`
+	Seeds []string    `json:"seeds"` // kids of configured keys already adopted
+}
+
+// Keyring holds the active signing key plus retired, verification-only keys.
+// Tokens carry the kid of the key that signed them, so promoting a new key never
+// invalidates tokens that are still within their TTL. The ring lives in Redis so
+// every instance signs and verifies with the same keys, sealed with kek.
+type Keyring struct {
+	name   string
+	maxTTL time.Duration // how long a retired key must keep verifying
+	seed   []Key         // keys from configuration, seed[0] is the preferred active key
+	rdb    *redis.Client
+	kek    []byte
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	keys     []Key // keys[0] is the active key
+	lastSync time.Time
+}
+
+func newKeyring(name string, maxTTL time.Duration, seed []Key, rdb *redis.Client, kek []byte, now func() time.Time) *Keyring {
+	created := now()
+	keys := make([]Key, len(seed))
+	for i, k := range seed {
+		if k.CreatedAt.IsZero() {
+			k.CreatedAt = created
+		}
+		if i > 0 && k.RetiredAt == nil {
+			k.RetiredAt = &created
+		}
+		keys[i] = k
+	}
+	return &Keyring{
+		name:   name,
+		maxTTL: maxTTL,
+		seed:   keys,
+		rdb:    rdb,
+		kek:    kek,
+		now:    now,
+		keys:   keys,
+	}
+}
+
+// Active returns the key that signs new tokens.
+func (kr *Keyring) Active() Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.keys[0]
+}
+
+// Keys returns a snapshot of the ring, active key first.
+func (kr *Keyring) Keys() []Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return append([]Key(nil), kr.keys...)
+}
+
+// lookup finds a key by kid. A miss reloads the ring once in case another
+// instance has just rotated.
+func (kr *Keyring) lookup(kid string) (Key, bool) {
+	if k, ok := kr.find(kid); ok {
+		return k, true
+	}
+
+	kr.mu.RLock()
+	recent := kr.now().Sub(kr.lastSync) < resyncCooldown
+	kr.mu.RUnlock()
+	if recent || kr.rdb == nil {
+		return Key{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := kr.Sync(ctx); err != nil {
+		return Key{}, false
+	}
+	return kr.find(kid)
+}
+
+// sign signs claims with the active key and stamps its kid in the header.
+func (kr *Keyring) sign(claims jwt.MapClaims) (string, error) {
+	k := kr.Active()
+	t := jwt.NewWithClaims(k.method(), claims)
+	t.Header["kid"] = k.ID
+	return t.SignedString(k.signingKey())
+}
+
+// keyFunc resolves the verification key of a token by its kid header. The
+// algorithm must match the key, so an HS256 token can never be checked against
+// a public key (and vice versa). Tokens minted before kids existed are tried
+// against every key of their algorithm.
+func (kr *Keyring) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		var set jwt.VerificationKeySet
+		for _, k := range kr.Keys() {
+			if k.Algorithm == t.Method.Alg() {
+				set.Keys = append(set.Keys, k.verificationKey())
+			}
+		}
+		if len(set.Keys) == 0 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return set, nil
+	}
+
+	k, ok := kr.lookup(kid)
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if t.Method.Alg() != k.Algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return k.verificationKey(), nil
+}
+
+func (kr *Keyring) find(kid string) (Key, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	for _, k := range kr.keys {
+		if k.ID == kid {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// Sync loads the ring from Redis. On first run it stores the configured keys;
+// a configured key that the ring has never seen (e.g. a new ACCESS_SECRET) is
+// promoted and the previous active key retired.
+func (kr *Keyring) Sync(ctx context.Context) error {
+	return kr.update(ctx, func(keys []Key, now time.Time) ([]Key, bool, error) {
+		return keys, false, nil
+	})
+}
+
+// Rotate promotes a freshly generated key and retires the current active key.
+func (kr *Keyring) Rotate(ctx context.Context) error {
+	return kr.update(ctx, func(keys []Key, now time.Time) ([]Key, bool, error) {
+		k, err := GenerateKey(keys[0].Algorithm)
+		if err != nil {
+			return nil, false, err
+		}
+		return promote(keys, k, now), true, nil
+	})
+}
+
+// RotateIfOlder rotates only when the active key was created more than age ago.
+// The check runs inside the Redis transaction, so concurrent instances rotate once.
+func (kr *Keyring) RotateIfOlder(ctx context.Context, age time.Duration) (bool, error) {
+	var rotated bool
+	err := kr.update(ctx, func(keys []Key, now time.Time) ([]Key, bool, error) {
+		rotated = false
+		if now.Sub(keys[0].CreatedAt) < age {
+			return keys, false, nil
+		}
+		k, err := GenerateKey(keys[0].Algorithm)
+		if err != nil {
+			return nil, false, err
+		}
+		rotated = true
+		return promote(keys, k, now), true, nil
+	})
+	return rotated, err
+}
+
+// Prune drops retired keys once every token they signed has expired.
+func (kr *Keyring) Prune(ctx context.Context) error {
+	return kr.update(ctx, func(keys []Key, now time.Time) ([]Key, bool, error) {
+		out := keys[:1]
+		for _, k := range keys[1:] {
+			if k.RetiredAt != nil && now.Sub(*k.RetiredAt) > kr.maxTTL {
+				continue
+			}
+			out = append(out, k)
+		}
+		return out, len(out) != len(keys), nil
+	})
+}
+
+// Remove drops a retired key immediately, invalidating every token it signed.
+func (kr *Keyring) Remove(ctx context.Context, kid string) error {
+	return kr.update(ctx, func(keys []Key, now time.Time) ([]Key, bool, error) {
+		if keys[0].ID == kid {
+			return nil, false, domain.ErrActiveSigningKey
+		}
+		out := without(keys, kid)
+		if len(out) == len(keys) {
+			return nil, false, domain.ErrSigningKeyNotFound
+		}
+		return out, true, nil
+	})
+}
+
+func promote(keys []Key, k Key, now time.Time) []Key {
+	k.CreatedAt = now
+	prev := keys[0]
+	prev.RetiredAt = &now
+	out := make([]Key, 0, len(keys)+1)
+	out = append(out, k, prev)
+	return append(out, keys[1:]...)
+}
+
+// update applies fn to the stored ring inside a WATCH transaction and refreshes
+// the in-memory copy. fn reports whether it changed anything.
+func (kr *Keyring) update(ctx context.Context, fn func(keys []Key, now time.Time) ([]Key, bool, error)) error {
+	if kr.rdb == nil {
+		kr.mu.Lock()
+		defer kr.mu.Unlock()
+		keys, _, err := fn(append([]Key(nil), kr.keys...), kr.now())
+		if err != nil {
+			return err
+		}
+		kr.keys = keys
+		kr.lastSync = kr.now()
+		return nil
+	}
+
+	aead, err := newKeyAEAD(kr.kek)
+	if err != nil {
+		return fmt.Errorf("keyring %s: %w", kr.name, err)
+	}
+
+	key := keyringKey(kr.name)
+	var keys []Key
+	txf := func(tx *redis.Tx) error {
+		now := kr.now()
+		doc, found, err := loadRing(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		changed := !found
+		if !found {
+			doc = ringDoc{}
+			keys = append([]Key(nil), kr.seed...)
+			for _, k := range kr.seed {
+				doc.Seeds = append(doc.Seeds, k.ID)
+			}
+		} else {
+			keys = make([]Key, 0, len(doc.Keys))
+			for _, sk := range doc.Keys {
+				k, err := sk.unmarshal(aead)
+				if err != nil {
+					return err
+				}
+				keys = append(keys, k)
+				changed = changed || !sk.Sealed
+			}
+			if !contains(doc.Seeds, kr.seed[0].ID) {
+				keys = promote(without(keys, kr.seed[0].ID), kr.seed[0], now)
+				doc.Seeds = append(doc.Seeds, kr.seed[0].ID)
+				changed = true
+			}
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("keyring %s is empty", kr.name)
+		}
+
+		var fnChanged bool
+		keys, fnChanged, err = fn(keys, now)
+		if err != nil {
+			return err
+		}
+		if !changed && !fnChanged {
+			return nil
+		}
+
+		doc.Keys = doc.Keys[:0]
+		for _, k := range keys {
+			sk, err := k.marshal(aead)
+			if err != nil {
+				return err
+			}
+			doc.Keys = append(doc.Keys, sk)
+		}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			return p.Set(ctx, key, b, 0).Err()
+		})
+		return err
+	}
+
+	// Another instance may write the ring between WATCH and EXEC; retry a few times.
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = kr.rdb.Watch(ctx, txf, key); !errors.Is(err, redis.TxFailedErr) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("keyring %s: %w", kr.name, err)
+	}
+
+	kr.mu.Lock()
+	kr.keys = keys
+	kr.lastSync = kr.now()
+	kr.mu.Unlock()
+	return nil
+}
+
+func loadRing(ctx context.Context, tx *redis.Tx, key string) (ringDoc, bool, error) {
+	b, err := tx.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ringDoc{}, false, nil
+	}
+	if err != nil {
+		return ringDoc{}, false, err
+	}
+	var doc ringDoc
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return ringDoc{}, false, err
+	}
+	return doc, true, nil
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func without(keys []Key, kid string) []Key {
+	out := make([]Key, 0, len(keys))
+	for _, k := range keys {
+		if k.ID != kid {
+			out = append(out, k)
+		}
+	}
+	return out
+}
@@ -0,0 +1,305 @@
+package token
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testKEK = bytes.Repeat([]byte{0x42}, 32)
+
+func fixedClock(t time.Time) func() time.Time { return func() time.Time { return t } }
+
+func TestKeyringSealsStoredKeys(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+	now := fixedClock(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	secret := NewSecretKey([]byte("refresh-secret-refresh-secret-01"))
+	signer, err := GenerateKey(AlgES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(signer.Signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kr := newKeyring("access", time.Hour, []Key{signer, secret}, rdb, testKEK, now)
+	if err := kr.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	raw, err := mr.Get(keyringKey("access"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc ringDoc
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, sk := range doc.Keys {
+		if !sk.Sealed || bytes.Contains(sk.Material, secret.Secret) || bytes.Contains(sk.Material, der) {
+			t.Errorf("key %s stored in the clear", sk.ID)
+		}
+	}
+
+	t.Run("another instance with the same key", func(t *testing.T) {
+		other := newKeyring("access", time.Hour, []Key{signer}, rdb, testKEK, now)
+		if err := other.Sync(ctx); err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		if got := other.Keys(); len(got) != 2 || !bytes.Equal(got[1].Secret, secret.Secret) {
+			t.Errorf("keys = %+v, want the stored ring", got)
+		}
+	})
+
+	t.Run("wrong key-encryption key", func(t *testing.T) {
+		other := newKeyring("access", time.Hour, []Key{signer}, rdb, bytes.Repeat([]byte{0x24}, 32), now)
+		if err := other.Sync(ctx); err == nil || !strings.Contains(err.Error(), "cannot decrypt") {
+			t.Errorf("Sync() error = %v, want a decryption error", err)
+		}
+	})
+
+	t.Run("no key-encryption key", func(t *testing.T) {
+		other := newKeyring("access", time.Hour, []Key{signer}, rdb, nil, now)
+		if err := other.Sync(ctx); err == nil {
+			t.Error("Sync() without a key-encryption key succeeded")
+		}
+	})
+
+	t.Run("material moved to another kid", func(t *testing.T) {
+		swapped := doc
+		swapped.Keys = append([]storedKey(nil), doc.Keys...)
+		swapped.Keys[0].Material, swapped.Keys[1].Material = doc.Keys[1].Material, doc.Keys[0].Material
+		if _, err := swapped.Keys[0].unmarshal(mustAEAD(t)); err == nil {
+			t.Error("unmarshal accepted material sealed for another kid")
+		}
+	})
+}
+
+func TestKeyringSealsPlaintextRings(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	secret := NewSecretKey([]byte("refresh-secret-refresh-secret-01"))
+	legacy, err := json.Marshal(ringDoc{
+		Keys:  []storedKey{{ID: secret.ID, Algorithm: AlgHS256, Material: secret.Secret}},
+		Seeds: []string{secret.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr.Set(keyringKey("refresh"), string(legacy))
+
+	kr := newKeyring("refresh", time.Hour, []Key{secret}, rdb, testKEK, time.Now)
+	if err := kr.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	raw, err := mr.Get(keyringKey("refresh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(raw, `"sealed":true`) || bytes.Contains([]byte(raw), mustJSON(t, secret.Secret)) {
+		t.Errorf("plaintext ring was not re-sealed: %s", raw)
+	}
+}
+
+func mustAEAD(t *testing.T) cipher.AEAD {
+	t.Helper()
+	aead, err := newKeyAEAD(testKEK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aead
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Trim(b, `"`)
+}
+
+// testClock is a clock the test moves by hand.
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time          { return c.t }
+func (c *testClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func verify(kr *Keyring, tok string) error {
+	_, err := jwt.Parse(tok, kr.keyFunc, jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgES256, AlgEdDSA}))
+	return err
+}
+
+func signWith(t *testing.T, k Key, kid string) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(k.method(), jwt.MapClaims{"sub": "u1"})
+	tok.Header["kid"] = kid
+	s, err := tok.SignedString(k.signingKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestKeyringKeyFunc(t *testing.T) {
+	active, err := GenerateKey(AlgES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired, err := GenerateKey(AlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown, err := GenerateKey(AlgES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(active.Signer.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr := newKeyring("access", time.Hour, []Key{active, retired}, nil, nil, time.Now)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "active key", token: signWith(t, active, active.ID)},
+		{name: "retired key", token: signWith(t, retired, retired.ID)},
+		{name: "no kid", token: signWith(t, retired, "")},
+		{name: "unknown kid", token: signWith(t, unknown, unknown.ID), wantErr: true},
+		{name: "kid of another key", token: signWith(t, unknown, active.ID), wantErr: true},
+		{name: "HS256 keyed with the public key", token: signWith(t, NewSecretKey(pub), active.ID), wantErr: true},
+		{name: "alg of another key", token: signWith(t, retired, active.ID), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verify(kr, tt.token); (err != nil) != tt.wantErr {
+				t.Errorf("verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+	clock := &testClock{t: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	seed := NewSecretKey([]byte("access-secret-access-secret-0123"))
+	a := newKeyring("access", time.Hour, []Key{seed}, rdb, testKEK, clock.now)
+	b := newKeyring("access", time.Hour, []Key{seed}, rdb, testKEK, clock.now)
+	for _, kr := range []*Keyring{a, b} {
+		if err := kr.Sync(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old, err := a.sign(jwt.MapClaims{"sub": "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated, err := a.RotateIfOlder(ctx, 24*time.Hour); err != nil || rotated {
+		t.Fatalf("RotateIfOlder(fresh key) = %v, %v; want no rotation", rotated, err)
+	}
+	clock.advance(25 * time.Hour)
+	if rotated, err := a.RotateIfOlder(ctx, 24*time.Hour); err != nil || !rotated {
+		t.Fatalf("RotateIfOlder(old key) = %v, %v; want a rotation", rotated, err)
+	}
+	if rotated, err := b.RotateIfOlder(ctx, 24*time.Hour); err != nil || rotated {
+		t.Fatalf("second instance rotated again: %v, %v", rotated, err)
+	}
+
+	// b's RotateIfOlder loaded the key a rotated to instead of rotating again.
+	fresh, err := a.sign(jwt.MapClaims{"sub": "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(b, fresh); err != nil {
+		t.Errorf("other instance rejects a token of the new key: %v", err)
+	}
+	if b.Active().ID != a.Active().ID || a.Active().ID == seed.ID {
+		t.Errorf("active keys = %s, %s; want the same new key", a.Active().ID, b.Active().ID)
+	}
+
+	if err := a.Remove(ctx, a.Active().ID); !errors.Is(err, domain.ErrActiveSigningKey) {
+		t.Errorf("Remove(active) error = %v, want ErrActiveSigningKey", err)
+	}
+	if err := a.Remove(ctx, "nope"); !errors.Is(err, domain.ErrSigningKeyNotFound) {
+		t.Errorf("Remove(unknown) error = %v, want ErrSigningKeyNotFound", err)
+	}
+
+	// The retired key verifies until every token it signed has expired.
+	if err := a.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(a, old); err != nil {
+		t.Errorf("retired key within its TTL rejected: %v", err)
+	}
+	clock.advance(time.Hour + time.Second)
+	if err := a.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(a, old); err == nil {
+		t.Error("pruned key still verifies")
+	}
+	if err := b.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(b, old); err == nil {
+		t.Error("key pruned by another instance still verifies")
+	}
+	if err := verify(b, fresh); err != nil {
+		t.Errorf("active key rejected after Prune: %v", err)
+	}
+}
+
+func TestKeyringRemove(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+	seed := NewSecretKey([]byte("access-secret-access-secret-0123"))
+	a := newKeyring("access", time.Hour, []Key{seed}, rdb, testKEK, time.Now)
+	b := newKeyring("access", time.Hour, []Key{seed}, rdb, testKEK, time.Now)
+	if err := a.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	old, err := a.sign(jwt.MapClaims{"sub": "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// b has never seen the new kid and reloads the ring to verify it.
+	fresh, err := a.sign(jwt.MapClaims{"sub": "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(b, fresh); err != nil {
+		t.Fatalf("other instance rejects a token of the new key: %v", err)
+	}
+	if err := verify(b, old); err != nil {
+		t.Fatalf("retired key rejected: %v", err)
+	}
+
+	if err := a.Remove(ctx, seed.ID); err != nil {
+		t.Fatalf("Remove(retired) error = %v", err)
+	}
+	if err := b.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(b, old); err == nil {
+		t.Error("removed key still verifies on the other instance")
+	}
+}
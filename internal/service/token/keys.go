@@ -0,0 +1,314 @@
+package token
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is a single signing key. HMAC keys carry Secret; asymmetric keys carry a
+// Signer whose public half is published on the JWKS endpoint so resource
+// servers can verify tokens offline.
+type Key struct {
+	ID        string // kid header; defaults to the RFC 7638 thumbprint
+	Algorithm string
+	Secret    []byte
+	Signer    crypto.Signer
+
+	CreatedAt time.Time
+	RetiredAt *time.Time // set once the key only verifies
+}
+
+// IsAsymmetric reports whether alg is one of the supported public-key algorithms.
+func IsAsymmetric(alg string) bool {
+	switch alg {
+	case AlgRS256, AlgES256, AlgEdDSA:
+		return true
+	}
+	return false
+}
+
+// NewSecretKey wraps a shared HS256 secret. Its kid is derived from the secret hash.
+func NewSecretKey(secret []byte) Key {
+	sum := sha256.Sum256(secret)
+	return Key{ID: "hs-" + b64(sum[:12]), Algorithm: AlgHS256, Secret: secret}
+}
+
+// GenerateKey creates a fresh key for the given algorithm.
+func GenerateKey(alg string) (Key, error) {
+	var (
+		signer crypto.Signer
+		err    error
+	)
+	switch alg {
+	case AlgHS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return Key{}, err
+		}
+		return NewSecretKey(secret), nil
+	case AlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return Key{}, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	return newKey(signer)
+}
+
+// LoadKeyFile reads a PEM encoded private key (PKCS#8, PKCS#1 or SEC 1) from disk.
+func LoadKeyFile(path string) (Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+	k, err := ParseKeyPEM(b)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+// ParseKeyPEM parses a PEM encoded private key and infers its algorithm from the key type.
+func ParseKeyPEM(data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, errors.New("no PEM block found")
+	}
+
+	var (
+		parsed any
+		err    error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return Key{}, err
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return Key{}, errors.New("unsupported private key type")
+	}
+	return newKey(signer)
+}
+
+func newKey(signer crypto.Signer) (Key, error) {
+	var alg string
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		alg = AlgRS256
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return Key{}, errors.New("only P-256 EC keys are supported")
+		}
+		alg = AlgES256
+	case ed25519.PublicKey:
+		alg = AlgEdDSA
+	default:
+		return Key{}, errors.New("unsupported public key type")
+	}
+
+	k := Key{Algorithm: alg, Signer: signer}
+	kid, err := thumbprint(k.JWK())
+	if err != nil {
+		return Key{}, err
+	}
+	k.ID = kid
+	return k, nil
+}
+
+func (k Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+func (k Key) signingKey() any {
+	if k.Signer != nil {
+		return k.Signer
+	}
+	return k.Secret
+}
+
+func (k Key) verificationKey() jwt.VerificationKey {
+	if k.Signer != nil {
+		return k.Signer.Public()
+	}
+	return k.Secret
+}
+
+// JWK returns the public JSON Web Key for k. Only meaningful for asymmetric keys.
+func (k Key) JWK() domain.JWK {
+	jwk := domain.JWK{Use: "sig", Alg: k.Algorithm, Kid: k.ID}
+	switch pub := k.Signer.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = b64(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = b64(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64(pub)
+	}
+	return jwk
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint, used as the default kid.
+func thumbprint(j domain.JWK) (string, error) {
+	// Required members only, in lexicographic order.
+	var members any
+	switch j.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return b64(sum[:]), nil
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// storedKey is the Redis representation of a Key. Material is sealed with the
+// key-encryption key so Redis never holds usable signing keys; rings written
+// before encryption have Sealed unset and are re-sealed on the next write.
+type storedKey struct {
+	ID        string     `json:"kid"`
+	Algorithm string     `json:"alg"`
+	Material  []byte     `json:"material"` // nonce + AES-GCM of the HMAC secret or PKCS#8 private key
+	Sealed    bool       `json:"sealed,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+// newKeyAEAD builds the AES-256-GCM cipher that seals stored keys.
+func newKeyAEAD(kek []byte) (cipher.AEAD, error) {
+	if len(kek) != 32 {
+		return nil, errors.New("key-encryption key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// marshal seals the key material, binding it to the kid so a ciphertext can't
+// be moved to another entry of the ring.
+func (k Key) marshal(aead cipher.AEAD) (storedKey, error) {
+	material := k.Secret
+	if k.Signer != nil {
+		der, err := x509.MarshalPKCS8PrivateKey(k.Signer)
+		if err != nil {
+			return storedKey{}, err
+		}
+		material = der
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return storedKey{}, err
+	}
+	return storedKey{
+		ID:        k.ID,
+		Algorithm: k.Algorithm,
+		Material:  aead.Seal(nonce, nonce, material, []byte(k.ID)),
+		Sealed:    true,
+		CreatedAt: k.CreatedAt,
+		RetiredAt: k.RetiredAt,
+	}, nil
+}
+
+func (sk storedKey) unmarshal(aead cipher.AEAD) (Key, error) {
+	material := sk.Material
+	if sk.Sealed {
+		n := aead.NonceSize()
+		if len(material) < n {
+			return Key{}, fmt.Errorf("key %s: sealed material too short", sk.ID)
+		}
+		var err error
+		material, err = aead.Open(nil, material[:n], material[n:], []byte(sk.ID))
+		if err != nil {
+			return Key{}, fmt.Errorf("key %s: cannot decrypt, check JWT_KEY_ENCRYPTION_KEY", sk.ID)
+		}
+	}
+
+	k := Key{ID: sk.ID, Algorithm: sk.Algorithm, CreatedAt: sk.CreatedAt, RetiredAt: sk.RetiredAt}
+	if sk.Algorithm == AlgHS256 {
+		k.Secret = material
+		return k, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(material)
+	if err != nil {
+		return Key{}, fmt.Errorf("key %s: %w", sk.ID, err)
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return Key{}, fmt.Errorf("key %s: unsupported private key type", sk.ID)
+	}
+	k.Signer = signer
+	return k, nil
+}
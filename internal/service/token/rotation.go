@@ -0,0 +1,106 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"go.uber.org/zap"
+)
+
+// keySyncInterval is how often RunKeyRotation reloads the keyrings and looks
+// for due rotations and expired keys.
+const keySyncInterval = time.Minute
+
+func (s *Service) rings() []*Keyring { return []*Keyring{s.access, s.refresh} }
+
+// SyncKeys loads both keyrings from Redis, storing the configured keys on first run.
+func (s *Service) SyncKeys(ctx context.Context) error {
+	for _, kr := range s.rings() {
+		if err := kr.Sync(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListKeys implements domain.KeyManager.
+func (s *Service) ListKeys() []domain.SigningKeyInfo {
+	var out []domain.SigningKeyInfo
+	for _, kr := range s.rings() {
+		for i, k := range kr.Keys() {
+			out = append(out, domain.SigningKeyInfo{
+				ID:        k.ID,
+				Ring:      kr.name,
+				Algorithm: k.Algorithm,
+				Active:    i == 0,
+				CreatedAt: k.CreatedAt,
+				RetiredAt: k.RetiredAt,
+			})
+		}
+	}
+	return out
+}
+
+// RotateKeys implements domain.KeyManager. Retired keys keep verifying until
+// the longest TTL of the tokens they signed has elapsed.
+func (s *Service) RotateKeys(ctx context.Context) error {
+	for _, kr := range s.rings() {
+		if err := kr.Rotate(ctx); err != nil {
+			return err
+		}
+		zap.L().Info("signing key rotated", zap.String("ring", kr.name), zap.String("kid", kr.Active().ID))
+	}
+	return nil
+}
+
+// RemoveKey implements domain.KeyManager.
+func (s *Service) RemoveKey(ctx context.Context, kid string) error {
+	for _, kr := range s.rings() {
+		err := kr.Remove(ctx, kid)
+		if errors.Is(err, domain.ErrSigningKeyNotFound) {
+			continue
+		}
+		if err == nil {
+			zap.L().Warn("signing key removed", zap.String("ring", kr.name), zap.String("kid", kid))
+		}
+		return err
+	}
+	return domain.ErrSigningKeyNotFound
+}
+
+// RunKeyRotation is the scheduled key job. It keeps the in-memory keyrings in
+// sync with Redis, promotes a new key once the active one is older than every
+// (0 disables automatic rotation) and drops retired keys after their max token
+// TTL. It blocks until ctx is done.
+func (s *Service) RunKeyRotation(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(keySyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, kr := range s.rings() {
+			if err := kr.Sync(ctx); err != nil {
+				zap.L().Error("keyring sync failed", zap.String("ring", kr.name), zap.Error(err))
+				continue
+			}
+			if every > 0 {
+				rotated, err := kr.RotateIfOlder(ctx, every)
+				if err != nil {
+					zap.L().Error("scheduled key rotation failed", zap.String("ring", kr.name), zap.Error(err))
+				} else if rotated {
+					zap.L().Info("signing key rotated", zap.String("ring", kr.name), zap.String("kid", kr.Active().ID))
+				}
+			}
+			if err := kr.Prune(ctx); err != nil {
+				zap.L().Error("keyring prune failed", zap.String("ring", kr.name), zap.Error(err))
+			}
+		}
+	}
+}
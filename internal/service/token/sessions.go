@@ -0,0 +1,44 @@
+package token
+
+import (
+	"context"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
+)
+
+// ListSessions implements domain.SessionManager.
+func (s *Service) ListSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	return s.store.ListUserSessions(ctx, userID)
+}
+
+// RevokeSession implements domain.SessionManager. A session of another user is
+// reported as not found.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	sess, found, err := s.store.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !found || sess.UserID != userID {
+		return domain.ErrSessionNotFound
+	}
+	if err := s.store.RevokeSession(ctx, sessionID, s.cfg.RefreshTTL); err != nil {
+		return err
+	}
+	metrics.IncAuthTokensRevoked("refresh")
+	return nil
+}
+
+// RevokeAllSessions implements domain.SessionManager.
+func (s *Service) RevokeAllSessions(ctx context.Context, userID string) error {
+	return s.RevokeOtherSessions(ctx, userID, "")
+}
+
+// RevokeOtherSessions implements domain.SessionManager.
+func (s *Service) RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) error {
+	n, err := s.store.RevokeAllUserSessions(ctx, userID, keepSessionID, s.cfg.RefreshTTL)
+	for i := 0; i < n; i++ {
+		metrics.IncAuthTokensRevoked("refresh")
+	}
+	return err
+}
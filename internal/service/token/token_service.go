@@ -7,10 +7,12 @@ import (
 	"time"
 
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/cache"
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 )
 
 // Config holds secrets and TTLs for tokens.
@@ -21,29 +23,55 @@ type Config struct {
 	RefreshTTL      time.Duration
 	Issuer          string
 	DefaultAudience []string
+
+	// SigningKeys switches access tokens to asymmetric signing. They seed the
+	// access keyring: the first key signs, every key is accepted on verification
+	// (selected by kid) and published on the JWKS endpoint. Empty keeps HS256
+	// seeded with AccessSecret. Refresh tokens are only ever verified here and
+	// use an HS256 keyring seeded with RefreshSecret.
+	SigningKeys []Key
+
+	// KeyEncryptionKey (32 bytes) seals the keyrings stored in Redis with
+	// AES-256-GCM. Syncing with Redis fails without it.
+	KeyEncryptionKey []byte
 }
 
 // Service is the concrete TokenService implementation.
 type Service struct {
-	cfg   Config
-	redis *redis.Client
-	now   func() time.Time // injectable for tests
+	cfg     Config
+	store   *cache.TokenStore
+	now     func() time.Time // injectable for tests
+	access  *Keyring
+	refresh *Keyring
 }
 
-// NewService builds a new token service.
+// NewService builds a new token service. Keyrings start from the configured
+// keys; call SyncKeys to share them with the other instances through Redis.
 func NewService(cfg Config, redisClient *redis.Client) *Service {
-	return &Service{
+	s := &Service{
 		cfg:   cfg,
-		redis: redisClient,
+		store: cache.NewTokenStore(redisClient),
 		now:   time.Now,
 	}
+
+	accessSeed := cfg.SigningKeys
+	if len(accessSeed) == 0 {
+		accessSeed = []Key{NewSecretKey(cfg.AccessSecret)}
+	}
+	s.access = newKeyring("access", cfg.AccessTTL, accessSeed, redisClient, cfg.KeyEncryptionKey, s.clock)
+	s.refresh = newKeyring("refresh", cfg.RefreshTTL, []Key{NewSecretKey(cfg.RefreshSecret)}, redisClient, cfg.KeyEncryptionKey, s.clock)
+	return s
 }
 
-func refreshKey(jti string) string   { return "auth:refresh:" + jti }
-func blacklistKey(jti string) string { return "auth:blacklist:" + jti }
+func (s *Service) clock() time.Time { return s.now() }
+
+// IssuePair creates a fresh access+refresh token pair for the given principal,
+// starting a new session (refresh token family).
+func (s *Service) IssuePair(p domain.Principal, meta domain.SessionMeta) (domain.TokenPair, error) {
+	return s.issuePair(p, uuid.NewString(), meta)
+}
 
-// IssuePair creates a fresh access+refresh token pair for the given principal.
-func (s *Service) IssuePair(p domain.Principal) (domain.TokenPair, error) {
+func (s *Service) issuePair(p domain.Principal, familyID string, meta domain.SessionMeta) (domain.TokenPair, error) {
 	now := s.now()
 
 	accessJTI := uuid.NewString()
@@ -66,6 +94,13 @@ func (s *Service) IssuePair(p domain.Principal) (domain.TokenPair, error) {
 		"scope":        p.Scopes,
 		"aud":          aud,
 		"client_id":    p.ClientID,
+		"fid":          familyID,
+	}
+	if !p.AuthTime.IsZero() {
+		baseClaims["auth_time"] = p.AuthTime.Unix()
+	}
+	if p.TenantID != "" {
+		baseClaims["tenant_id"] = p.TenantID
 	}
 
 	accessClaims := jwt.MapClaims{}
@@ -76,7 +111,7 @@ func (s *Service) IssuePair(p domain.Principal) (domain.TokenPair, error) {
 	accessClaims["iat"] = now.Unix()
 	accessClaims["exp"] = accessExp.Unix()
 
-	accessToken, err := s.sign(accessClaims, s.cfg.AccessSecret)
+	accessToken, err := s.access.sign(accessClaims)
 	if err != nil {
 		return domain.TokenPair{}, fmt.Errorf("sign access: %w", err)
 	}
@@ -89,14 +124,21 @@ func (s *Service) IssuePair(p domain.Principal) (domain.TokenPair, error) {
 	refreshClaims["iat"] = now.Unix()
 	refreshClaims["exp"] = refreshExp.Unix()
 
-	refreshToken, err := s.sign(refreshClaims, s.cfg.RefreshSecret)
+	refreshToken, err := s.refresh.sign(refreshClaims)
 	if err != nil {
 		return domain.TokenPair{}, fmt.Errorf("sign refresh: %w", err)
 	}
 
-	// Persist refresh marker (active) in Redis
+	// Persist refresh marker (active) and the session in Redis
 	ctx := context.Background()
-	if err := s.saveRefresh(ctx, refreshJTI, p.ID, s.cfg.RefreshTTL); err != nil {
+	sess := domain.Session{
+		ID:         familyID,
+		UserID:     p.ID,
+		UserAgent:  meta.UserAgent,
+		IP:         meta.IP,
+		LastUsedAt: now,
+	}
+	if err := s.store.SetRefresh(ctx, refreshJTI, sess, s.cfg.RefreshTTL); err != nil {
 		return domain.TokenPair{}, fmt.Errorf("save refresh: %w", err)
 	}
 
@@ -108,18 +150,19 @@ func (s *Service) IssuePair(p domain.Principal) (domain.TokenPair, error) {
 		RefreshToken: refreshToken,
 		AccessExp:    accessExp,
 		RefreshExp:   refreshExp,
+		Scopes:       p.Scopes,
 	}, nil
 }
 
 // VerifyAccess validates the access token signature/exp and blacklist.
 func (s *Service) VerifyAccess(token string) (*domain.TokenClaims, error) {
-	claims, jti, err := s.parseAndValidate(token, s.cfg.AccessSecret)
+	claims, jti, err := s.parseAndValidate(token, s.access.keyFunc)
 	if err != nil {
 		return nil, err
 	}
 
 	ctx := context.Background()
-	revoked, err := s.isAccessRevoked(ctx, jti)
+	revoked, err := s.store.IsAccessRevoked(ctx, jti, claims.FamilyID)
 	if err != nil {
 		return nil, fmt.Errorf("blacklist check: %w", err)
 	}
@@ -129,80 +172,149 @@ func (s *Service) VerifyAccess(token string) (*domain.TokenClaims, error) {
 	return claims, nil
 }
 
-// Rotate validates the refresh token, checks Redis, then returns a brand-new pair.
-func (s *Service) Rotate(refreshToken string) (domain.TokenPair, error) {
-	claims, refreshJTI, err := s.parseAndValidate(refreshToken, s.cfg.RefreshSecret)
+// RotateForClient validates the refresh token, consumes it in Redis, then
+// returns a brand-new pair in the same family. The token must have been issued
+// to clientID; the check runs before the token is consumed, so a wrong client
+// can neither use nor burn someone else's refresh token. A token that was
+// already rotated is a replay: the family is revoked and ErrRefreshReused
+// returned.
+func (s *Service) RotateForClient(refreshToken, clientID string, meta domain.SessionMeta) (domain.TokenPair, error) {
+	claims, refreshJTI, err := s.parseAndValidate(refreshToken, s.refresh.keyFunc)
 	if err != nil {
 		return domain.TokenPair{}, err
 	}
+	if claims.ClientID != clientID {
+		return domain.TokenPair{}, domain.ErrTokenClientMismatch
+	}
 
 	ctx := context.Background()
-	ok, err := s.refreshExists(ctx, refreshJTI)
+	ok, err := s.store.ConsumeRefresh(ctx, refreshJTI, claims.FamilyID)
 	if err != nil {
-		return domain.TokenPair{}, fmt.Errorf("check refresh in redis: %w", err)
+		return domain.TokenPair{}, fmt.Errorf("consume refresh in redis: %w", err)
 	}
 	if !ok {
-		return domain.TokenPair{}, errors.New("refresh not found (revoked or expired)")
+		fid, err := s.store.RotatedSession(ctx, refreshJTI)
+		if err != nil {
+			return domain.TokenPair{}, fmt.Errorf("check rotated refresh: %w", err)
+		}
+		if fid == "" {
+			return domain.TokenPair{}, errors.New("refresh not found (revoked or expired)")
+		}
+		if err := s.store.RevokeSession(ctx, fid, s.cfg.RefreshTTL); err != nil {
+			return domain.TokenPair{}, fmt.Errorf("revoke family: %w", err)
+		}
+		metrics.IncAuthRefreshReuse()
+		zap.L().Warn("refresh token reuse detected, family revoked",
+			zap.String("sub", claims.SubjectID),
+			zap.String("client_id", claims.ClientID),
+			zap.String("fid", fid),
+			zap.String("jti", refreshJTI),
+		)
+		return domain.TokenPair{}, domain.ErrRefreshReused
 	}
 
-	p := principalFromClaims(*claims)
-
-	pair, err := s.IssuePair(p)
-	if err != nil {
-		return domain.TokenPair{}, err
+	fid := claims.FamilyID
+	if fid == "" {
+		fid = uuid.NewString() // issued before families existed
 	}
-
-	if err := s.deleteRefresh(ctx, refreshJTI); err != nil {
-		return domain.TokenPair{}, fmt.Errorf("delete old refresh: %w", err)
+	if err := s.store.MarkRotated(ctx, refreshJTI, fid, claims.ExpiresAt.Sub(s.now())); err != nil {
+		return domain.TokenPair{}, fmt.Errorf("mark refresh rotated: %w", err)
 	}
 
-	return pair, nil
+	return s.issuePair(claims.Principal(), fid, meta)
 }
 
-// RevokePair blacklists the access token and deletes the refresh token entry from Redis.
+// RevokePair blacklists the access token and revokes the refresh token family,
+// ending the session the refresh token belongs to.
 func (s *Service) RevokePair(accessToken, refreshToken string) error {
 	ctx := context.Background()
 
 	// Parse both tokens (best-effort: não vaza info se inválidos).
-	_, accessJTI, _ := s.parseAndValidate(accessToken, s.cfg.AccessSecret)
-	_, refreshJTI, _ := s.parseAndValidate(refreshToken, s.cfg.RefreshSecret)
+	accessClaims, accessJTI, _ := s.parseAndValidate(accessToken, s.access.keyFunc)
+	refreshClaims, refreshJTI, _ := s.parseAndValidate(refreshToken, s.refresh.keyFunc)
 
 	// Blacklist access se deu pra extrair JTI.
 	if accessJTI != "" {
-		// TTL igual ao restante da validade do token.
-		ttl, _ := s.remainingTTL(accessToken, s.cfg.AccessSecret)
-		if ttl <= 0 {
-			ttl = s.cfg.AccessTTL // fallback
-		}
-		if err := s.blacklistAccess(ctx, accessJTI, ttl); err != nil {
-			return fmt.Errorf("blacklist access: %w", err)
+		if err := s.revokeAccess(ctx, accessClaims, accessJTI); err != nil {
+			return err
 		}
-		// ----- METRICS: revoked access -----
-		metrics.IncAuthTokensRevoked("access")
 	}
 
-	// Delete refresh JTI entry (idempotente).
 	if refreshJTI != "" {
-		if err := s.deleteRefresh(ctx, refreshJTI); err != nil {
-			return fmt.Errorf("delete refresh: %w", err)
+		if err := s.revokeRefresh(ctx, refreshClaims, refreshJTI); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RevokeToken revokes a single token for the client it was issued to (RFC 7009).
+// Revoking a refresh token ends its session, access tokens included.
+func (s *Service) RevokeToken(token, tokenTypeHint, clientID string) error {
+	ctx := context.Background()
+
+	refreshFirst := tokenTypeHint == domain.TokenTypeHintRefresh
+	for _, refresh := range []bool{refreshFirst, !refreshFirst} {
+		keyFunc := s.access.keyFunc
+		if refresh {
+			keyFunc = s.refresh.keyFunc
 		}
-		// ----- METRICS: revoked refresh -----
-		metrics.IncAuthTokensRevoked("refresh")
+		claims, jti, err := s.parseAndValidate(token, keyFunc)
+		if err != nil || jti == "" {
+			continue
+		}
+		if claims.ClientID != clientID {
+			return domain.ErrTokenClientMismatch
+		}
+		if refresh {
+			return s.revokeRefresh(ctx, claims, jti)
+		}
+		return s.revokeAccess(ctx, claims, jti)
+	}
+	return nil // unknown tokens are not an error (RFC 7009 section 2.2)
+}
+
+// revokeAccess blacklists an access token for the rest of its lifetime.
+func (s *Service) revokeAccess(ctx context.Context, claims *domain.TokenClaims, jti string) error {
+	// TTL igual ao restante da validade do token.
+	ttl := claims.ExpiresAt.Sub(s.now())
+	if ttl <= 0 {
+		ttl = s.cfg.AccessTTL // fallback
+	}
+	if err := s.store.BlacklistAccess(ctx, jti, ttl); err != nil {
+		return fmt.Errorf("blacklist access: %w", err)
 	}
+	// ----- METRICS: revoked access -----
+	metrics.IncAuthTokensRevoked("access")
+	return nil
+}
 
+// revokeRefresh revokes the refresh token family, or just the JTI for tokens
+// issued before families (idempotente).
+func (s *Service) revokeRefresh(ctx context.Context, claims *domain.TokenClaims, jti string) error {
+	if claims.FamilyID != "" {
+		if err := s.store.RevokeSession(ctx, claims.FamilyID, s.cfg.RefreshTTL); err != nil {
+			return fmt.Errorf("revoke refresh family: %w", err)
+		}
+	} else if err := s.store.DeleteRefresh(ctx, jti); err != nil {
+		return fmt.Errorf("delete refresh: %w", err)
+	}
+	// ----- METRICS: revoked refresh -----
+	metrics.IncAuthTokensRevoked("refresh")
 	return nil
 }
 
 // Introspect returns (active, claims) where active=false means invalid/expired/revoked.
 func (s *Service) Introspect(token string) (bool, *domain.TokenClaims, error) {
-	claims, jti, err := s.parseAndValidate(token, s.cfg.AccessSecret)
+	claims, jti, err := s.parseAndValidate(token, s.access.keyFunc)
 	if err != nil {
 		// Could be expired/invalid signature, we treat as inactive.
 		return false, nil, nil
 	}
 	// Check blacklist.
 	ctx := context.Background()
-	revoked, err := s.isAccessRevoked(ctx, jti)
+	revoked, err := s.store.IsAccessRevoked(ctx, jti, claims.FamilyID)
 	if err != nil {
 		return false, nil, fmt.Errorf("blacklist check: %w", err)
 	}
@@ -212,6 +324,23 @@ func (s *Service) Introspect(token string) (bool, *domain.TokenClaims, error) {
 	return true, claims, nil
 }
 
+// IntrospectRefresh is Introspect for refresh tokens: a refresh token is active
+// until it is rotated, its session revoked or it expires.
+func (s *Service) IntrospectRefresh(token string) (bool, *domain.TokenClaims, error) {
+	claims, jti, err := s.parseAndValidate(token, s.refresh.keyFunc)
+	if err != nil {
+		return false, nil, nil
+	}
+	active, err := s.store.RefreshActive(context.Background(), jti)
+	if err != nil {
+		return false, nil, fmt.Errorf("refresh check: %w", err)
+	}
+	if !active {
+		return false, nil, nil
+	}
+	return true, claims, nil
+}
+
 // IssueAccessOnly generates an access token without a refresh token (client_credentials).
 func (s *Service) IssueAccessOnly(p domain.Principal) (token string, exp time.Time, err error) {
 	now := s.now()
@@ -236,8 +365,11 @@ func (s *Service) IssueAccessOnly(p domain.Principal) (token string, exp time.Ti
 		"iat":          now.Unix(),
 		"exp":          exp.Unix(),
 	}
+	if p.TenantID != "" {
+		claims["tenant_id"] = p.TenantID
+	}
 
-	tok, err := s.sign(claims, s.cfg.AccessSecret)
+	tok, err := s.access.sign(claims)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("sign access: %w", err)
 	}
@@ -247,53 +379,43 @@ func (s *Service) IssueAccessOnly(p domain.Principal) (token string, exp time.Ti
 	return tok, exp, nil
 }
 
-
-// sign signs a MapClaims with the given secret using HS256.
-func (s *Service) sign(claims jwt.MapClaims, secret []byte) (string, error) {
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString(secret)
+// JWKS returns the public keys (active and retired) that verify access tokens.
+// It is empty in HS256 mode.
+func (s *Service) JWKS() domain.JWKSet {
+	set := domain.JWKSet{Keys: []domain.JWK{}}
+	for _, k := range s.access.Keys() {
+		if IsAsymmetric(k.Algorithm) {
+			set.Keys = append(set.Keys, k.JWK())
+		}
+	}
+	return set
 }
 
 // parseAndValidate parses and validates signature + exp. Returns domain claims and the JTI.
-func (s *Service) parseAndValidate(tokenStr string, secret []byte) (*domain.TokenClaims, string, error) {
+func (s *Service) parseAndValidate(tokenStr string, keyFunc jwt.Keyfunc) (*domain.TokenClaims, string, error) {
 	parser := jwt.NewParser(
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgES256, AlgEdDSA}),
 		jwt.WithAudience(), // allow aud as array
 	)
 	var mc jwt.MapClaims
-	tok, err := parser.ParseWithClaims(tokenStr, &mc, func(t *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
+	tok, err := parser.ParseWithClaims(tokenStr, &mc, keyFunc)
 	if err != nil || !tok.Valid {
 		return nil, "", errors.New("invalid token")
 	}
+	// ID tokens share the access keyring and action tokens the refresh one, but
+	// neither is ever a bearer credential.
+	if _, isIDToken := mc["at_hash"]; isIDToken {
+		return nil, "", errors.New("invalid token")
+	}
+	if _, isActionToken := mc["purpose"]; isActionToken {
+		return nil, "", errors.New("invalid token")
+	}
 
 	jti, _ := mc["jti"].(string)
 	claims := claimsFromMap(mc)
 	return &claims, jti, nil
 }
 
-// remainingTTL estimates remaining validity of a token by its exp claim.
-func (s *Service) remainingTTL(tokenStr string, secret []byte) (time.Duration, error) {
-	var mc jwt.MapClaims
-	_, err := jwt.ParseWithClaims(tokenStr, &mc, func(t *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
-	if err != nil {
-		return 0, err
-	}
-	expFloat, ok := mc["exp"].(float64)
-	if !ok {
-		return 0, errors.New("exp not found")
-	}
-	exp := time.Unix(int64(expFloat), 0)
-	ttl := time.Until(exp)
-	if ttl < 0 {
-		return 0, nil
-	}
-	return ttl, nil
-}
-
 // claimsFromMap converts jwt.MapClaims into domain.TokenClaims.
 func claimsFromMap(mc jwt.MapClaims) domain.TokenClaims {
 	roles := toStringSlice(mc["roles"])
@@ -302,6 +424,10 @@ func claimsFromMap(mc jwt.MapClaims) domain.TokenClaims {
 	email, _ := mc["email"].(string)
 	clientID, _ := mc["client_id"].(string)
 	sub, _ := mc["sub"].(string)
+	jti, _ := mc["jti"].(string)
+	iss, _ := mc["iss"].(string)
+	fid, _ := mc["fid"].(string)
+	tenantID, _ := mc["tenant_id"].(string)
 
 	var st domain.PrincipalType = "user"
 	if stStr, ok := mc["subject_type"].(string); ok && stStr != "" {
@@ -316,20 +442,25 @@ func claimsFromMap(mc jwt.MapClaims) domain.TokenClaims {
 		Scopes:      scopes,
 		ClientID:    clientID,
 		Audience:    aud,
+		TenantID:    tenantID,
+		ID:          jti,
+		IssuedAt:    numericTime(mc["iat"]),
+		ExpiresAt:   numericTime(mc["exp"]),
+		Issuer:      iss,
+		FamilyID:    fid,
+		AuthTime:    numericTime(mc["auth_time"]),
 	}
 }
 
-// principalFromClaims reconstructs a Principal from TokenClaims.
-func principalFromClaims(c domain.TokenClaims) domain.Principal {
-	return domain.Principal{
-		Type:     c.SubjectType,
-		ID:       c.SubjectID,
-		Email:    c.Email,
-		Roles:    c.Roles,
-		Scopes:   c.Scopes,
-		ClientID: c.ClientID,
-		Audience: c.Audience,
+// numericTime converts a NumericDate claim as decoded from JSON.
+func numericTime(v any) time.Time {
+	switch t := v.(type) {
+	case float64:
+		return time.Unix(int64(t), 0)
+	case int64:
+		return time.Unix(t, 0)
 	}
+	return time.Time{}
 }
 
 func toStringSlice(v any) []string {
@@ -351,33 +482,3 @@ func toStringSlice(v any) []string {
 		return nil
 	}
 }
-
-
-func (s *Service) saveRefresh(ctx context.Context, jti string, userID string, ttl time.Duration) error {
-	// Value could be userID or any marker; we only check existence later.
-	return s.redis.Set(ctx, refreshKey(jti), userID, ttl).Err()
-}
-
-func (s *Service) deleteRefresh(ctx context.Context, jti string) error {
-	return s.redis.Del(ctx, refreshKey(jti)).Err()
-}
-
-func (s *Service) refreshExists(ctx context.Context, jti string) (bool, error) {
-	n, err := s.redis.Exists(ctx, refreshKey(jti)).Result()
-	if err != nil {
-		return false, err
-	}
-	return n == 1, nil
-}
-
-func (s *Service) blacklistAccess(ctx context.Context, jti string, ttl time.Duration) error {
-	return s.redis.Set(ctx, blacklistKey(jti), "1", ttl).Err()
-}
-
-func (s *Service) isAccessRevoked(ctx context.Context, jti string) (bool, error) {
-	n, err := s.redis.Exists(ctx, blacklistKey(jti)).Result()
-	if err != nil {
-		return false, err
-	}
-	return n == 1, nil
-}
@@ -0,0 +1,190 @@
+package token
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
+
+func testConfig() Config {
+	return Config{
+		AccessSecret:     []byte("access-secret-access-secret-0123"),
+		RefreshSecret:    []byte("refresh-secret-refresh-secret-01"),
+		AccessTTL:        15 * time.Minute,
+		RefreshTTL:       24 * time.Hour,
+		Issuer:           "https://auth.example.com",
+		KeyEncryptionKey: testKEK,
+	}
+}
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	metrics.MustRegister()
+	_, rdb := newTestRedis(t)
+	return NewService(testConfig(), rdb)
+}
+
+var testUser = domain.Principal{Type: domain.PrincipalUser, ID: "u1", Email: "u1@example.com"}
+
+func TestRotateForClient(t *testing.T) {
+	s := newTestService(t)
+	first, err := s.IssuePair(testUser, domain.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := s.RotateForClient(first.RefreshToken, "", domain.SessionMeta{})
+	if err != nil {
+		t.Fatalf("RotateForClient() error = %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatal("rotation returned the same tokens")
+	}
+	firstClaims, err := s.VerifyAccess(first.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondClaims, err := s.VerifyAccess(second.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyAccess(rotated) error = %v", err)
+	}
+	if secondClaims.FamilyID != firstClaims.FamilyID || secondClaims.SubjectID != testUser.ID {
+		t.Errorf("rotated claims = %+v, want subject %q in family %q", secondClaims, testUser.ID, firstClaims.FamilyID)
+	}
+
+	third, err := s.RotateForClient(second.RefreshToken, "", domain.SessionMeta{})
+	if err != nil {
+		t.Fatalf("rotating the rotated token error = %v", err)
+	}
+
+	// Replaying the first token revokes the whole family, including the tokens
+	// rotated from it.
+	if _, err := s.RotateForClient(first.RefreshToken, "", domain.SessionMeta{}); !errors.Is(err, domain.ErrRefreshReused) {
+		t.Fatalf("replay error = %v, want ErrRefreshReused", err)
+	}
+	for name, tok := range map[string]string{"first": first.AccessToken, "second": second.AccessToken, "third": third.AccessToken} {
+		if _, err := s.VerifyAccess(tok); err == nil {
+			t.Errorf("%s access token still valid after the family was revoked", name)
+		}
+	}
+	if _, err := s.RotateForClient(third.RefreshToken, "", domain.SessionMeta{}); err == nil {
+		t.Error("latest refresh token still rotates after the family was revoked")
+	}
+
+	other, err := s.IssuePair(testUser, domain.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyAccess(other.AccessToken); err != nil {
+		t.Errorf("another session of the user was revoked too: %v", err)
+	}
+}
+
+func TestRotateForClientChecksTheClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		issuedTo string
+		clientID string
+		wantErr  error
+	}{
+		{name: "first-party token", wantErr: nil},
+		{name: "client token by its client", issuedTo: "app", clientID: "app"},
+		{name: "client token as first-party", issuedTo: "app", wantErr: domain.ErrTokenClientMismatch},
+		{name: "client token by another client", issuedTo: "app", clientID: "other", wantErr: domain.ErrTokenClientMismatch},
+		{name: "first-party token by a client", clientID: "app", wantErr: domain.ErrTokenClientMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t)
+			p := testUser
+			p.ClientID = tt.issuedTo
+			pair, err := s.IssuePair(p, domain.SessionMeta{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.RotateForClient(pair.RefreshToken, tt.clientID, domain.SessionMeta{}); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RotateForClient() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				// The refused token was not consumed.
+				if _, err := s.RotateForClient(pair.RefreshToken, tt.issuedTo, domain.SessionMeta{}); err != nil {
+					t.Errorf("rotation by the owning client error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRotateForClientConcurrentUse(t *testing.T) {
+	s := newTestService(t)
+	pair, err := s.IssuePair(testUser, domain.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 8
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		wins int
+	)
+	start := make(chan struct{})
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := s.RotateForClient(pair.RefreshToken, "", domain.SessionMeta{}); err == nil {
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if wins != 1 {
+		t.Errorf("%d callers rotated the same refresh token, want exactly 1", wins)
+	}
+}
+
+func TestVerifyAccessAfterLogout(t *testing.T) {
+	s := newTestService(t)
+	pair, err := s.IssuePair(testUser, domain.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := s.RotateForClient(pair.RefreshToken, "", domain.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Logging out with the latest pair ends the session the earlier access
+	// token belongs to as well.
+	if err := s.RevokePair(rotated.AccessToken, rotated.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	for name, tok := range map[string]string{"earlier": pair.AccessToken, "latest": rotated.AccessToken} {
+		if _, err := s.VerifyAccess(tok); err == nil {
+			t.Errorf("%s access token still valid after logout", name)
+		}
+		if active, _, err := s.Introspect(tok); err != nil || active {
+			t.Errorf("Introspect(%s) = %v, %v; want inactive", name, active, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var errCBOR = errors.New("malformed cbor")
+
+// cborDecoder decodes the subset of CBOR (RFC 8949) WebAuthn uses: integers,
+// byte and text strings, arrays, maps and simple values. Indefinite lengths,
+// tags and floats are rejected; authenticators don't emit them.
+//
+// Values decode to int64, []byte, string, []any, map[any]any, bool or nil.
+type cborDecoder struct {
+	buf []byte
+	pos int
+}
+
+// decodeCBOR decodes one item and returns the bytes that follow it.
+func decodeCBOR(b []byte) (any, []byte, error) {
+	d := &cborDecoder{buf: b}
+	v, err := d.item(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, b[d.pos:], nil
+}
+
+const cborMaxDepth = 16
+
+func (d *cborDecoder) head() (major byte, arg uint64, err error) {
+	if d.pos >= len(d.buf) {
+		return 0, 0, errCBOR
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	major, info := b>>5, b&0x1f
+
+	var n int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		n = 1
+	case info == 25:
+		n = 2
+	case info == 26:
+		n = 4
+	case info == 27:
+		n = 8
+	default:
+		return 0, 0, fmt.Errorf("%w: unsupported additional info %d", errCBOR, info)
+	}
+	if len(d.buf)-d.pos < n {
+		return 0, 0, errCBOR
+	}
+	raw := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	switch n {
+	case 1:
+		arg = uint64(raw[0])
+	case 2:
+		arg = uint64(binary.BigEndian.Uint16(raw))
+	case 4:
+		arg = uint64(binary.BigEndian.Uint32(raw))
+	default:
+		arg = binary.BigEndian.Uint64(raw)
+	}
+	return major, arg, nil
+}
+
+func (d *cborDecoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.buf)-d.pos) {
+		return nil, errCBOR
+	}
+	b := d.buf[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+func (d *cborDecoder) item(depth int) (any, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("%w: nested too deeply", errCBOR)
+	}
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0: // unsigned integer
+		if arg > math.MaxInt64 {
+			return nil, errCBOR
+		}
+		return int64(arg), nil
+	case 1: // negative integer
+		if arg > math.MaxInt64 {
+			return nil, errCBOR
+		}
+		return -1 - int64(arg), nil
+	case 2: // byte string
+		return d.bytes(arg)
+	case 3: // text string
+		b, err := d.bytes(arg)
+		return string(b), err
+	case 4: // array
+		if arg > uint64(len(d.buf)-d.pos) {
+			return nil, errCBOR
+		}
+		arr := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			v, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case 5: // map
+		if arg > uint64(len(d.buf)-d.pos) {
+			return nil, errCBOR
+		}
+		m := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			k, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("%w: unsupported map key", errCBOR)
+			}
+			v, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	case 7: // simple values
+		switch arg {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unsupported major type %d", errCBOR, major)
+}
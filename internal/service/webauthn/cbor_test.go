@@ -0,0 +1,145 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// cborHead encodes the head of an item with a definite argument.
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 1<<8:
+		return []byte{major<<5 | 24, byte(n)}
+	case n < 1<<16:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	case n < 1<<32:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, n)
+}
+
+// encodeCBOR encodes the values authenticators emit, with map keys in
+// canonical order.
+func encodeCBOR(v any) []byte {
+	switch x := v.(type) {
+	case int:
+		if x >= 0 {
+			return cborHead(0, uint64(x))
+		}
+		return cborHead(1, uint64(-1-x))
+	case []byte:
+		return append(cborHead(2, uint64(len(x))), x...)
+	case string:
+		return append(cborHead(3, uint64(len(x))), x...)
+	case []any:
+		out := cborHead(4, uint64(len(x)))
+		for _, e := range x {
+			out = append(out, encodeCBOR(e)...)
+		}
+		return out
+	case map[any]any:
+		keys := make([][]byte, 0, len(x))
+		byKey := map[string]any{}
+		for k, e := range x {
+			ek := encodeCBOR(k)
+			keys = append(keys, ek)
+			byKey[string(ek)] = e
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+		out := cborHead(5, uint64(len(x)))
+		for _, k := range keys {
+			out = append(out, k...)
+			out = append(out, encodeCBOR(byKey[string(k)])...)
+		}
+		return out
+	case bool:
+		if x {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case nil:
+		return []byte{0xf6}
+	}
+	panic("encodeCBOR: unsupported type")
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want any
+	}{
+		{name: "small uint", in: []byte{0x17}, want: int64(23)},
+		{name: "uint8", in: []byte{0x18, 0xff}, want: int64(255)},
+		{name: "uint16", in: []byte{0x19, 0x01, 0x00}, want: int64(256)},
+		{name: "uint32", in: []byte{0x1a, 0x00, 0x01, 0x00, 0x00}, want: int64(65536)},
+		{name: "uint64", in: []byte{0x1b, 0, 0, 0, 1, 0, 0, 0, 0}, want: int64(1 << 32)},
+		{name: "negative", in: []byte{0x26}, want: int64(-7)},
+		{name: "negative uint16", in: []byte{0x39, 0x01, 0x00}, want: int64(-257)},
+		{name: "byte string", in: []byte{0x43, 1, 2, 3}, want: []byte{1, 2, 3}},
+		{name: "text string", in: []byte{0x64, 'n', 'o', 'n', 'e'}, want: "none"},
+		{name: "array", in: encodeCBOR([]any{1, "a"}), want: []any{int64(1), "a"}},
+		{name: "map", in: encodeCBOR(map[any]any{1: 2, "fmt": "none", -1: []byte{9}}), want: map[any]any{int64(1): int64(2), "fmt": "none", int64(-1): []byte{9}}},
+		{name: "simple values", in: encodeCBOR([]any{false, true, nil}), want: []any{false, true, nil}},
+		{name: "undefined", in: []byte{0xf7}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := decodeCBOR(tt.in)
+			if err != nil {
+				t.Fatalf("decodeCBOR() error = %v", err)
+			}
+			if len(rest) != 0 {
+				t.Errorf("rest = %x, want nothing", rest)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCBOR() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORReturnsTheRest(t *testing.T) {
+	in := append(encodeCBOR(map[any]any{1: 2}), 0xde, 0xad)
+	_, rest, err := decodeCBOR(in)
+	if err != nil || !bytes.Equal(rest, []byte{0xde, 0xad}) {
+		t.Fatalf("decodeCBOR() rest = %x, err = %v", rest, err)
+	}
+}
+
+func TestDecodeCBORMalformed(t *testing.T) {
+	deep := bytes.Repeat([]byte{0x81}, cborMaxDepth+2) // [[[[...
+	deep = append(deep, 0x00)
+
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{name: "empty", in: nil},
+		{name: "truncated argument", in: []byte{0x19, 0x01}},
+		{name: "truncated byte string", in: []byte{0x45, 1, 2}},
+		{name: "truncated map", in: []byte{0xa2, 0x01, 0x02, 0x03}},
+		{name: "length beyond the input", in: []byte{0x5b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "array longer than the input", in: []byte{0x9a, 0xff, 0xff, 0xff, 0xff}},
+		{name: "uint beyond int64", in: []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "indefinite length", in: []byte{0x5f, 0x41, 0x00, 0xff}},
+		{name: "reserved additional info", in: []byte{0x1c}},
+		{name: "tag", in: []byte{0xc2, 0x41, 0x01}},
+		{name: "float", in: []byte{0xf9, 0x3c, 0x00}},
+		{name: "byte string map key", in: []byte{0xa1, 0x41, 0x01, 0x01}},
+		{name: "nested too deeply", in: deep},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCBOR(tt.in); !errors.Is(err, errCBOR) {
+				t.Errorf("decodeCBOR(%x) error = %v, want errCBOR", tt.in, err)
+			}
+		})
+	}
+}
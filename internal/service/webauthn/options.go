@@ -0,0 +1,132 @@
+package webauthn
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+// Option and response types follow the WebAuthn JSON serialization, so
+// browsers can hand them to PublicKeyCredential.parseCreationOptionsFromJSON
+// and friends (or decode the base64url fields themselves).
+
+type RPEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          Base64URL `json:"id" swaggertype:"string"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string    `json:"type"`
+	ID   Base64URL `json:"id" swaggertype:"string"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions is the publicKey argument of navigator.credentials.create().
+type CreationOptions struct {
+	Challenge              Base64URL              `json:"challenge" swaggertype:"string"`
+	RP                     RPEntity               `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"` // milliseconds
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions is the publicKey argument of navigator.credentials.get().
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge" swaggertype:"string"`
+	Timeout          int64                  `json:"timeout"` // milliseconds
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationResponse is the PublicKeyCredential returned by create().
+type RegistrationResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId" swaggertype:"string"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON" swaggertype:"string"`
+		AttestationObject Base64URL `json:"attestationObject" swaggertype:"string"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the PublicKeyCredential returned by get().
+type AssertionResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId" swaggertype:"string"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON" swaggertype:"string"`
+		AuthenticatorData Base64URL `json:"authenticatorData" swaggertype:"string"`
+		Signature         Base64URL `json:"signature" swaggertype:"string"`
+		UserHandle        Base64URL `json:"userHandle,omitempty" swaggertype:"string"`
+	} `json:"response"`
+}
+
+// NewChallenge returns a random 32-byte challenge.
+func NewChallenge() ([]byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func descriptors(ids [][]byte) []CredentialDescriptor {
+	out := make([]CredentialDescriptor, len(ids))
+	for i, id := range ids {
+		out[i] = CredentialDescriptor{Type: "public-key", ID: id}
+	}
+	return out
+}
+
+// CreationOptions builds registration options. exclude lists the credentials
+// the user already has, so an authenticator is not registered twice.
+func (rp *RelyingParty) CreationOptions(challenge, userHandle []byte, userName string, exclude [][]byte, timeout time.Duration) CreationOptions {
+	params := make([]CredentialParameter, len(SupportedAlgs))
+	for i, alg := range SupportedAlgs {
+		params[i] = CredentialParameter{Type: "public-key", Alg: alg}
+	}
+	return CreationOptions{
+		Challenge:          challenge,
+		RP:                 RPEntity{ID: rp.ID, Name: rp.Name},
+		User:               UserEntity{ID: userHandle, Name: userName, DisplayName: userName},
+		PubKeyCredParams:   params,
+		Timeout:            timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(exclude),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions builds sign-in options. With no allowed credentials the
+// browser offers the discoverable credentials (passkeys) for the RP.
+func (rp *RelyingParty) RequestOptions(challenge []byte, allow [][]byte, timeout time.Duration) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          timeout.Milliseconds(),
+		RPID:             rp.ID,
+		AllowCredentials: descriptors(allow),
+		UserVerification: "required",
+	}
+}
@@ -0,0 +1,245 @@
+// Package webauthn verifies WebAuthn (Level 2) registration and assertion
+// ceremonies for a relying party. It supports the "none" attestation
+// conveyance only: credentials are tied to an account, not to a trusted
+// authenticator model.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrVerification = errors.New("webauthn verification failed")
+
+func verificationError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrVerification, fmt.Sprintf(format, args...))
+}
+
+// Base64URL is binary data carried as unpadded base64url in JSON, like every
+// binary field of the WebAuthn JSON serialization.
+type Base64URL []byte
+
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = raw
+	return nil
+}
+
+// Authenticator data flags (WebAuthn section 6.1).
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+	flagExtensions   = 0x80
+)
+
+type RelyingParty struct {
+	ID      string   // effective domain, e.g. "example.com"
+	Name    string   // shown by the authenticator
+	Origins []string // accepted origins, e.g. "https://app.example.com"
+}
+
+// Credential is a verified new credential.
+type Credential struct {
+	ID        []byte
+	PublicKey []byte // COSE_Key
+	SignCount uint32
+	AAGUID    []byte
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+
+	// Attested credential data, registration only.
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, typ string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return verificationError("malformed clientDataJSON")
+	}
+	if cd.Type != typ {
+		return verificationError("unexpected ceremony type %q", cd.Type)
+	}
+	got, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return verificationError("challenge mismatch")
+	}
+	if !slices.Contains(rp.Origins, cd.Origin) {
+		return verificationError("origin %q not allowed", cd.Origin)
+	}
+	if cd.CrossOrigin {
+		return verificationError("cross-origin ceremonies are not allowed")
+	}
+	return nil
+}
+
+func parseAuthenticatorData(b []byte) (*authenticatorData, error) {
+	if len(b) < 37 {
+		return nil, verificationError("authenticator data too short")
+	}
+	ad := &authenticatorData{
+		rpIDHash:  b[:32],
+		flags:     b[32],
+		signCount: binary.BigEndian.Uint32(b[33:37]),
+	}
+	rest := b[37:]
+
+	if ad.flags&flagAttested != 0 {
+		if len(rest) < 18 {
+			return nil, verificationError("attested credential data too short")
+		}
+		ad.aaguid = rest[:16]
+		n := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if n == 0 || n > 1023 || len(rest) < n {
+			return nil, verificationError("bad credential id length")
+		}
+		ad.credentialID = rest[:n]
+		rest = rest[n:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, verificationError("malformed credential public key")
+		}
+		ad.publicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+	if ad.flags&flagExtensions != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, verificationError("malformed extensions")
+		}
+		rest = after
+	}
+	if len(rest) != 0 {
+		return nil, verificationError("trailing bytes in authenticator data")
+	}
+	return ad, nil
+}
+
+// checkAuthenticatorData enforces the RP ID and the user flags. User
+// verification is required: a passkey sign-in replaces password and second
+// factor at once.
+func (rp *RelyingParty) checkAuthenticatorData(ad *authenticatorData) error {
+	want := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(ad.rpIDHash, want[:]) {
+		return verificationError("rp id mismatch")
+	}
+	if ad.flags&flagUserPresent == 0 {
+		return verificationError("user not present")
+	}
+	if ad.flags&flagUserVerified == 0 {
+		return verificationError("user not verified")
+	}
+	return nil
+}
+
+// VerifyRegistration checks the response of navigator.credentials.create()
+// (WebAuthn section 7.1) and returns the new credential.
+func (rp *RelyingParty) VerifyRegistration(challenge, clientDataJSON, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	v, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, verificationError("malformed attestation object")
+	}
+	att, ok := v.(map[any]any)
+	if !ok {
+		return nil, verificationError("malformed attestation object")
+	}
+	format, _ := att["fmt"].(string)
+	stmt, _ := att["attStmt"].(map[any]any)
+	raw, _ := att["authData"].([]byte)
+	// We ask for "none"; other formats are accepted without looking at their
+	// statement, which is the same trust level.
+	if format == "" || (format == "none" && len(stmt) != 0) {
+		return nil, verificationError("bad attestation format")
+	}
+
+	ad, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.checkAuthenticatorData(ad); err != nil {
+		return nil, err
+	}
+	if ad.credentialID == nil {
+		return nil, verificationError("no attested credential data")
+	}
+	if _, err := parseCOSEKey(ad.publicKey); err != nil {
+		return nil, verificationError("%v", err)
+	}
+
+	return &Credential{
+		ID:        bytes.Clone(ad.credentialID),
+		PublicKey: bytes.Clone(ad.publicKey),
+		SignCount: ad.signCount,
+		AAGUID:    bytes.Clone(ad.aaguid),
+	}, nil
+}
+
+// VerifyAssertion checks the response of navigator.credentials.get() (WebAuthn
+// section 7.2) against a stored credential and returns its new signature
+// counter. A counter that doesn't move forward means the authenticator was
+// cloned.
+func (rp *RelyingParty) VerifyAssertion(challenge, publicKey []byte, storedCount uint32, clientDataJSON, authData, signature []byte) (uint32, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	ad, err := parseAuthenticatorData(authData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.checkAuthenticatorData(ad); err != nil {
+		return 0, err
+	}
+
+	key, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return 0, verificationError("%v", err)
+	}
+	clientHash := sha256.Sum256(clientDataJSON)
+	signed := append(bytes.Clone(authData), clientHash[:]...)
+	if !key.verify(signed, signature) {
+		return 0, verificationError("bad signature")
+	}
+
+	if (ad.signCount != 0 || storedCount != 0) && ad.signCount <= storedCount {
+		return 0, verificationError("signature counter did not increase")
+	}
+	return ad.signCount, nil
+}
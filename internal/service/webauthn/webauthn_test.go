@@ -0,0 +1,349 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+const testOrigin = "https://app.example.com"
+
+var testRP = &RelyingParty{ID: "example.com", Name: "Example", Origins: []string{testOrigin}}
+
+// softAuthenticator is a software authenticator holding one credential.
+type softAuthenticator struct {
+	alg       int
+	key       crypto.Signer
+	id        []byte
+	signCount uint32
+}
+
+func newSoftAuthenticator(t *testing.T, alg int) *softAuthenticator {
+	t.Helper()
+	a := &softAuthenticator{alg: alg, id: make([]byte, 16), signCount: 1}
+	_, _ = rand.Read(a.id)
+	var err error
+	switch alg {
+	case AlgES256:
+		a.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, a.key, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		a.key, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	switch k := a.key.Public().(type) {
+	case *ecdsa.PublicKey:
+		return encodeCBOR(map[any]any{1: 2, 3: AlgES256, -1: 1, -2: k.X.FillBytes(make([]byte, 32)), -3: k.Y.FillBytes(make([]byte, 32))})
+	case ed25519.PublicKey:
+		return encodeCBOR(map[any]any{1: 1, 3: AlgEdDSA, -1: 6, -2: []byte(k)})
+	case *rsa.PublicKey:
+		return encodeCBOR(map[any]any{1: 3, 3: AlgRS256, -1: k.N.Bytes(), -2: big.NewInt(int64(k.E)).Bytes()})
+	}
+	panic("unreachable")
+}
+
+func (a *softAuthenticator) sign(msg []byte) []byte {
+	var (
+		sig []byte
+		err error
+	)
+	switch a.alg {
+	case AlgEdDSA:
+		sig, err = a.key.Sign(rand.Reader, msg, crypto.Hash(0))
+	default:
+		sum := sha256.Sum256(msg)
+		sig, err = a.key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+// ceremony is what a browser and authenticator would produce; tests corrupt
+// one field at a time.
+type ceremony struct {
+	typ         string
+	challenge   []byte
+	origin      string
+	crossOrigin bool
+	rpID        string
+	flags       byte
+	signCount   uint32
+}
+
+func validCeremony(typ string, challenge []byte) ceremony {
+	return ceremony{
+		typ:       typ,
+		challenge: challenge,
+		origin:    testOrigin,
+		rpID:      testRP.ID,
+		flags:     flagUserPresent | flagUserVerified,
+		signCount: 1,
+	}
+}
+
+func (c ceremony) clientDataJSON() []byte {
+	b, _ := json.Marshal(map[string]any{
+		"type":        c.typ,
+		"challenge":   base64.RawURLEncoding.EncodeToString(c.challenge),
+		"origin":      c.origin,
+		"crossOrigin": c.crossOrigin,
+	})
+	return b
+}
+
+func (c ceremony) authData(attested []byte) []byte {
+	h := sha256.Sum256([]byte(c.rpID))
+	b := append(h[:], c.flags)
+	b = binary.BigEndian.AppendUint32(b, c.signCount)
+	return append(b, attested...)
+}
+
+// register returns clientDataJSON and the attestation object of a "none"
+// attestation of the credential.
+func (a *softAuthenticator) register(c ceremony) (clientDataJSON, attestationObject []byte) {
+	attested := make([]byte, 16) // AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.id)))
+	attested = append(attested, a.id...)
+	attested = append(attested, a.coseKey()...)
+	c.flags |= flagAttested
+	return c.clientDataJSON(), encodeCBOR(map[any]any{
+		"fmt":      "none",
+		"attStmt":  map[any]any{},
+		"authData": c.authData(attested),
+	})
+}
+
+// assert returns clientDataJSON, authenticator data and signature.
+func (a *softAuthenticator) assert(c ceremony) (clientDataJSON, authData, sig []byte) {
+	clientDataJSON = c.clientDataJSON()
+	authData = c.authData(nil)
+	h := sha256.Sum256(clientDataJSON)
+	return clientDataJSON, authData, a.sign(append(bytes.Clone(authData), h[:]...))
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	for _, alg := range SupportedAlgs {
+		t.Run(algName(alg), func(t *testing.T) {
+			a := newSoftAuthenticator(t, alg)
+			challenge, _ := NewChallenge()
+
+			cd, att := a.register(validCeremony("webauthn.create", challenge))
+			cred, err := testRP.VerifyRegistration(challenge, cd, att)
+			if err != nil {
+				t.Fatalf("VerifyRegistration() error = %v", err)
+			}
+			if !bytes.Equal(cred.ID, a.id) || cred.SignCount != 1 || len(cred.AAGUID) != 16 {
+				t.Fatalf("credential = %+v", cred)
+			}
+
+			challenge, _ = NewChallenge()
+			c := validCeremony("webauthn.get", challenge)
+			c.signCount = 2
+			cd, ad, sig := a.assert(c)
+			count, err := testRP.VerifyAssertion(challenge, cred.PublicKey, cred.SignCount, cd, ad, sig)
+			if err != nil {
+				t.Fatalf("VerifyAssertion() error = %v", err)
+			}
+			if count != 2 {
+				t.Errorf("sign count = %d, want 2", count)
+			}
+		})
+	}
+}
+
+func algName(alg int) string {
+	switch alg {
+	case AlgES256:
+		return "ES256"
+	case AlgEdDSA:
+		return "EdDSA"
+	case AlgRS256:
+		return "RS256"
+	}
+	return "unknown"
+}
+
+func TestVerifyRegistrationRejects(t *testing.T) {
+	a := newSoftAuthenticator(t, AlgES256)
+	challenge := []byte("registration-challenge-0123456789")
+
+	tests := []struct {
+		name   string
+		mutate func(c *ceremony)
+		// attestation replaces the attestation object when set.
+		attestation func(att []byte) []byte
+	}{
+		{name: "bad origin", mutate: func(c *ceremony) { c.origin = "https://evil.example.net" }},
+		{name: "origin of another scheme", mutate: func(c *ceremony) { c.origin = "http://app.example.com" }},
+		{name: "cross-origin", mutate: func(c *ceremony) { c.crossOrigin = true }},
+		{name: "wrong ceremony type", mutate: func(c *ceremony) { c.typ = "webauthn.get" }},
+		{name: "other challenge", mutate: func(c *ceremony) { c.challenge = []byte("another-challenge") }},
+		{name: "wrong rpIdHash", mutate: func(c *ceremony) { c.rpID = "evil.example.net" }},
+		{name: "user not present", mutate: func(c *ceremony) { c.flags = flagUserVerified }},
+		{name: "user not verified", mutate: func(c *ceremony) { c.flags = flagUserPresent }},
+		{name: "truncated attestation object", attestation: func(att []byte) []byte { return att[:len(att)/2] }},
+		{name: "trailing bytes after attestation object", attestation: func(att []byte) []byte { return append(att, 0x00) }},
+		{name: "attestation object not a map", attestation: func([]byte) []byte { return encodeCBOR([]any{"none"}) }},
+		{name: "garbage", attestation: func([]byte) []byte { return []byte{0xff, 0xfe, 0xfd} }},
+		{name: "none with a statement", attestation: func([]byte) []byte {
+			_, att := a.register(validCeremony("webauthn.create", challenge))
+			v, _, _ := decodeCBOR(att)
+			m := v.(map[any]any)
+			return encodeCBOR(map[any]any{"fmt": "none", "attStmt": map[any]any{"sig": []byte{1}}, "authData": m["authData"]})
+		}},
+		{name: "no attested credential data", attestation: func([]byte) []byte {
+			return encodeCBOR(map[any]any{"fmt": "none", "attStmt": map[any]any{}, "authData": validCeremony("webauthn.create", challenge).authData(nil)})
+		}},
+		{name: "malformed credential public key", attestation: func([]byte) []byte {
+			c := validCeremony("webauthn.create", challenge)
+			c.flags |= flagAttested
+			attested := binary.BigEndian.AppendUint16(make([]byte, 16), uint16(len(a.id)))
+			attested = append(append(attested, a.id...), 0xa5, 0x01) // map of 5 pairs, cut short
+			return encodeCBOR(map[any]any{"fmt": "none", "attStmt": map[any]any{}, "authData": c.authData(attested)})
+		}},
+		{name: "unsupported key type", attestation: func([]byte) []byte {
+			c := validCeremony("webauthn.create", challenge)
+			c.flags |= flagAttested
+			attested := binary.BigEndian.AppendUint16(make([]byte, 16), uint16(len(a.id)))
+			attested = append(append(attested, a.id...), encodeCBOR(map[any]any{1: 2, 3: -35, -1: 2})...) // ES384
+			return encodeCBOR(map[any]any{"fmt": "none", "attStmt": map[any]any{}, "authData": c.authData(attested)})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validCeremony("webauthn.create", challenge)
+			if tt.mutate != nil {
+				tt.mutate(&c)
+			}
+			cd, att := a.register(c)
+			if tt.attestation != nil {
+				att = tt.attestation(att)
+			}
+			if _, err := testRP.VerifyRegistration(challenge, cd, att); !errors.Is(err, ErrVerification) {
+				t.Errorf("VerifyRegistration() error = %v, want ErrVerification", err)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionRejects(t *testing.T) {
+	a := newSoftAuthenticator(t, AlgES256)
+	other := newSoftAuthenticator(t, AlgES256)
+	challenge := []byte("assertion-challenge-0123456789")
+	const stored = 10
+
+	tests := []struct {
+		name   string
+		mutate func(c *ceremony)
+		// tamper alters the response after signing when set.
+		tamper    func(cd, ad, sig []byte) ([]byte, []byte, []byte)
+		publicKey []byte
+	}{
+		{name: "bad origin", mutate: func(c *ceremony) { c.origin = "https://evil.example.net" }},
+		{name: "wrong ceremony type", mutate: func(c *ceremony) { c.typ = "webauthn.create" }},
+		{name: "other challenge", mutate: func(c *ceremony) { c.challenge = []byte("replayed-challenge") }},
+		{name: "wrong rpIdHash", mutate: func(c *ceremony) { c.rpID = "evil.example.net" }},
+		{name: "user not verified", mutate: func(c *ceremony) { c.flags = flagUserPresent }},
+		{name: "sign count regression", mutate: func(c *ceremony) { c.signCount = stored - 1 }},
+		{name: "sign count unchanged", mutate: func(c *ceremony) { c.signCount = stored }},
+		{name: "sign count reset to zero", mutate: func(c *ceremony) { c.signCount = 0 }},
+		{name: "signature of another key", publicKey: other.coseKey()},
+		{name: "authenticator data altered after signing", tamper: func(cd, ad, sig []byte) ([]byte, []byte, []byte) {
+			ad = bytes.Clone(ad)
+			ad[len(ad)-1]++ // bump the counter
+			return cd, ad, sig
+		}},
+		{name: "truncated signature", tamper: func(cd, ad, sig []byte) ([]byte, []byte, []byte) {
+			return cd, ad, sig[:len(sig)-1]
+		}},
+		{name: "authenticator data too short", tamper: func(cd, ad, sig []byte) ([]byte, []byte, []byte) {
+			return cd, ad[:36], sig
+		}},
+		{name: "trailing bytes in authenticator data", tamper: func(cd, ad, sig []byte) ([]byte, []byte, []byte) {
+			return cd, append(bytes.Clone(ad), 0x00), sig
+		}},
+		{name: "malformed clientDataJSON", tamper: func(_, ad, sig []byte) ([]byte, []byte, []byte) {
+			return []byte("{not json"), ad, sig
+		}},
+		{name: "malformed stored public key", publicKey: []byte{0xa5, 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validCeremony("webauthn.get", challenge)
+			c.signCount = stored + 1
+			if tt.mutate != nil {
+				tt.mutate(&c)
+			}
+			cd, ad, sig := a.assert(c)
+			if tt.tamper != nil {
+				cd, ad, sig = tt.tamper(cd, ad, sig)
+			}
+			key := a.coseKey()
+			if tt.publicKey != nil {
+				key = tt.publicKey
+			}
+			if _, err := testRP.VerifyAssertion(challenge, key, stored, cd, ad, sig); !errors.Is(err, ErrVerification) {
+				t.Errorf("VerifyAssertion() error = %v, want ErrVerification", err)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionWithoutCounter(t *testing.T) {
+	// Authenticators that don't implement a counter always report 0.
+	a := newSoftAuthenticator(t, AlgEdDSA)
+	challenge := []byte("assertion-challenge-0123456789")
+	c := validCeremony("webauthn.get", challenge)
+	c.signCount = 0
+	cd, ad, sig := a.assert(c)
+	count, err := testRP.VerifyAssertion(challenge, a.coseKey(), 0, cd, ad, sig)
+	if err != nil || count != 0 {
+		t.Fatalf("VerifyAssertion() = %d, %v", count, err)
+	}
+}
+
+func TestParseCOSEKeyRejects(t *testing.T) {
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	x := ec.X.FillBytes(make([]byte, 32))
+	offCurve := bytes.Clone(x)
+	offCurve[31] ^= 1
+
+	tests := []struct {
+		name string
+		key  []byte
+	}{
+		{name: "not a map", key: encodeCBOR([]any{1, 2})},
+		{name: "EC2 point off the curve", key: encodeCBOR(map[any]any{1: 2, 3: AlgES256, -1: 1, -2: x, -3: offCurve})},
+		{name: "EC2 on another curve", key: encodeCBOR(map[any]any{1: 2, 3: AlgES256, -1: 2, -2: x, -3: x})},
+		{name: "Ed25519 key of the wrong size", key: encodeCBOR(map[any]any{1: 1, 3: AlgEdDSA, -1: 6, -2: []byte{1, 2, 3}})},
+		{name: "RSA key under 2048 bits", key: encodeCBOR(map[any]any{1: 3, 3: AlgRS256, -1: make([]byte, 128), -2: []byte{1, 0, 1}})},
+		{name: "algorithm of another key type", key: encodeCBOR(map[any]any{1: 1, 3: AlgES256, -1: 6, -2: make([]byte, 32)})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseCOSEKey(tt.key); !errors.Is(err, ErrUnsupportedKey) {
+				t.Errorf("parseCOSEKey() error = %v, want ErrUnsupportedKey", err)
+			}
+		})
+	}
+}
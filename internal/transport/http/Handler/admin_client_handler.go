@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type AdminClientHandler struct {
+	UC       *usecase.AdminClientUseCase
+	Validate *validator.Validate
+}
+
+// uc only sees the clients of the organization when serving /orgs/{orgId}.
+func (h *AdminClientHandler) uc(r *http.Request) *usecase.AdminClientUseCase {
+	if tenant, ok := middleware.GetTenant(r); ok {
+		return h.UC.ForTenant(tenant)
+	}
+	return h.UC
+}
+
+type ClientResponse struct {
+	ClientID        string    `json:"client_id"`
+	TenantID        string    `json:"tenant_id"`
+	Name            string    `json:"name"`
+	AllowedScopes   []string  `json:"allowed_scopes"`
+	AllowedAudience []string  `json:"allowed_audience"`
+	RedirectURIs    []string  `json:"redirect_uris"`
+	Public          bool      `json:"public"`
+	Active          bool      `json:"active"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// End of the grace period of the previous secret, while it lasts.
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+}
+
+// ClientSecretResponse carries a newly generated secret. It is only shown once.
+type ClientSecretResponse struct {
+	ClientResponse
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+type CreateClientRequest struct {
+	ClientID        string   `json:"client_id" validate:"required,max=100" example:"service-b"`
+	Name            string   `json:"name" validate:"max=200" example:"Service B"`
+	AllowedScopes   []string `json:"allowed_scopes" validate:"dive,required"`
+	AllowedAudience []string `json:"allowed_audience" validate:"dive,required"`
+	RedirectURIs    []string `json:"redirect_uris" validate:"dive,url"`
+	Public          bool     `json:"public"`
+}
+
+type UpdateClientRequest struct {
+	Name            *string  `json:"name,omitempty" validate:"omitempty,max=200"`
+	AllowedScopes   []string `json:"allowed_scopes,omitempty" validate:"omitempty,dive,required"`
+	AllowedAudience []string `json:"allowed_audience,omitempty" validate:"omitempty,dive,required"`
+	RedirectURIs    []string `json:"redirect_uris,omitempty" validate:"omitempty,dive,url"`
+}
+
+type RotateClientSecretRequest struct {
+	// Seconds the old secret keeps working; 0 revokes it at once.
+	GracePeriodSeconds *int `json:"grace_period_seconds,omitempty" validate:"omitempty,min=0" example:"86400"`
+}
+
+func clientResponse(c *domain.Client) ClientResponse {
+	resp := ClientResponse{
+		ClientID:        c.ClientID,
+		TenantID:        c.TenantID,
+		Name:            c.Name,
+		AllowedScopes:   emptyIfNil(c.AllowedScopes),
+		AllowedAudience: emptyIfNil(c.AllowedAudience),
+		RedirectURIs:    emptyIfNil(c.RedirectURIs),
+		Public:          c.Public,
+		Active:          c.Active,
+		CreatedAt:       c.CreatedAt,
+	}
+	if c.PreviousSecretExpiresAt != nil && time.Now().Before(*c.PreviousSecretExpiresAt) {
+		resp.PreviousSecretExpiresAt = c.PreviousSecretExpiresAt
+	}
+	return resp
+}
+
+func emptyIfNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// writeAdminClientError maps the errors of AdminClientUseCase; fallback is
+// the message of unexpected ones.
+func writeAdminClientError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrClientNotFound):
+		apierrors.NotFound(w, "Client not found")
+	case errors.Is(err, domain.ErrClientIDInUse):
+		apierrors.Conflict(w, "Client with this client_id already exists")
+	case errors.Is(err, domain.ErrPublicClient):
+		apierrors.Conflict(w, "Public clients have no secret")
+	default:
+		apierrors.InternalError(w, fallback)
+	}
+}
+
+// @Summary      Create a client
+// @Description  Registers an active OAuth client. Confidential clients get a generated client_secret, returned only in this response; public clients get none.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body CreateClientRequest true "Client data"
+// @Success      201 {object} ClientSecretResponse
+// @Failure      400 {object} map[string]string
+// @Failure      409 {object} map[string]string "client_id already in use"
+// @Failure      422 {object} map[string]string
+// @Router       /admin/clients [post]
+func (h *AdminClientHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	var req CreateClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	c, secret, err := h.uc(r).Create(r.Context(), auditActor(r), usecase.NewClient{
+		ClientID:        req.ClientID,
+		Name:            req.Name,
+		AllowedScopes:   req.AllowedScopes,
+		AllowedAudience: req.AllowedAudience,
+		RedirectURIs:    req.RedirectURIs,
+		Public:          req.Public,
+	})
+	if err != nil {
+		writeAdminClientError(w, err, "Failed to create client")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, ClientSecretResponse{ClientResponse: clientResponse(c), ClientSecret: secret})
+}
+
+// @Summary      List clients
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} ClientResponse
+// @Router       /admin/clients [get]
+func (h *AdminClientHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.uc(r).List()
+	if err != nil {
+		apierrors.InternalError(w, "Failed to list clients")
+		return
+	}
+	resp := make([]ClientResponse, len(clients))
+	for i, c := range clients {
+		resp[i] = clientResponse(c)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// @Summary      Get a client
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        clientId path string true "Client ID (client_id)"
+// @Success      200 {object} ClientResponse
+// @Failure      404 {object} map[string]string
+// @Router       /admin/clients/{clientId} [get]
+func (h *AdminClientHandler) GetClient(w http.ResponseWriter, r *http.Request) {
+	c, err := h.uc(r).Get(chi.URLParam(r, "clientId"))
+	if err != nil {
+		writeAdminClientError(w, err, "Failed to find client")
+		return
+	}
+	writeJSON(w, http.StatusOK, clientResponse(c))
+}
+
+// @Summary      Update a client
+// @Description  Changes the name and the allowed scopes, audiences and redirect URIs. Omitted fields are unchanged; an empty list clears one.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        clientId path string true "Client ID (client_id)"
+// @Param        request body UpdateClientRequest true "Fields to change"
+// @Success      200 {object} ClientResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Router       /admin/clients/{clientId} [patch]
+func (h *AdminClientHandler) UpdateClient(w http.ResponseWriter, r *http.Request) {
+	var req UpdateClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	c, err := h.uc(r).Update(r.Context(), auditActor(r), chi.URLParam(r, "clientId"), usecase.ClientUpdate{
+		Name:            req.Name,
+		AllowedScopes:   req.AllowedScopes,
+		AllowedAudience: req.AllowedAudience,
+		RedirectURIs:    req.RedirectURIs,
+	})
+	if err != nil {
+		writeAdminClientError(w, err, "Failed to update client")
+		return
+	}
+	writeJSON(w, http.StatusOK, clientResponse(c))
+}
+
+// @Summary      Deactivate a client
+// @Description  The client fails authentication on every endpoint from now on. Access tokens already issued stay valid until they expire.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        clientId path string true "Client ID (client_id)"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/clients/{clientId}/deactivate [post]
+func (h *AdminClientHandler) DeactivateClient(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).SetActive(r.Context(), auditActor(r), chi.URLParam(r, "clientId"), false); err != nil {
+		writeAdminClientError(w, err, "Failed to deactivate client")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Reactivate a client
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        clientId path string true "Client ID (client_id)"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/clients/{clientId}/activate [post]
+func (h *AdminClientHandler) ActivateClient(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).SetActive(r.Context(), auditActor(r), chi.URLParam(r, "clientId"), true); err != nil {
+		writeAdminClientError(w, err, "Failed to activate client")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Rotate a client secret
+// @Description  Generates a new client_secret, returned only in this response. The old secret keeps working for grace_period_seconds (CLIENT_SECRET_GRACE_PERIOD by default) so deployments can switch over; 0 revokes it at once. The body is optional.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        clientId path string true "Client ID (client_id)"
+// @Param        request body RotateClientSecretRequest false "Grace period of the old secret"
+// @Success      200 {object} ClientSecretResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string "Public client"
+// @Failure      422 {object} map[string]string
+// @Router       /admin/clients/{clientId}/secret/rotate [post]
+func (h *AdminClientHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	var req RotateClientSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	var grace *time.Duration
+	if req.GracePeriodSeconds != nil {
+		g := time.Duration(*req.GracePeriodSeconds) * time.Second
+		grace = &g
+	}
+	c, secret, err := h.uc(r).RotateSecret(r.Context(), auditActor(r), chi.URLParam(r, "clientId"), grace)
+	if err != nil {
+		writeAdminClientError(w, err, "Failed to rotate client secret")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, ClientSecretResponse{ClientResponse: clientResponse(c), ClientSecret: secret})
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type AdminGroupHandler struct {
+	UC       *usecase.PermAdminUseCase
+	Validate *validator.Validate
+}
+
+// uc is the use case of the request, like AdminPermHandler.uc.
+func (h *AdminGroupHandler) uc(r *http.Request) *usecase.PermAdminUseCase {
+	if tenant, ok := middleware.GetTenant(r); ok {
+		return h.UC.ForTenant(tenant)
+	}
+	return h.UC
+}
+
+type CreateGroupRequest struct {
+	Key  string `json:"key" validate:"required" example:"support-team"`
+	Desc string `json:"desc" validate:"required" example:"Support team"`
+}
+
+type GroupResponse struct {
+	ID      string   `json:"id"`
+	Key     string   `json:"key"`
+	Desc    string   `json:"desc"`
+	Members []string `json:"members"`
+	Roles   []string `json:"roles"`
+	Scopes  []string `json:"scopes"`
+}
+
+// decodeIDs reads and validates an idsBody; false means the error response
+// is written.
+func (h *AdminGroupHandler) decodeIDs(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	var req idsBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return nil, false
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return nil, false
+	}
+	return req.IDs, true
+}
+
+// @Summary      Create group
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body CreateGroupRequest true "Group data"
+// @Success      201 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Router       /admin/groups [post]
+func (h *AdminGroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+	group, err := h.uc(r).CreateGroup(r.Context(), auditActor(r), req.Key, req.Desc)
+	if err != nil {
+		apierrors.Conflict(w, "Group with this key already exists")
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"id": group.ID, "key": group.Key})
+}
+
+// @Summary      List groups
+// @Description  Returns all groups (id, key, desc)
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} map[string]string
+// @Router       /admin/groups [get]
+func (h *AdminGroupHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.uc(r).ListGroups()
+	if err != nil {
+		apierrors.InternalError(w, "Internal server error")
+		return
+	}
+	out := make([]map[string]string, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, map[string]string{"id": g.ID, "key": g.Key, "desc": g.Desc})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// @Summary      Get group
+// @Description  Returns the group with its member user IDs and the keys of its roles and scopes
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        groupId path string true "Group ID"
+// @Success      200 {object} GroupResponse
+// @Failure      404 {object} map[string]string
+// @Router       /admin/groups/{groupId} [get]
+func (h *AdminGroupHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	g, err := h.uc(r).GetGroup(chi.URLParam(r, "groupId"))
+	if err != nil {
+		writePermError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, GroupResponse{
+		ID:      g.ID,
+		Key:     g.Key,
+		Desc:    g.Desc,
+		Members: emptyIfNil(g.Members),
+		Roles:   emptyIfNil(g.Roles),
+		Scopes:  emptyIfNil(g.Scopes),
+	})
+}
+
+// @Summary      Delete group
+// @Description  Deletes the group; its members lose the roles and scopes they got from it
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        groupId path string true "Group ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/groups/{groupId} [delete]
+func (h *AdminGroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).DeleteGroup(r.Context(), auditActor(r), chi.URLParam(r, "groupId")); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Add members to group
+// @Tags         Admin
+// @Accept       json
+// @Security     BearerAuth
+// @Param        groupId path string true "Group ID"
+// @Param        request body idsBody true "User IDs"
+// @Success      204
+// @Router       /admin/groups/{groupId}/members [post]
+func (h *AdminGroupHandler) AddMembers(w http.ResponseWriter, r *http.Request) {
+	ids, ok := h.decodeIDs(w, r)
+	if !ok {
+		return
+	}
+	if err := h.uc(r).AddUsersToGroup(r.Context(), auditActor(r), chi.URLParam(r, "groupId"), ids); err != nil {
+		writeAssignError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Remove member from group
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        groupId path string true "Group ID"
+// @Param        userId path string true "User ID"
+// @Success      204
+// @Router       /admin/groups/{groupId}/members/{userId} [delete]
+func (h *AdminGroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	groupID, userID := chi.URLParam(r, "groupId"), chi.URLParam(r, "userId")
+	if err := h.uc(r).RemoveUsersFromGroup(r.Context(), auditActor(r), groupID, []string{userID}); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Attach roles to group
+// @Tags         Admin
+// @Accept       json
+// @Security     BearerAuth
+// @Param        groupId path string true "Group ID"
+// @Param        request body idsBody true "Role IDs"
+// @Success      204
+// @Router       /admin/groups/{groupId}/roles [post]
+func (h *AdminGroupHandler) AddRoles(w http.ResponseWriter, r *http.Request) {
+	ids, ok := h.decodeIDs(w, r)
+	if !ok {
+		return
+	}
+	if err := h.uc(r).AddRolesToGroup(r.Context(), auditActor(r), chi.URLParam(r, "groupId"), ids); err != nil {
+		writeAssignError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Detach role from group
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        groupId path string true "Group ID"
+// @Param        roleId path string true "Role ID"
+// @Success      204
+// @Router       /admin/groups/{groupId}/roles/{roleId} [delete]
+func (h *AdminGroupHandler) RemoveRole(w http.ResponseWriter, r *http.Request) {
+	groupID, roleID := chi.URLParam(r, "groupId"), chi.URLParam(r, "roleId")
+	if err := h.uc(r).RemoveRolesFromGroup(r.Context(), auditActor(r), groupID, []string{roleID}); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Attach scopes to group
+// @Tags         Admin
+// @Accept       json
+// @Security     BearerAuth
+// @Param        groupId path string true "Group ID"
+// @Param        request body idsBody true "Scope IDs"
+// @Success      204
+// @Router       /admin/groups/{groupId}/scopes [post]
+func (h *AdminGroupHandler) AddScopes(w http.ResponseWriter, r *http.Request) {
+	ids, ok := h.decodeIDs(w, r)
+	if !ok {
+		return
+	}
+	if err := h.uc(r).AddScopesToGroup(r.Context(), auditActor(r), chi.URLParam(r, "groupId"), ids); err != nil {
+		writeAssignError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Detach scope from group
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        groupId path string true "Group ID"
+// @Param        scopeId path string true "Scope ID"
+// @Success      204
+// @Router       /admin/groups/{groupId}/scopes/{scopeId} [delete]
+func (h *AdminGroupHandler) RemoveScope(w http.ResponseWriter, r *http.Request) {
+	groupID, scopeID := chi.URLParam(r, "groupId"), chi.URLParam(r, "scopeId")
+	if err := h.uc(r).RemoveScopesFromGroup(r.Context(), auditActor(r), groupID, []string{scopeID}); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      List user groups
+// @Description  Returns the keys of the groups the user belongs to
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Success      200 {array} string
+// @Router       /admin/users/{userId}/groups [get]
+func (h *AdminGroupHandler) ListUserGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.uc(r).ListUserGroups(chi.URLParam(r, "userId"))
+	if err != nil {
+		writePermError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, groups)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminKeyHandler struct {
+	Keys  domain.KeyManager
+	Audit *usecase.Auditor
+}
+
+// @Summary      List signing keys
+// @Description  Returns the access and refresh keyrings: the active key and every retired key still accepted for verification. Key material is never returned.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} domain.SigningKeyInfo
+// @Router       /admin/keys [get]
+func (h *AdminKeyHandler) ListKeys(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.Keys.ListKeys())
+}
+
+// @Summary      Rotate signing keys
+// @Description  Promotes freshly generated keys for both keyrings. The previous keys are retired: they keep verifying outstanding tokens until the max token TTL elapses.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} domain.SigningKeyInfo
+// @Failure      500 {object} map[string]string
+// @Router       /admin/keys/rotate [post]
+func (h *AdminKeyHandler) RotateKeys(w http.ResponseWriter, r *http.Request) {
+	if err := h.Keys.RotateKeys(r.Context()); err != nil {
+		h.Audit.Record(r.Context(), auditActor(r), domain.AuditKeyRotate, domain.AuditFailure, "", "", nil)
+		apierrors.InternalError(w, "Failed to rotate signing keys")
+		return
+	}
+	keys := h.Keys.ListKeys()
+	details := map[string]string{}
+	for _, k := range keys {
+		if k.Active {
+			details[k.Ring] = k.ID
+		}
+	}
+	h.Audit.Record(r.Context(), auditActor(r), domain.AuditKeyRotate, domain.AuditSuccess, "", "", details)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(keys)
+}
+
+// @Summary      Remove a retired signing key
+// @Description  Drops a retired key before its TTL elapses (e.g. after a compromise). Every token it signed stops verifying immediately.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        kid path string true "Key ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /admin/keys/{kid} [delete]
+func (h *AdminKeyHandler) RemoveKey(w http.ResponseWriter, r *http.Request) {
+	kid := chi.URLParam(r, "kid")
+	err := h.Keys.RemoveKey(r.Context(), kid)
+	outcome := domain.AuditSuccess
+	if err != nil {
+		outcome = domain.AuditFailure
+	}
+	h.Audit.Record(r.Context(), auditActor(r), domain.AuditKeyRemove, outcome, "key", kid, nil)
+	switch {
+	case errors.Is(err, domain.ErrSigningKeyNotFound):
+		apierrors.NotFound(w, "Signing key not found")
+		return
+	case errors.Is(err, domain.ErrActiveSigningKey):
+		apierrors.Conflict(w, "The active signing key cannot be removed; rotate first")
+		return
+	case err != nil:
+		apierrors.InternalError(w, "Failed to remove signing key")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminLockoutHandler struct {
+	UserRepo domain.UserRepository
+	Guard    *usecase.LoginGuard
+	Audit    *usecase.Auditor
+}
+
+// @Summary      Unlock a user account
+// @Description  Lifts a temporary login lockout (password and second factor) and forgets the failed attempts, so the next lockout starts again from LOGIN_LOCKOUT_DURATION.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/users/{userId}/unlock [post]
+func (h *AdminLockoutHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.UserRepo.FindByID(chi.URLParam(r, "userId"))
+	if err != nil {
+		apierrors.InternalError(w, "Failed to find user")
+		return
+	}
+	if user == nil {
+		apierrors.NotFound(w, "User not found")
+		return
+	}
+
+	if err := h.Guard.Unlock(r.Context(), user); err != nil {
+		apierrors.InternalError(w, "Failed to unlock user")
+		return
+	}
+	h.Audit.Record(r.Context(), auditActor(r), domain.AuditUserUnlock, domain.AuditSuccess, "user", user.ID, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
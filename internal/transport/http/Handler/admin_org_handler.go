@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type AdminOrgHandler struct {
+	UC       *usecase.OrganizationUseCase
+	Validate *validator.Validate
+}
+
+type OrganizationResponse struct {
+	ID        string    `json:"id"` // tenant_id of its users, clients, roles, scopes and groups
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateOrganizationRequest struct {
+	Slug string `json:"slug" validate:"required,max=63" example:"acme"`
+	Name string `json:"name" validate:"required,max=200" example:"Acme Corp"`
+}
+
+func organizationResponse(o *domain.Organization) OrganizationResponse {
+	return OrganizationResponse{ID: o.ID, Slug: o.Slug, Name: o.Name, CreatedAt: o.CreatedAt}
+}
+
+// @Summary      Create an organization
+// @Description  Registers a tenant. Its id is the tenant_id of everything created under /orgs/{orgId} and of the tokens of its users and clients. The slug is lowercase letters and digits separated by hyphens.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body CreateOrganizationRequest true "Organization data"
+// @Success      201 {object} OrganizationResponse
+// @Failure      400 {object} map[string]string
+// @Failure      409 {object} map[string]string "Slug already in use"
+// @Failure      422 {object} map[string]string
+// @Router       /admin/orgs [post]
+func (h *AdminOrgHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var req CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	org, err := h.UC.Create(r.Context(), auditActor(r), req.Slug, req.Name)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidSlug):
+		apierrors.ValidationError(w, "Validation failed", "slug must be lowercase letters and digits separated by hyphens")
+		return
+	case errors.Is(err, domain.ErrSlugInUse):
+		apierrors.Conflict(w, "Organization with this slug already exists")
+		return
+	case err != nil:
+		apierrors.InternalError(w, "Failed to create organization")
+		return
+	}
+	writeJSON(w, http.StatusCreated, organizationResponse(org))
+}
+
+// @Summary      List organizations
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} OrganizationResponse
+// @Router       /admin/orgs [get]
+func (h *AdminOrgHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.UC.List()
+	if err != nil {
+		apierrors.InternalError(w, "Failed to list organizations")
+		return
+	}
+	resp := make([]OrganizationResponse, len(orgs))
+	for i, o := range orgs {
+		resp[i] = organizationResponse(o)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// @Summary      Get an organization
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId path string true "Organization ID"
+// @Success      200 {object} OrganizationResponse
+// @Failure      404 {object} map[string]string
+// @Router       /admin/orgs/{orgId} [get]
+func (h *AdminOrgHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	org, err := h.UC.Get(chi.URLParam(r, "orgId"))
+	if errors.Is(err, domain.ErrOrganizationNotFound) {
+		apierrors.NotFound(w, "Organization not found")
+		return
+	}
+	if err != nil {
+		apierrors.InternalError(w, "Failed to find organization")
+		return
+	}
+	writeJSON(w, http.StatusOK, organizationResponse(org))
+}
@@ -2,12 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
 	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
-	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -16,6 +19,15 @@ type AdminPermHandler struct {
 	Validate *validator.Validate
 }
 
+// uc returns the use case of the request: restricted to the organization of
+// the route under /orgs/{orgId}, platform-wide under /admin.
+func (h *AdminPermHandler) uc(r *http.Request) *usecase.PermAdminUseCase {
+	if tenant, ok := middleware.GetTenant(r); ok {
+		return h.UC.ForTenant(tenant)
+	}
+	return h.UC
+}
+
 type CreateRoleRequest struct {
 	Key  string `json:"key" validate:"required"`
 	Desc string `json:"desc" validate:"required"`
@@ -32,7 +44,6 @@ type idsBody struct {
 
 type grantUserScopeReq struct {
 	ScopeID   string     `json:"scope_id" validate:"required"`
-	GrantedBy string     `json:"granted_by" validate:"required"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
@@ -40,6 +51,28 @@ type revokeUserScopeReq struct {
 	ScopeID string `json:"scope_id" validate:"required"`
 }
 
+// RoleTreeNode is a role and, recursively, the roles it inherits from.
+type RoleTreeNode struct {
+	ID      string         `json:"id"`
+	Key     string         `json:"key"`
+	Desc    string         `json:"desc"`
+	Scopes  []string       `json:"scopes"`
+	Parents []RoleTreeNode `json:"parents,omitempty"`
+}
+
+type RoleTreeResponse struct {
+	RoleTreeNode
+	EffectiveScopes []string `json:"effective_scopes"`
+}
+
+func roleTreeNode(t domain.RoleTree) RoleTreeNode {
+	n := RoleTreeNode{ID: t.ID, Key: t.Key, Desc: t.Desc, Scopes: emptyIfNil(t.Scopes)}
+	for _, p := range t.Parents {
+		n.Parents = append(n.Parents, roleTreeNode(p))
+	}
+	return n
+}
+
 // @Summary Create scope
 // @Tags    Admin
 // @Accept  json
@@ -58,7 +91,7 @@ func (h *AdminPermHandler) CreateScope(w http.ResponseWriter, r *http.Request) {
 		apierrors.ValidationError(w, "Validation failed", err.Error())
 		return
 	}
-	s, err := h.UC.CreateScope(req.Key, req.Desc)
+	s, err := h.uc(r).CreateScope(r.Context(), auditActor(r), req.Key, req.Desc)
 	if err != nil {
 		apierrors.Conflict(w, "Scope with this key already exists")
 		return
@@ -73,8 +106,8 @@ func (h *AdminPermHandler) CreateScope(w http.ResponseWriter, r *http.Request) {
 // @Security BearerAuth
 // @Success 200 {array} map[string]string
 // @Router  /admin/scopes [get]
-func (h *AdminPermHandler) ListScopes(w http.ResponseWriter, _ *http.Request) {
-	list, err := h.UC.ListScopes()
+func (h *AdminPermHandler) ListScopes(w http.ResponseWriter, r *http.Request) {
+	list, err := h.uc(r).ListScopes()
 	if err != nil {
 		apierrors.InternalError(w, "Internal server error")
 		return
@@ -104,9 +137,9 @@ func (h *AdminPermHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 		apierrors.ValidationError(w, "Validation failed", err.Error())
 		return
 	}
-	role, err := h.UC.CreateRole(req.Key, req.Desc)
+	role, err := h.uc(r).CreateRole(r.Context(), auditActor(r), req.Key, req.Desc)
 	if err != nil {
-		apierrors.Conflict(w, "Resource conflict")
+		writeAssignError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
@@ -122,7 +155,7 @@ func (h *AdminPermHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 // @Success 204
 // @Router  /admin/roles/{roleId}/scopes [post]
 func (h *AdminPermHandler) AddScopesToRole(w http.ResponseWriter, r *http.Request) {
-	roleID := chiURLParam(r, "roleId")
+	roleID := chi.URLParam(r, "roleId")
 	var req idsBody
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		apierrors.BadRequest(w, "Invalid JSON payload")
@@ -132,8 +165,8 @@ func (h *AdminPermHandler) AddScopesToRole(w http.ResponseWriter, r *http.Reques
 		apierrors.ValidationError(w, "Validation failed", err.Error())
 		return
 	}
-	if err := h.UC.AddScopesToRole(roleID, req.IDs); err != nil {
-		apierrors.Conflict(w, "Resource conflict")
+	if err := h.uc(r).AddScopesToRole(r.Context(), auditActor(r), roleID, req.IDs); err != nil {
+		writeAssignError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -148,7 +181,7 @@ func (h *AdminPermHandler) AddScopesToRole(w http.ResponseWriter, r *http.Reques
 // @Success 204
 // @Router  /admin/users/{userId}/roles [post]
 func (h *AdminPermHandler) AddRolesToUser(w http.ResponseWriter, r *http.Request) {
-	userID := chiURLParam(r, "userId")
+	userID := chi.URLParam(r, "userId")
 	var req idsBody
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		apierrors.BadRequest(w, "Invalid JSON payload")
@@ -158,8 +191,8 @@ func (h *AdminPermHandler) AddRolesToUser(w http.ResponseWriter, r *http.Request
 		apierrors.ValidationError(w, "Validation failed", err.Error())
 		return
 	}
-	if err := h.UC.AddRolesToUser(userID, req.IDs); err != nil {
-		apierrors.Conflict(w, "Resource conflict")
+	if err := h.uc(r).AddRolesToUser(r.Context(), auditActor(r), userID, req.IDs); err != nil {
+		writeAssignError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -174,7 +207,7 @@ func (h *AdminPermHandler) AddRolesToUser(w http.ResponseWriter, r *http.Request
 // @Success 204
 // @Router  /admin/clients/{clientId}/scopes [post]
 func (h *AdminPermHandler) AddScopesToClient(w http.ResponseWriter, r *http.Request) {
-	clientID := chiURLParam(r, "clientId")
+	clientID := chi.URLParam(r, "clientId")
 	var req idsBody
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		apierrors.BadRequest(w, "Invalid JSON payload")
@@ -184,20 +217,13 @@ func (h *AdminPermHandler) AddScopesToClient(w http.ResponseWriter, r *http.Requ
 		apierrors.ValidationError(w, "Validation failed", err.Error())
 		return
 	}
-	if err := h.UC.AddScopesToClient(clientID, req.IDs); err != nil {
-		apierrors.Conflict(w, "Resource conflict")
+	if err := h.uc(r).AddScopesToClient(r.Context(), auditActor(r), clientID, req.IDs); err != nil {
+		writeAssignError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func chiURLParam(r *http.Request, key string) string {
-	if rctx := chi.RouteContext(r.Context()); rctx != nil {
-		return rctx.URLParam(key)
-	}
-	return ""
-}
-
 // @Summary      List roles
 // @Description  Returns all roles (id, key, desc)
 // @Tags         Admin
@@ -205,8 +231,8 @@ func chiURLParam(r *http.Request, key string) string {
 // @Security     BearerAuth
 // @Success      200 {array} map[string]string
 // @Router       /admin/roles [get]
-func (h *AdminPermHandler) ListRoles(w http.ResponseWriter, _ *http.Request) {
-	roles, err := h.UC.ListRoles()
+func (h *AdminPermHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.uc(r).ListRoles()
 	if err != nil {
 		apierrors.InternalError(w, "Internal server error")
 		return
@@ -228,9 +254,9 @@ func (h *AdminPermHandler) ListRoles(w http.ResponseWriter, _ *http.Request) {
 // @Router       /admin/users/{userId}/roles [get]
 func (h *AdminPermHandler) ListUserRoles(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "userId")
-	roles, err := h.UC.ListUserRoles(userID)
+	roles, err := h.uc(r).ListUserRoles(userID)
 	if err != nil {
-		apierrors.InternalError(w, "Internal server error")
+		writePermError(w, err)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(roles)
@@ -246,16 +272,16 @@ func (h *AdminPermHandler) ListUserRoles(w http.ResponseWriter, r *http.Request)
 // @Router       /admin/users/{userId}/scopes [get]
 func (h *AdminPermHandler) ListUserEffective(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "userId")
-	roles, scopes, err := h.UC.ListUserScopesEffective(userID)
+	roles, scopes, err := h.uc(r).ListUserScopesEffective(userID)
 	if err != nil {
-		apierrors.InternalError(w, "Internal server error")
+		writePermError(w, err)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{"roles": roles, "scopes": scopes})
 }
 
 // @Summary      Grant direct scope to user
-// @Description  Grants a scope directly to the user (optional expiration). Prefer roles; use direct scopes for exceptions.
+// @Description  Grants a scope directly to the user (optional expiration). The grant is attributed to the calling admin. Prefer roles; use direct scopes for exceptions.
 // @Tags         Admin
 // @Accept       json
 // @Security     BearerAuth
@@ -277,8 +303,8 @@ func (h *AdminPermHandler) GrantUserScope(w http.ResponseWriter, r *http.Request
 		apierrors.ValidationError(w, "Validation failed", err.Error())
 		return
 	}
-	if err := h.UC.GrantUserScope(userID, req.ScopeID, req.GrantedBy, req.ExpiresAt); err != nil {
-		apierrors.Conflict(w, "Resource conflict")
+	if err := h.uc(r).GrantUserScope(r.Context(), auditActor(r), userID, req.ScopeID, req.ExpiresAt); err != nil {
+		writeAssignError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -307,8 +333,8 @@ func (h *AdminPermHandler) RevokeUserScope(w http.ResponseWriter, r *http.Reques
 		apierrors.ValidationError(w, "Validation failed", err.Error())
 		return
 	}
-	if err := h.UC.RevokeUserScope(userID, req.ScopeID); err != nil {
-		apierrors.Conflict(w, "Resource conflict")
+	if err := h.uc(r).RevokeUserScope(r.Context(), auditActor(r), userID, req.ScopeID); err != nil {
+		writeAssignError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -324,10 +350,193 @@ func (h *AdminPermHandler) RevokeUserScope(w http.ResponseWriter, r *http.Reques
 // @Router       /admin/clients/{clientId}/scopes [get]
 func (h *AdminPermHandler) ListClientScopes(w http.ResponseWriter, r *http.Request) {
 	clientID := chi.URLParam(r, "clientId")
-	scopes, err := h.UC.ListClientScopes(clientID)
+	scopes, err := h.uc(r).ListClientScopes(clientID)
 	if err != nil {
-		apierrors.InternalError(w, "Internal server error")
+		writePermError(w, err)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(scopes)
 }
+
+// writePermError maps the errors of the delete/detach, inheritance and group
+// operations.
+func writePermError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrRoleNotFound):
+		apierrors.NotFound(w, "Role not found")
+	case errors.Is(err, domain.ErrScopeNotFound):
+		apierrors.NotFound(w, "Scope not found")
+	case errors.Is(err, domain.ErrGroupNotFound):
+		apierrors.NotFound(w, "Group not found")
+	case errors.Is(err, domain.ErrUserNotFound):
+		apierrors.NotFound(w, "User not found")
+	case errors.Is(err, domain.ErrClientNotFound):
+		apierrors.NotFound(w, "Client not found")
+	case errors.Is(err, domain.ErrRoleCycle):
+		apierrors.Conflict(w, "A role cannot inherit from itself")
+	case errors.Is(err, domain.ErrCrossTenant):
+		apierrors.Conflict(w, "Cannot assign across organizations")
+	default:
+		apierrors.InternalError(w, "Internal server error")
+	}
+}
+
+// writeAssignError is writePermError for assignments, where any other
+// failure is reported as a conflict.
+func writeAssignError(w http.ResponseWriter, err error) {
+	for _, known := range []error{
+		domain.ErrRoleNotFound, domain.ErrScopeNotFound, domain.ErrGroupNotFound,
+		domain.ErrUserNotFound, domain.ErrClientNotFound, domain.ErrCrossTenant,
+	} {
+		if errors.Is(err, known) {
+			writePermError(w, err)
+			return
+		}
+	}
+	apierrors.Conflict(w, "Resource conflict")
+}
+
+// @Summary      Delete scope
+// @Description  Deletes the scope and detaches it from every role, user and client
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        scopeId path string true "Scope ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/scopes/{scopeId} [delete]
+func (h *AdminPermHandler) DeleteScope(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).DeleteScope(r.Context(), auditActor(r), chi.URLParam(r, "scopeId")); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Delete role
+// @Description  Deletes the role and removes it from every user holding it
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        roleId path string true "Role ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/roles/{roleId} [delete]
+func (h *AdminPermHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).DeleteRole(r.Context(), auditActor(r), chi.URLParam(r, "roleId")); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Detach scope from role
+// @Description  Removes the scope from the role; every holder of the role loses it unless granted otherwise
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        roleId path string true "Role ID"
+// @Param        scopeId path string true "Scope ID"
+// @Success      204
+// @Router       /admin/roles/{roleId}/scopes/{scopeId} [delete]
+func (h *AdminPermHandler) RemoveScopeFromRole(w http.ResponseWriter, r *http.Request) {
+	roleID, scopeID := chi.URLParam(r, "roleId"), chi.URLParam(r, "scopeId")
+	if err := h.uc(r).RemoveScopesFromRole(r.Context(), auditActor(r), roleID, []string{scopeID}); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Remove role from user
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Param        roleId path string true "Role ID"
+// @Success      204
+// @Router       /admin/users/{userId}/roles/{roleId} [delete]
+func (h *AdminPermHandler) RemoveRoleFromUser(w http.ResponseWriter, r *http.Request) {
+	userID, roleID := chi.URLParam(r, "userId"), chi.URLParam(r, "roleId")
+	if err := h.uc(r).RemoveRolesFromUser(r.Context(), auditActor(r), userID, []string{roleID}); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Detach scope from client
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        clientId path string true "Client ID"
+// @Param        scopeId path string true "Scope ID"
+// @Success      204
+// @Router       /admin/clients/{clientId}/scopes/{scopeId} [delete]
+func (h *AdminPermHandler) RemoveScopeFromClient(w http.ResponseWriter, r *http.Request) {
+	clientID, scopeID := chi.URLParam(r, "clientId"), chi.URLParam(r, "scopeId")
+	if err := h.uc(r).RemoveScopesFromClient(r.Context(), auditActor(r), clientID, []string{scopeID}); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Add parent roles
+// @Description  Makes the role inherit every scope of the given roles (and of their own parents). Rejected when the role would inherit from itself.
+// @Tags         Admin
+// @Accept       json
+// @Security     BearerAuth
+// @Param        roleId path string true "Role ID"
+// @Param        request body idsBody true "Parent role IDs"
+// @Success      204
+// @Failure      404 {object} map[string]string "Unknown role"
+// @Failure      409 {object} map[string]string "Inheritance cycle"
+// @Failure      422 {object} map[string]string
+// @Router       /admin/roles/{roleId}/parents [post]
+func (h *AdminPermHandler) AddParentRoles(w http.ResponseWriter, r *http.Request) {
+	roleID := chi.URLParam(r, "roleId")
+	var req idsBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+	if err := h.uc(r).AddParentRoles(r.Context(), auditActor(r), roleID, req.IDs); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Remove a parent role
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        roleId path string true "Role ID"
+// @Param        parentId path string true "Parent role ID"
+// @Success      204
+// @Router       /admin/roles/{roleId}/parents/{parentId} [delete]
+func (h *AdminPermHandler) RemoveParentRole(w http.ResponseWriter, r *http.Request) {
+	roleID, parentID := chi.URLParam(r, "roleId"), chi.URLParam(r, "parentId")
+	if err := h.uc(r).RemoveParentRoles(r.Context(), auditActor(r), roleID, []string{parentID}); err != nil {
+		writePermError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Get the role tree
+// @Description  Returns the role with the roles it inherits from, recursively, each with its own scopes, and the scopes the role resolves to.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        roleId path string true "Role ID"
+// @Success      200 {object} RoleTreeResponse
+// @Failure      404 {object} map[string]string
+// @Router       /admin/roles/{roleId}/tree [get]
+func (h *AdminPermHandler) RoleTree(w http.ResponseWriter, r *http.Request) {
+	tree, scopes, err := h.uc(r).RoleTree(chi.URLParam(r, "roleId"))
+	if err != nil {
+		writePermError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, RoleTreeResponse{RoleTreeNode: roleTreeNode(tree), EffectiveScopes: emptyIfNil(scopes)})
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type AdminPolicyHandler struct {
+	UC       *usecase.PolicyUseCase
+	Validate *validator.Validate
+}
+
+// uc manages the policies of the organization when serving /orgs/{orgId}.
+func (h *AdminPolicyHandler) uc(r *http.Request) *usecase.PolicyUseCase {
+	if tenant, ok := middleware.GetTenant(r); ok {
+		return h.UC.ForTenant(tenant)
+	}
+	return h.UC
+}
+
+type PolicyResponse struct {
+	ID          string                  `json:"id"`
+	TenantID    string                  `json:"tenant_id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Effect      string                  `json:"effect" example:"allow"`
+	Actions     []string                `json:"actions"`
+	Subject     map[string][]string     `json:"subject"`
+	Resource    map[string][]string     `json:"resource"`
+	Conditions  domain.PolicyConditions `json:"conditions"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+type CreatePolicyRequest struct {
+	Name        string   `json:"name" validate:"required,max=200" example:"editors-edit-own-documents"`
+	Description string   `json:"description" validate:"max=1000"`
+	Effect      string   `json:"effect" validate:"required,oneof=allow deny" example:"allow"`
+	Actions     []string `json:"actions" validate:"required,min=1,dive,required" example:"documents:edit"`
+
+	// Subject attribute (id, type, email, client_id, tenant_id, roles,
+	// scopes, audience) to its accepted values.
+	Subject map[string][]string `json:"subject,omitempty"`
+	// Resource attribute to its accepted values; "$subject.<attr>" stands
+	// for an attribute of the caller.
+	Resource   map[string][]string     `json:"resource,omitempty"`
+	Conditions domain.PolicyConditions `json:"conditions"`
+}
+
+func policyResponse(p *domain.Policy) PolicyResponse {
+	resp := PolicyResponse{
+		ID:          p.ID,
+		TenantID:    p.TenantID,
+		Name:        p.Name,
+		Description: p.Description,
+		Effect:      string(p.Effect),
+		Actions:     emptyIfNil(p.Actions),
+		Subject:     p.Subject,
+		Resource:    p.Resource,
+		Conditions:  p.Conditions,
+		CreatedAt:   p.CreatedAt,
+	}
+	if resp.Subject == nil {
+		resp.Subject = map[string][]string{}
+	}
+	if resp.Resource == nil {
+		resp.Resource = map[string][]string{}
+	}
+	return resp
+}
+
+func writePolicyError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrPolicyNotFound):
+		apierrors.NotFound(w, "Policy not found")
+	case errors.Is(err, usecase.ErrInvalidPolicy):
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+	default:
+		apierrors.InternalError(w, fallback)
+	}
+}
+
+// @Summary      Create a policy
+// @Description  Adds an attribute-based rule to the policies evaluated by /authz/check. It applies to the actions listed when every subject and resource attribute listed has one of the accepted values and the conditions (time of day, IP ranges) hold. A matching deny wins over any allow; nothing is allowed without a matching allow.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body CreatePolicyRequest true "Policy"
+// @Success      201 {object} PolicyResponse
+// @Failure      400 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Router       /admin/policies [post]
+func (h *AdminPolicyHandler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	var req CreatePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	p, err := h.uc(r).Create(r.Context(), auditActor(r), domain.Policy{
+		Name:        req.Name,
+		Description: req.Description,
+		Effect:      domain.PolicyEffect(req.Effect),
+		Actions:     req.Actions,
+		Subject:     req.Subject,
+		Resource:    req.Resource,
+		Conditions:  req.Conditions,
+	})
+	if err != nil {
+		writePolicyError(w, err, "Failed to create policy")
+		return
+	}
+	writeJSON(w, http.StatusCreated, policyResponse(p))
+}
+
+// @Summary      List policies
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} PolicyResponse
+// @Router       /admin/policies [get]
+func (h *AdminPolicyHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.uc(r).List()
+	if err != nil {
+		apierrors.InternalError(w, "Failed to list policies")
+		return
+	}
+	resp := make([]PolicyResponse, len(policies))
+	for i, p := range policies {
+		resp[i] = policyResponse(p)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// @Summary      Get a policy
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        policyId path string true "Policy ID"
+// @Success      200 {object} PolicyResponse
+// @Failure      404 {object} map[string]string
+// @Router       /admin/policies/{policyId} [get]
+func (h *AdminPolicyHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	p, err := h.uc(r).Get(chi.URLParam(r, "policyId"))
+	if err != nil {
+		writePolicyError(w, err, "Failed to find policy")
+		return
+	}
+	writeJSON(w, http.StatusOK, policyResponse(p))
+}
+
+// @Summary      Delete a policy
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        policyId path string true "Policy ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/policies/{policyId} [delete]
+func (h *AdminPolicyHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).Delete(r.Context(), auditActor(r), chi.URLParam(r, "policyId")); err != nil {
+		writePolicyError(w, err, "Failed to delete policy")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
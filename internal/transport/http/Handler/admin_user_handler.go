@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type AdminUserHandler struct {
+	UC       *usecase.AdminUserUseCase
+	Validate *validator.Validate
+}
+
+// uc only sees the users of the organization when serving /orgs/{orgId}.
+func (h *AdminUserHandler) uc(r *http.Request) *usecase.AdminUserUseCase {
+	if tenant, ok := middleware.GetTenant(r); ok {
+		return h.UC.ForTenant(tenant)
+	}
+	return h.UC
+}
+
+type AdminUserResponse struct {
+	ID                    string    `json:"id"`
+	Email                 string    `json:"email"`
+	TenantID              string    `json:"tenant_id"`
+	Verified              bool      `json:"verified"`
+	Disabled              bool      `json:"disabled"`
+	PasswordResetRequired bool      `json:"password_reset_required"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+type AdminUserListResponse struct {
+	Users  []AdminUserResponse `json:"users"`
+	Total  int64               `json:"total"`
+	Offset int                 `json:"offset"`
+}
+
+type UpdateUserRequest struct {
+	Email    *string `json:"email,omitempty" validate:"omitempty,email" example:"user@example.com"`
+	Verified *bool   `json:"verified,omitempty"`
+
+	// Organization to move the user to; "" moves it to the platform.
+	TenantID *string `json:"tenant_id,omitempty" validate:"omitnil,len=0|uuid"`
+}
+
+func adminUserResponse(u *domain.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:                    u.ID,
+		Email:                 u.Email,
+		TenantID:              u.TenantID,
+		Verified:              u.Verified,
+		Disabled:              u.Disabled,
+		PasswordResetRequired: u.PasswordResetRequired,
+		CreatedAt:             u.CreatedAt,
+	}
+}
+
+func parseBoolParam(v string) (*bool, error) {
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// writeAdminUserError maps the errors of AdminUserUseCase; fallback is the
+// message of unexpected ones.
+func writeAdminUserError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		apierrors.NotFound(w, "User not found")
+	case errors.Is(err, domain.ErrEmailInUse):
+		apierrors.Conflict(w, "User with this email already exists")
+	case errors.Is(err, usecase.ErrSelfModification):
+		apierrors.Forbidden(w, "You cannot disable or delete your own account")
+	case errors.Is(err, domain.ErrCrossTenant):
+		apierrors.Forbidden(w, "Users cannot be moved out of their organization")
+	case errors.Is(err, domain.ErrOrganizationNotFound):
+		apierrors.NotFound(w, "Organization not found")
+	default:
+		apierrors.InternalError(w, fallback)
+	}
+}
+
+// @Summary      List users
+// @Description  Returns a page of users, newest first.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        search query string false "Part of the email (case-insensitive)"
+// @Param        verified query bool false "Only verified (true) or unverified (false) users"
+// @Param        disabled query bool false "Only disabled (true) or enabled (false) users"
+// @Param        offset query int false "Users to skip"
+// @Param        limit query int false "Page size (default 50, max 200)"
+// @Success      200 {object} AdminUserListResponse
+// @Failure      400 {object} map[string]string
+// @Router       /admin/users [get]
+func (h *AdminUserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := domain.UserFilter{Search: q.Get("search")}
+
+	var err error
+	if f.Verified, err = parseBoolParam(q.Get("verified")); err != nil {
+		apierrors.BadRequest(w, "verified must be true or false")
+		return
+	}
+	if f.Disabled, err = parseBoolParam(q.Get("disabled")); err != nil {
+		apierrors.BadRequest(w, "disabled must be true or false")
+		return
+	}
+	if v := q.Get("offset"); v != "" {
+		if f.Offset, err = strconv.Atoi(v); err != nil || f.Offset < 0 {
+			apierrors.BadRequest(w, "offset must be a non-negative integer")
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit <= 0 {
+			apierrors.BadRequest(w, "limit must be a positive integer")
+			return
+		}
+	}
+
+	users, total, err := h.uc(r).List(f)
+	if err != nil {
+		apierrors.InternalError(w, "Failed to list users")
+		return
+	}
+	resp := AdminUserListResponse{Users: make([]AdminUserResponse, len(users)), Total: total, Offset: f.Offset}
+	for i, u := range users {
+		resp.Users[i] = adminUserResponse(u)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// @Summary      Get a user
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Success      200 {object} AdminUserResponse
+// @Failure      404 {object} map[string]string
+// @Router       /admin/users/{userId} [get]
+func (h *AdminUserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.uc(r).Get(chi.URLParam(r, "userId"))
+	if err != nil {
+		writeAdminUserError(w, err, "Failed to find user")
+		return
+	}
+	writeJSON(w, http.StatusOK, adminUserResponse(user))
+}
+
+// @Summary      Update a user
+// @Description  Changes the email, the verified flag and/or the organization. A new email is unverified unless verified is sent too. Moving a user to another organization (tenant_id, "" for the platform) drops its roles, scopes and groups and ends all its sessions; only platform administrators can.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Param        request body UpdateUserRequest true "Fields to change"
+// @Success      200 {object} AdminUserResponse
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string "Move requested through /orgs"
+// @Failure      404 {object} map[string]string "User or organization not found"
+// @Failure      409 {object} map[string]string "Email already in use"
+// @Failure      422 {object} map[string]string
+// @Router       /admin/users/{userId} [patch]
+func (h *AdminUserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	var req UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	user, err := h.uc(r).Update(r.Context(), auditActor(r), chi.URLParam(r, "userId"), usecase.UserUpdate{
+		Email:    req.Email,
+		Verified: req.Verified,
+		TenantID: req.TenantID,
+	})
+	if err != nil {
+		writeAdminUserError(w, err, "Failed to update user")
+		return
+	}
+	writeJSON(w, http.StatusOK, adminUserResponse(user))
+}
+
+// @Summary      Disable a user
+// @Description  Blocks every sign-in of the user and ends all their sessions.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Success      204
+// @Failure      403 {object} map[string]string "Own account"
+// @Failure      404 {object} map[string]string
+// @Router       /admin/users/{userId}/disable [post]
+func (h *AdminUserHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).SetDisabled(r.Context(), auditActor(r), chi.URLParam(r, "userId"), true); err != nil {
+		writeAdminUserError(w, err, "Failed to disable user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Enable a user
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/users/{userId}/enable [post]
+func (h *AdminUserHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).SetDisabled(r.Context(), auditActor(r), chi.URLParam(r, "userId"), false); err != nil {
+		writeAdminUserError(w, err, "Failed to enable user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Delete a user
+// @Description  Deletes the user with their role and scope assignments, second factors and passkeys, and ends all their sessions.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Success      204
+// @Failure      403 {object} map[string]string "Own account"
+// @Failure      404 {object} map[string]string
+// @Router       /admin/users/{userId} [delete]
+func (h *AdminUserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).Delete(r.Context(), auditActor(r), chi.URLParam(r, "userId")); err != nil {
+		writeAdminUserError(w, err, "Failed to delete user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Force a password reset
+// @Description  Blocks sign-in until the user sets a new password through /auth/password/reset, ends all their sessions and emails them a reset link.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/users/{userId}/password/reset [post]
+func (h *AdminUserHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc(r).ForcePasswordReset(r.Context(), auditActor(r), chi.URLParam(r, "userId")); err != nil {
+		writeAdminUserError(w, err, "Failed to force a password reset")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,6 +22,12 @@ type AuthHandler struct {
 	TokenService         domain.TokenService
 	Cache                *cache.RedisClient
 	PermissionRepository domain.PermissionRepository
+
+	// MFA, when set, turns logins of enrolled users into a two-step flow.
+	MFA *usecase.MFAUseCase
+
+	// Audit, when set, records refreshes and logouts.
+	Audit *usecase.Auditor
 }
 
 type LoginRequest struct {
@@ -35,6 +43,19 @@ type AuthResponse struct {
 	RefreshExp   time.Time `json:"refresh_exp"`
 }
 
+// MFAChallengeResponse is returned by /auth/login instead of tokens when the
+// user has MFA enabled.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in" example:"300"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required" example:"123456"`
+}
+
 // UserResponse represents basic user information
 type UserResponse struct {
 	ID    string `json:"id" example:"123"`
@@ -62,23 +83,9 @@ type LogoutRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
-type IntrospectRequest struct {
-	Token string `json:"token" validate:"required"`
-}
-
-type IntrospectResponse struct {
-	Active      bool     `json:"active"`
-	SubjectType string   `json:"subject_type,omitempty"`
-	Sub         string   `json:"sub,omitempty"`
-	Email       string   `json:"email,omitempty"`
-	Roles       []string `json:"roles,omitempty"`
-	Scope       []string `json:"scope,omitempty"`
-	Aud         []string `json:"aud,omitempty"`
-}
-
 // SignUpHandler godoc
 // @Summary Register a new user
-// @Description Creates a new user account and returns an access+refresh token pair
+// @Description Creates a new user account, emails a verification link and returns an access+refresh token pair. When REQUIRE_EMAIL_VERIFICATION is on, no tokens are issued: the response is the created user and the client signs in after verifying.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -87,6 +94,7 @@ type IntrospectResponse struct {
 // @Failure 400 {object} map[string]string
 // @Failure 422 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 429 {object} map[string]string "Rate limited; see Retry-After"
 // @Router /auth/signup [post]
 func (h *AuthHandler) SignUpHandler(w http.ResponseWriter, r *http.Request) {
 	// 1) Decode and validate payload
@@ -101,16 +109,27 @@ func (h *AuthHandler) SignUpHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2) Create user (domain-level)
-	user, err := h.Signup.Execute(req.Email, req.Password)
+	user, err := h.Signup.Execute(r.Context(), req.Email, req.Password, sessionMeta(r).IP)
 	if err != nil {
 		if strings.Contains(err.Error(), "already exists") {
 			apierrors.Conflict(w, "User with this email already exists")
 			return
 		}
+		if errors.Is(err, usecase.ErrWeakPassword) {
+			apierrors.ValidationError(w, "Password does not meet the policy", err.Error())
+			return
+		}
 		apierrors.InternalError(w, "Failed to create user")
 		return
 	}
 
+	if h.Login.RequireVerified {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(UserResponse{ID: user.ID, Email: user.Email})
+		return
+	}
+
 	roles, scopes, err := h.PermissionRepository.ListUserScopesEffective(user.ID, time.Now())
 	if err != nil {
 		apierrors.InternalError(w, "Failed to fetch user permissions")
@@ -125,9 +144,11 @@ func (h *AuthHandler) SignUpHandler(w http.ResponseWriter, r *http.Request) {
 		Roles:    roles,
 		Scopes:   scopes,
 		Audience: nil,
+		TenantID: user.TenantID,
+		AuthTime: time.Now(),
 	}
 
-	pair, err := h.TokenService.IssuePair(principal)
+	pair, err := h.TokenService.IssuePair(principal, sessionMeta(r))
 	if err != nil {
 		apierrors.InternalError(w, "Failed to issue authentication tokens")
 		return
@@ -150,13 +171,17 @@ func (h *AuthHandler) SignUpHandler(w http.ResponseWriter, r *http.Request) {
 
 // LoginHandler godoc
 // @Summary Authenticate a user
-// @Description Logs in a user with email and password, returning a JWT token
+// @Description Logs in a user with email and password, returning a JWT token. Users with MFA enabled get an MFAChallengeResponse instead, to complete at /auth/mfa/verify.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body LoginRequest true "User login credentials"
 // @Success 200 {object} AuthResponse
+// @Success 200 {object} MFAChallengeResponse "MFA required"
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Email not verified, account disabled or password reset required"
+// @Failure 423 {object} map[string]string "Account locked; see Retry-After"
+// @Failure 429 {object} map[string]string "Too many failures from this IP; see Retry-After"
 // @Router /auth/login [post]
 func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	// 1) Decode and validate input
@@ -170,12 +195,83 @@ func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.Login.Execute(req.Email, req.Password)
+	user, err := h.Login.Execute(r.Context(), req.Email, req.Password, sessionMeta(r).IP)
+	if writeLockout(w, err) {
+		return
+	}
+	if writeSignInRefused(w, err) {
+		return
+	}
 	if err != nil || user.ID == "" {
 		apierrors.Unauthorized(w, "Invalid email or password")
 		return
 	}
 
+	if h.MFA != nil {
+		enabled, err := h.MFA.Enabled(user.ID)
+		if err != nil {
+			apierrors.InternalError(w, "Failed to check multi-factor authentication")
+			return
+		}
+		if enabled {
+			challenge, err := h.MFA.Challenge(r.Context(), user.ID)
+			if err != nil {
+				apierrors.InternalError(w, "Failed to start multi-factor authentication")
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			_ = json.NewEncoder(w).Encode(MFAChallengeResponse{
+				MFARequired: true,
+				MFAToken:    challenge,
+				ExpiresIn:   int64(h.MFA.ChallengeTTL.Seconds()),
+			})
+			return
+		}
+	}
+
+	h.issueUserTokens(w, r, user)
+}
+
+// writeLockout answers a throttled login (423 for a locked account, 429 for a
+// throttled IP) with Retry-After. It reports whether err was one.
+func writeLockout(w http.ResponseWriter, err error) bool {
+	var le *domain.LockoutError
+	if !errors.As(err, &le) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(le.RetryAfter)))
+	if errors.Is(err, domain.ErrAccountLocked) {
+		apierrors.Locked(w, "Account temporarily locked after too many failed logins")
+	} else {
+		apierrors.TooManyRequests(w, "Too many failed logins; try again later")
+	}
+	return true
+}
+
+// writeSignInRefused answers 403 for an account that proved its identity but
+// may not sign in.
+func writeSignInRefused(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, domain.ErrEmailNotVerified):
+		apierrors.Forbidden(w, "Email address not verified")
+	case errors.Is(err, domain.ErrUserDisabled):
+		apierrors.Forbidden(w, "Account disabled")
+	case errors.Is(err, domain.ErrPasswordResetRequired):
+		apierrors.Forbidden(w, "Password reset required; use /auth/password/forgot")
+	default:
+		return false
+	}
+	return true
+}
+
+// retryAfterSeconds rounds up, so clients never retry before the lock ends.
+func retryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// issueUserTokens answers a completed sign-in with a fresh access+refresh pair.
+func (h *AuthHandler) issueUserTokens(w http.ResponseWriter, r *http.Request, user *domain.User) {
 	roles, scopes, err := h.PermissionRepository.ListUserScopesEffective(user.ID, time.Now())
 	if err != nil {
 		apierrors.InternalError(w, "Failed to fetch user permissions")
@@ -185,13 +281,15 @@ func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	principal := domain.Principal{
 		Type:     domain.PrincipalUser,
 		ID:       user.ID,
-		Email:    req.Email,
+		Email:    user.Email,
 		Roles:    roles,
 		Scopes:   scopes,
 		Audience: nil,
+		TenantID: user.TenantID,
+		AuthTime: time.Now(),
 	}
 
-	pair, err := h.TokenService.IssuePair(principal)
+	pair, err := h.TokenService.IssuePair(principal, sessionMeta(r))
 	if err != nil {
 		apierrors.InternalError(w, "Failed to issue authentication tokens")
 		return
@@ -206,9 +304,53 @@ func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// VerifyMFAHandler godoc
+// @Summary Complete a login with a second factor
+// @Description Exchanges the mfa_token returned by /auth/login, plus a TOTP code or an unused recovery code, for an access+refresh pair. The challenge expires after MFA_CHALLENGE_TTL, is single-use and allows MFA_MAX_ATTEMPTS codes.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body MFAVerifyRequest true "Challenge and code"
+// @Success 200 {object} AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 403 {object} map[string]string "Account disabled or password reset required"
+// @Failure 423 {object} map[string]string "Account locked; see Retry-After"
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var req MFAVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	user, err := h.MFA.Verify(r.Context(), req.MFAToken, req.Code)
+	if writeLockout(w, err) || writeSignInRefused(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrMFAChallengeNotFound):
+		apierrors.Unauthorized(w, "Invalid or expired MFA challenge; sign in again")
+		return
+	case errors.Is(err, domain.ErrInvalidMFACode):
+		apierrors.Unauthorized(w, "Invalid verification code")
+		return
+	case err != nil:
+		apierrors.InternalError(w, "Failed to verify the code")
+		return
+	}
+
+	h.issueUserTokens(w, r, user)
+}
+
 // RefreshHandler godoc
 // @Summary Rotate tokens using a valid refresh token
-// @Description Exchanges a valid refresh token for a new access+refresh pair
+// @Description Exchanges a valid refresh token for a new access+refresh pair. Each refresh token is single-use: presenting one that was already rotated revokes every token of its login session. Refresh tokens issued to an OAuth client are refused here and must be redeemed at /oauth/token.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -216,6 +358,7 @@ func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} RefreshResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string "Rate limited; see Retry-After"
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
 	var req RefreshRequest
@@ -228,11 +371,28 @@ func (h *AuthHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pair, err := h.TokenService.Rotate(req.RefreshToken)
+	actor := auditActor(r)
+	pair, err := h.TokenService.RotateForClient(req.RefreshToken, "", sessionMeta(r))
+	if errors.Is(err, domain.ErrTokenClientMismatch) {
+		h.Audit.Record(r.Context(), actor, domain.AuditRefresh, domain.AuditFailure, "", "", map[string]string{"reason": "client_mismatch"})
+		apierrors.Unauthorized(w, "Refresh token was issued to an OAuth client; redeem it at /oauth/token")
+		return
+	}
+	if errors.Is(err, domain.ErrRefreshReused) {
+		h.Audit.Record(r.Context(), actor, domain.AuditRefresh, domain.AuditFailure, "", "", map[string]string{"reason": "reused"})
+		apierrors.Unauthorized(w, "Refresh token already used; the session has been revoked")
+		return
+	}
 	if err != nil {
+		h.Audit.Record(r.Context(), actor, domain.AuditRefresh, domain.AuditFailure, "", "", map[string]string{"reason": "invalid"})
 		apierrors.Unauthorized(w, "Invalid or expired refresh token")
 		return
 	}
+	var sessionID string
+	if claims, err := h.TokenService.VerifyAccess(pair.AccessToken); err == nil {
+		actor.Type, actor.ID, sessionID = claims.SubjectType, claims.SubjectID, claims.FamilyID
+	}
+	h.Audit.Record(r.Context(), actor, domain.AuditRefresh, domain.AuditSuccess, "session", sessionID, nil)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(RefreshResponse{
@@ -245,7 +405,7 @@ func (h *AuthHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
 
 // LogoutHandler godoc
 // @Summary Logout and revoke tokens
-// @Description Revokes the provided tokens: access is blacklisted; refresh is removed from Redis.
+// @Description Revokes the provided tokens: access is blacklisted; the refresh token and every token of its login session are revoked.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -273,52 +433,18 @@ func (h *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Identify the session before it goes away.
+	actor := auditActor(r)
+	var sessionID string
+	if active, claims, err := h.TokenService.IntrospectRefresh(req.RefreshToken); err == nil && active {
+		actor.Type, actor.ID, sessionID = claims.SubjectType, claims.SubjectID, claims.FamilyID
+	}
+
 	if err := h.TokenService.RevokePair(access, req.RefreshToken); err != nil {
 		apierrors.InternalError(w, "Failed to revoke tokens")
 		return
 	}
+	h.Audit.Record(r.Context(), actor, domain.AuditLogout, domain.AuditSuccess, "session", sessionID, nil)
 
 	w.WriteHeader(http.StatusNoContent)
 }
-
-// IntrospectHandler godoc
-// @Summary Introspect an access token
-// @Description Validates an access token and returns whether it's active along with principal info
-// @Tags auth
-// @Accept json
-// @Produce json
-// @Param input body IntrospectRequest true "Token to introspect"
-// @Success 200 {object} IntrospectResponse
-// @Failure 400 {object} map[string]string
-// @Router /auth/introspect [post]
-func (h *AuthHandler) IntrospectHandler(w http.ResponseWriter, r *http.Request) {
-	var req IntrospectRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		apierrors.BadRequest(w, "Invalid JSON payload")
-		return
-	}
-	if err := h.Validate.Struct(req); err != nil {
-		apierrors.ValidationError(w, "Validation failed", err.Error())
-		return
-	}
-
-	active, claims, err := h.TokenService.Introspect(req.Token)
-	if err != nil {
-		// Operational error on our side (e.g., Redis down)
-		apierrors.InternalError(w, "Token introspection failed")
-		return
-	}
-
-	resp := IntrospectResponse{Active: active}
-	if active && claims != nil {
-		resp.SubjectType = string(claims.SubjectType)
-		resp.Sub = claims.SubjectID
-		resp.Email = claims.Email
-		resp.Roles = claims.Roles
-		resp.Scope = claims.Scopes
-		resp.Aud = claims.Audience
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
-}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/service/token"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRefreshHandlerRefusesClientTokens(t *testing.T) {
+	metrics.MustRegister()
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	svc := token.NewService(token.Config{
+		AccessSecret:  []byte("access-secret-access-secret-0123"),
+		RefreshSecret: []byte("refresh-secret-refresh-secret-01"),
+		AccessTTL:     time.Minute,
+		RefreshTTL:    time.Hour,
+	}, rdb)
+	h := &AuthHandler{Validate: validator.New(), TokenService: svc}
+
+	tests := []struct {
+		name     string
+		clientID string
+		want     int
+	}{
+		{name: "first-party token", want: http.StatusOK},
+		{name: "token issued to an OAuth client", clientID: "app", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair, err := svc.IssuePair(domain.Principal{Type: domain.PrincipalUser, ID: "u1", ClientID: tt.clientID}, domain.SessionMeta{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			body := strings.NewReader(`{"refresh_token":"` + pair.RefreshToken + `"}`)
+			h.RefreshHandler(rec, httptest.NewRequest(http.MethodPost, "/auth/refresh", body))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+
+			if tt.clientID != "" {
+				// Refused without being consumed: its client can still redeem it.
+				if _, err := svc.RotateForClient(pair.RefreshToken, tt.clientID, domain.SessionMeta{}); err != nil {
+					t.Errorf("rotation by the owning client = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-playground/validator/v10"
+)
+
+type AuthzHandler struct {
+	UC       *usecase.AuthzUseCase
+	Validate *validator.Validate
+}
+
+type AuthzCheckRequest struct {
+	Action   string            `json:"action" validate:"required" example:"documents:edit"`
+	Resource map[string]string `json:"resource,omitempty"`
+}
+
+type AuthzDecisionResponse struct {
+	Allowed bool `json:"allowed"`
+	// Policy that decided; absent when no policy matched (denied by default).
+	PolicyID string `json:"policy_id,omitempty"`
+}
+
+type AuthzSubjectRef struct {
+	Type string `json:"type" validate:"required,oneof=user service" example:"user"`
+	ID   string `json:"id" validate:"required"` // user ID, or client_id of a service
+}
+
+type AuthzBatchRequest struct {
+	// Access token of the subject. Without token and subject, the subject
+	// is the caller.
+	Token   string           `json:"token,omitempty" validate:"excluded_with=Subject"`
+	Subject *AuthzSubjectRef `json:"subject,omitempty"`
+	// IP the subject's request came from, for IP conditions. Only taken
+	// from services; others are judged on the IP of this request.
+	IP    string              `json:"ip,omitempty" validate:"omitempty,ip"`
+	Items []AuthzCheckRequest `json:"items" validate:"required,min=1,max=500,dive"`
+}
+
+type AuthzBatchResponse struct {
+	Decisions []AuthzDecisionResponse `json:"decisions"` // in the order of items
+}
+
+// @Summary      Check a policy decision
+// @Description  Evaluates the policies of the caller's organization for the caller of the access token: may it perform action on the resource described by its attributes? As in /authz/batch, its roles and scopes are re-resolved, so grants revoked since the token was issued no longer count, and a disabled or deactivated caller is denied. Conditions use the time of the request and the IP it comes from. A denial is still a 200 with allowed=false.
+// @Tags         authz
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body AuthzCheckRequest true "Action and resource attributes"
+// @Success      200 {object} AuthzDecisionResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Router       /authz/check [post]
+func (h *AuthzHandler) Check(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+	var req AuthzCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	d, err := h.UC.Evaluate(r.Context(), domain.AccessRequest{
+		Subject:  p,
+		Action:   req.Action,
+		Resource: req.Resource,
+		IP:       sessionMeta(r).IP,
+	})
+	if err != nil {
+		apierrors.InternalError(w, "Failed to evaluate policies")
+		return
+	}
+	writeJSON(w, http.StatusOK, AuthzDecisionResponse{Allowed: d.Allowed, PolicyID: d.PolicyID})
+}
+
+// @Summary      Check policy decisions in bulk
+// @Description  Evaluates up to 500 (action, resource) items for one subject at once, e.g. to filter a list. The subject is the bearer of token, the principal named by subject (services only), or the caller. Its roles and scopes are re-resolved, so grants revoked since its token was issued no longer count. A subject that can't be resolved (inactive token; unknown, disabled or deactivated principal; another organization than the caller's) gets every item denied.
+// @Tags         authz
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body AuthzBatchRequest true "Subject and items"
+// @Success      200 {object} AuthzBatchResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string "Subject references are for services only"
+// @Failure      422 {object} map[string]string
+// @Router       /authz/batch [post]
+func (h *AuthzHandler) CheckBatch(w http.ResponseWriter, r *http.Request) {
+	caller, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+	var req AuthzBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	var ref *usecase.SubjectRef
+	if req.Subject != nil {
+		ref = &usecase.SubjectRef{Type: domain.PrincipalType(req.Subject.Type), ID: req.Subject.ID}
+	}
+	subject, found, err := h.UC.Subject(caller, req.Token, ref)
+	if errors.Is(err, usecase.ErrSubjectRefForbidden) {
+		apierrors.Forbidden(w, "Only services can name subjects by reference")
+		return
+	}
+	if err != nil {
+		apierrors.InternalError(w, "Failed to resolve subject")
+		return
+	}
+
+	resp := AuthzBatchResponse{Decisions: make([]AuthzDecisionResponse, len(req.Items))}
+	if found {
+		ip := sessionMeta(r).IP
+		if req.IP != "" && caller.Type == domain.PrincipalService {
+			ip = req.IP
+		}
+		items := make([]usecase.AuthzItem, len(req.Items))
+		for i, it := range req.Items {
+			items[i] = usecase.AuthzItem{Action: it.Action, Resource: it.Resource}
+		}
+		decisions, err := h.UC.Decide(r.Context(), subject, ip, items)
+		if err != nil {
+			apierrors.InternalError(w, "Failed to evaluate policies")
+			return
+		}
+		for i, d := range decisions {
+			resp.Decisions[i] = AuthzDecisionResponse{Allowed: d.Allowed, PolicyID: d.PolicyID}
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -2,83 +2,158 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
 	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
-	"github.com/go-playground/validator/v10"
 )
 
-type ClientTokenRequest struct {
-	ClientID string   `json:"client_id" validate:"required"`
-	Secret   string   `json:"client_secret" validate:"required"`
-	Scopes   []string `json:"scopes"`
-	Audience []string `json:"audience"`
-}
-
-type ClientTokenResponse struct {
-	AccessToken string    `json:"access_token"`
-	AccessExp   time.Time `json:"access_exp"`
-}
-
 type ClientTokenHandler struct {
-	Validate             *validator.Validate
 	UC                   *usecase.ClientCredentialsUseCase
 	TokenService         domain.TokenService
 	PermissionRepository domain.PermissionRepository
+
+	// Audit, when set, records every client_credentials grant.
+	Audit *usecase.Auditor
+}
+
+// clientCredentials extracts the client authentication of a token request
+// (RFC 6749 section 2.3.1): HTTP Basic (client_secret_basic) or client_id and
+// client_secret in the form body (client_secret_post). Using both is rejected.
+func clientCredentials(r *http.Request) (clientID, secret string, err error) {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret"), nil
+	}
+	if r.PostForm.Get("client_secret") != "" {
+		return "", "", domain.NewOAuthError(domain.OAuthInvalidRequest, "use only one client authentication method")
+	}
+
+	// Basic credentials are form-encoded before being base64-encoded.
+	clientID, err1 := url.QueryUnescape(user)
+	secret, err2 := url.QueryUnescape(pass)
+	if err1 != nil || err2 != nil {
+		return "", "", domain.NewOAuthError(domain.OAuthInvalidClient, "malformed basic credentials")
+	}
+	if formID := r.PostForm.Get("client_id"); formID != "" && formID != clientID {
+		return "", "", domain.NewOAuthError(domain.OAuthInvalidRequest, "client_id does not match the authenticated client")
+	}
+	return clientID, secret, nil
+}
+
+// auditGrant records a client_credentials grant; a failed one has no
+// principal yet, only the client_id it claimed.
+func (h *ClientTokenHandler) auditGrant(r *http.Request, clientID string, p domain.Principal, err error) {
+	actor := domain.Actor{Type: p.Type, ID: p.ID, IP: sessionMeta(r).IP}
+	details := map[string]string{"client_id": clientID}
+	outcome := domain.AuditSuccess
+	if err != nil {
+		outcome = domain.AuditFailure
+		var oerr *domain.OAuthError
+		if errors.As(err, &oerr) {
+			details["reason"] = oerr.Code
+		}
+	} else {
+		details["scope"] = strings.Join(p.Scopes, " ")
+	}
+	h.Audit.Record(r.Context(), actor, domain.AuditClientToken, outcome, "client", p.ID, details)
+}
+
+// spaceDelimited splits a space-delimited parameter such as scope.
+func spaceDelimited(v string) []string {
+	return strings.Fields(v)
+}
+
+func writeTokenResponse(w http.ResponseWriter, resp OAuthTokenResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func expiresIn(exp time.Time) int64 {
+	return int64(time.Until(exp).Round(time.Second).Seconds())
 }
 
 // @Summary      Client Token
-// @Description  Issue access token for client credentials grant
+// @Description  OAuth 2.0 token endpoint for the client_credentials grant (RFC 6749 section 4.4). Clients authenticate with HTTP Basic (client_secret_basic) or client_id/client_secret in the body (client_secret_post). The token carries the client's scopes, narrowed by scope when given, and the requested audience (all allowed audiences by default). Also served by /oauth/token.
 // @Tags         auth
-// @Accept       json
+// @Accept       x-www-form-urlencoded
 // @Produce      json
-// @Param        request body ClientTokenRequest true "Client credentials payload"
-// @Success      200 {object} ClientTokenResponse
-// @Failure      400 {string} string "Invalid payload"
-// @Failure      422 {string} string "Validation failed"
-// @Failure      401 {string} string "Invalid client credentials"
-// @Failure      500 {string} string "Internal server error"
+// @Param        grant_type formData string true "Must be client_credentials"
+// @Param        client_id formData string false "Client ID (when not using HTTP Basic)"
+// @Param        client_secret formData string false "Client secret (when not using HTTP Basic)"
+// @Param        scope formData string false "Space-delimited subset of the client's scopes"
+// @Param        audience formData string false "Space-delimited or repeated audiences (defaults to the client's allowed audiences)"
+// @Success      200 {object} OAuthTokenResponse
+// @Failure      400 {object} errors.OAuthErrorResponse
+// @Failure      401 {object} errors.OAuthErrorResponse
+// @Failure      500 {object} errors.OAuthErrorResponse
+// @Failure      429 {object} map[string]string "Rate limited per client; see Retry-After"
 // @Router       /auth/token [post]
 func (h *ClientTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	var req ClientTokenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		apierrors.BadRequest(w, "Invalid JSON payload")
+	if err := r.ParseForm(); err != nil {
+		apierrors.OAuthError(w, http.StatusBadRequest, domain.OAuthInvalidRequest, "malformed form body")
 		return
 	}
-	if err := h.Validate.Struct(req); err != nil {
-		apierrors.ValidationError(w, "Validation failed", err.Error())
-		return
+
+	switch grantType := r.PostForm.Get("grant_type"); grantType {
+	case "client_credentials":
+		resp, err := h.grant(r)
+		if err != nil {
+			writeOAuthError(w, err)
+			return
+		}
+		writeTokenResponse(w, resp)
+	case "":
+		apierrors.OAuthError(w, http.StatusBadRequest, domain.OAuthInvalidRequest, "grant_type is required")
+	default:
+		apierrors.OAuthError(w, http.StatusBadRequest, domain.OAuthUnsupportedGrantType, "unsupported grant_type "+grantType)
 	}
+}
 
-	scopes, err := h.PermissionRepository.ListClientScopes(req.ClientID)
+// grant runs the client_credentials grant on a parsed token request.
+func (h *ClientTokenHandler) grant(r *http.Request) (OAuthTokenResponse, error) {
+	clientID, secret, err := clientCredentials(r)
 	if err != nil {
-		apierrors.Unauthorized(w, "Invalid client credentials")
-		return
+		return OAuthTokenResponse{}, err
+	}
+	if clientID == "" {
+		return OAuthTokenResponse{}, domain.NewOAuthError(domain.OAuthInvalidClient, "client authentication is required")
+	}
+
+	scopes, err := h.PermissionRepository.ListClientScopes(clientID)
+	if err != nil {
+		return OAuthTokenResponse{}, domain.NewOAuthError(domain.OAuthInvalidClient, "client authentication failed")
 	}
 
 	principal, err := h.UC.Execute(usecase.ClientCredentialsInput{
-		ClientID: req.ClientID,
-		Secret:   req.Secret,
-		Scopes:   scopes,
-		Audience: req.Audience,
+		ClientID:        clientID,
+		Secret:          secret,
+		Scopes:          scopes,
+		Audience:        spaceDelimited(strings.Join(r.PostForm["audience"], " ")),
+		RequestedScopes: spaceDelimited(r.PostForm.Get("scope")),
 	})
 	if err != nil {
-		apierrors.Unauthorized(w, "Invalid client credentials")
-		return
+		h.auditGrant(r, clientID, domain.Principal{}, err)
+		return OAuthTokenResponse{}, err
 	}
 
 	token, exp, err := h.TokenService.IssueAccessOnly(principal)
 	if err != nil {
-		apierrors.InternalError(w, "Failed to issue access token")
-		return
+		return OAuthTokenResponse{}, err
 	}
+	h.auditGrant(r, clientID, principal, nil)
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(ClientTokenResponse{
+	return OAuthTokenResponse{
 		AccessToken: token,
-		AccessExp:   exp,
-	})
+		TokenType:   "Bearer",
+		ExpiresIn:   expiresIn(exp),
+		Scope:       strings.Join(principal.Scopes, " "),
+	}, nil
 }
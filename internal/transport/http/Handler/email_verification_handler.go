@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-playground/validator/v10"
+)
+
+type EmailVerificationHandler struct {
+	UC       *usecase.EmailVerificationUseCase
+	Validate *validator.Validate
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email" example:"user@example.com"`
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Marks the account as verified using the token from the verification email. Tokens expire after EMAIL_VERIFICATION_TTL and only count for the address they were sent to.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body VerifyEmailRequest true "Verification token"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /auth/verify-email [post]
+func (h *EmailVerificationHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	user, err := h.UC.Verify(r.Context(), req.Token)
+	if errors.Is(err, domain.ErrInvalidActionToken) {
+		apierrors.BadRequest(w, "Invalid or expired verification token")
+		return
+	}
+	if err != nil {
+		apierrors.InternalError(w, "Failed to verify email")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(UserResponse{ID: user.ID, Email: user.Email})
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Sends a new verification email if the address belongs to an unverified account. Always answers 202 so addresses can't be probed.
+// @Tags auth
+// @Accept json
+// @Param input body ResendVerificationRequest true "Account email"
+// @Success 202 {string} string "Accepted"
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /auth/verify-email/resend [post]
+func (h *EmailVerificationHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	h.UC.Resend(r.Context(), req.Email)
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+)
+
+type IntrospectionHandler struct {
+	ClientAuth   *usecase.ClientAuthUseCase
+	TokenService domain.TokenService
+}
+
+// IntrospectResponse is the RFC 7662 (section 2.2) introspection response.
+// Inactive tokens only report active=false.
+type IntrospectResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty" example:"read:users write:users"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	TokenType string   `json:"token_type,omitempty" example:"Bearer"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Aud       []string `json:"aud,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+
+	// Extensions.
+	SubjectType string   `json:"subject_type,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	SessionID   string   `json:"sid,omitempty"`
+	TenantID    string   `json:"tenant_id,omitempty"`
+}
+
+// introspect tries the hinted token type first, then the other one (RFC 7662
+// section 2.1: the hint is only an optimization).
+func (h *IntrospectionHandler) introspect(token, hint string) (bool, *domain.TokenClaims, string, error) {
+	order := []string{domain.TokenTypeHintAccess, domain.TokenTypeHintRefresh}
+	if hint == domain.TokenTypeHintRefresh {
+		order[0], order[1] = order[1], order[0]
+	}
+
+	for _, typ := range order {
+		lookup := h.TokenService.Introspect
+		if typ == domain.TokenTypeHintRefresh {
+			lookup = h.TokenService.IntrospectRefresh
+		}
+		active, claims, err := lookup(token)
+		if err != nil || active {
+			return active, claims, typ, err
+		}
+	}
+	return false, nil, "", nil
+}
+
+// IntrospectHandler godoc
+// @Summary Introspect a token
+// @Description RFC 7662 token introspection for resource servers. The caller must authenticate as a confidential client (HTTP Basic or client_id/client_secret in the body). Access and refresh tokens are both understood; token_type_hint only decides which is tried first.
+// @Tags auth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "Client ID (when not using HTTP Basic)"
+// @Param client_secret formData string false "Client secret (when not using HTTP Basic)"
+// @Success 200 {object} IntrospectResponse
+// @Failure 400 {object} errors.OAuthErrorResponse
+// @Failure 401 {object} errors.OAuthErrorResponse
+// @Failure 500 {object} errors.OAuthErrorResponse
+// @Failure 429 {object} map[string]string "Rate limited per client; see Retry-After"
+// @Router /auth/introspect [post]
+func (h *IntrospectionHandler) IntrospectHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		apierrors.OAuthError(w, http.StatusBadRequest, domain.OAuthInvalidRequest, "malformed form body")
+		return
+	}
+
+	clientID, secret, err := clientCredentials(r)
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+	client, err := h.ClientAuth.Authenticate(clientID, secret)
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+	if client.Public {
+		writeOAuthError(w, domain.NewOAuthError(domain.OAuthUnauthorizedClient, "public clients cannot introspect tokens"))
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		apierrors.OAuthError(w, http.StatusBadRequest, domain.OAuthInvalidRequest, "token is required")
+		return
+	}
+
+	active, claims, typ, err := h.introspect(token, r.PostForm.Get("token_type_hint"))
+	if err != nil {
+		// Operational error on our side (e.g., Redis down)
+		apierrors.OAuthError(w, http.StatusInternalServerError, domain.OAuthServerError, "token introspection failed")
+		return
+	}
+
+	// Clients of an organization only learn about tokens of their tenant.
+	if active && claims != nil && client.TenantID != domain.PlatformTenant && claims.TenantID != client.TenantID {
+		active = false
+	}
+
+	resp := IntrospectResponse{Active: active}
+	if active && claims != nil {
+		resp.Scope = strings.Join(claims.Scopes, " ")
+		resp.ClientID = claims.ClientID
+		resp.Username = claims.Email
+		resp.TokenType = "Bearer"
+		if typ == domain.TokenTypeHintRefresh {
+			resp.TokenType = domain.TokenTypeHintRefresh
+		}
+		resp.Exp = claims.ExpiresAt.Unix()
+		resp.Iat = claims.IssuedAt.Unix()
+		resp.Sub = claims.SubjectID
+		resp.Aud = claims.Audience
+		resp.Iss = claims.Issuer
+		resp.Jti = claims.ID
+		resp.SubjectType = string(claims.SubjectType)
+		resp.Email = claims.Email
+		resp.Roles = claims.Roles
+		resp.SessionID = claims.FamilyID
+		resp.TenantID = claims.TenantID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
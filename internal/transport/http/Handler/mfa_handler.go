@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-playground/validator/v10"
+)
+
+type MFAHandler struct {
+	UC       *usecase.MFAUseCase
+	Validate *validator.Validate
+}
+
+type TOTPEnrollResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/auth-service:user@example.com?secret=JBSWY3DPEHPK3PXP"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required" example:"123456"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Generates a new TOTP secret for the authenticated user, as a base32 secret and an otpauth:// URI for authenticator apps. MFA stays off until a code is confirmed at /auth/mfa/totp/confirm; enrolling again replaces a pending secret.
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} TOTPEnrollResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Not a user token"
+// @Failure 409 {object} map[string]string "MFA already enabled"
+// @Router /auth/mfa/totp/enroll [post]
+func (h *MFAHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if p.Type != domain.PrincipalUser {
+		apierrors.Forbidden(w, "Only users can manage multi-factor authentication")
+		return
+	}
+
+	enrollment, err := h.UC.Enroll(p.ID)
+	if errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+		apierrors.Conflict(w, "Multi-factor authentication is already enabled")
+		return
+	}
+	if err != nil {
+		apierrors.InternalError(w, "Failed to start TOTP enrollment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(TOTPEnrollResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.URI,
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enables MFA with a code from the authenticator app and returns one-time recovery codes. They are stored hashed and shown only this once.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body MFACodeRequest true "TOTP code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Not a user token"
+// @Failure 409 {object} map[string]string "No pending enrollment or MFA already enabled"
+// @Failure 422 {object} map[string]string
+// @Router /auth/mfa/totp/confirm [post]
+func (h *MFAHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if p.Type != domain.PrincipalUser {
+		apierrors.Forbidden(w, "Only users can manage multi-factor authentication")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	codes, err := h.UC.Confirm(p.ID, req.Code)
+	switch {
+	case errors.Is(err, domain.ErrInvalidMFACode):
+		apierrors.ValidationError(w, "Invalid verification code", err.Error())
+		return
+	case errors.Is(err, domain.ErrMFANotEnrolled):
+		apierrors.Conflict(w, "Start an enrollment at /auth/mfa/totp/enroll first")
+		return
+	case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+		apierrors.Conflict(w, "Multi-factor authentication is already enabled")
+		return
+	case err != nil:
+		apierrors.InternalError(w, "Failed to confirm TOTP enrollment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP godoc
+// @Summary Disable MFA
+// @Description Turns MFA off and deletes the recovery codes. Requires a current TOTP code or an unused recovery code. Wrong codes count towards the same lockout as those of /auth/mfa/verify.
+// @Tags mfa
+// @Accept json
+// @Security BearerAuth
+// @Param input body MFACodeRequest true "TOTP or recovery code"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Not a user token"
+// @Failure 409 {object} map[string]string "MFA not enabled"
+// @Failure 422 {object} map[string]string
+// @Failure 423 {object} map[string]string "Codes locked after too many failures; see Retry-After"
+// @Router /auth/mfa/totp/disable [post]
+func (h *MFAHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if p.Type != domain.PrincipalUser {
+		apierrors.Forbidden(w, "Only users can manage multi-factor authentication")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	err := h.UC.Disable(r.Context(), p.ID, req.Code)
+	if writeLockout(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrInvalidMFACode):
+		apierrors.ValidationError(w, "Invalid verification code", err.Error())
+		return
+	case errors.Is(err, domain.ErrMFANotEnabled):
+		apierrors.Conflict(w, "Multi-factor authentication is not enabled")
+		return
+	case err != nil:
+		apierrors.InternalError(w, "Failed to disable multi-factor authentication")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
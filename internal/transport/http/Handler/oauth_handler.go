@@ -0,0 +1,506 @@
+package handler
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+)
+
+type OAuthHandler struct {
+	Login        *usecase.LoginUseCase
+	AuthCode     *usecase.AuthorizationCodeUseCase
+	TokenService domain.TokenService
+
+	// MFA, when set, asks enrolled users for a code after their password.
+	MFA *usecase.MFAUseCase
+
+	// ClientCredentials serves the client_credentials grant on /oauth/token.
+	ClientCredentials *ClientTokenHandler
+
+	Audit *usecase.Auditor
+}
+
+// OAuthTokenResponse is the RFC 6749 (section 5.1) token response.
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty" example:"read:users write:users"`
+	IDToken      string `json:"id_token,omitempty"` // OIDC, when the openid scope was granted
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Sign in</title>
+<style>
+body{font-family:system-ui,sans-serif;background:#f4f5f7;display:flex;justify-content:center;padding-top:10vh}
+form{background:#fff;padding:2rem;border-radius:8px;box-shadow:0 1px 4px rgba(0,0,0,.1);width:320px}
+input{display:block;width:100%;box-sizing:border-box;margin:.5rem 0 1rem;padding:.5rem}
+button{width:100%;padding:.6rem}
+.error{color:#b00020}
+</style>
+</head>
+<body>
+{{if .Fatal}}
+<form><h1>Authorization error</h1><p class="error">{{.Fatal}}</p></form>
+{{else}}
+<form method="post" action="/oauth/authorize">
+<h1>Sign in</h1>
+<p>to continue to <strong>{{.ClientName}}</strong></p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{if .MFAToken}}
+<label>Authentication code<input type="text" name="code" inputmode="numeric" autocomplete="one-time-code" required autofocus></label>
+<p>Lost your device? Enter a recovery code instead.</p>
+<input type="hidden" name="mfa_token" value="{{.MFAToken}}">
+{{else}}
+<label>Email<input type="email" name="email" value="{{.Email}}" required autofocus></label>
+<label>Password<input type="password" name="password" required></label>
+{{end}}
+<input type="hidden" name="response_type" value="{{.In.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.In.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.In.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.In.Scope}}">
+<input type="hidden" name="state" value="{{.In.State}}">
+<input type="hidden" name="code_challenge" value="{{.In.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.In.CodeChallengeMethod}}">
+<input type="hidden" name="nonce" value="{{.In.Nonce}}">
+<button type="submit">Sign in</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type loginPageData struct {
+	In         usecase.AuthorizeInput
+	ClientName string
+	Email      string
+	Error      string
+	Fatal      string
+
+	// MFAToken switches the page to the second sign-in step.
+	MFAToken string
+}
+
+func renderLoginPage(w http.ResponseWriter, status int, data loginPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	// The page collects credentials: never let another site frame it.
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	w.WriteHeader(status)
+	_ = loginPage.Execute(w, data)
+}
+
+// renderLockout shows the sign-in page of a throttled login (see
+// writeLockout). It reports whether err was one.
+func renderLockout(w http.ResponseWriter, err error, data loginPageData) bool {
+	var le *domain.LockoutError
+	if !errors.As(err, &le) {
+		return false
+	}
+	status := http.StatusTooManyRequests
+	if errors.Is(err, domain.ErrAccountLocked) {
+		status = http.StatusLocked
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(le.RetryAfter)))
+	data.Error = "Too many failed sign-in attempts, try again later"
+	renderLoginPage(w, status, data)
+	return true
+}
+
+// renderSignInRefused shows the login page again for an account that proved
+// its identity but may not sign in.
+func renderSignInRefused(w http.ResponseWriter, err error, data loginPageData) bool {
+	switch {
+	case errors.Is(err, domain.ErrEmailNotVerified):
+		data.Error = "Verify your email address before signing in"
+	case errors.Is(err, domain.ErrUserDisabled):
+		data.Error = "This account is disabled"
+	case errors.Is(err, domain.ErrPasswordResetRequired):
+		data.Error = "You must reset your password before signing in"
+	case errors.Is(err, domain.ErrCrossTenant):
+		data.Error = "This account cannot sign in to this application"
+	default:
+		return false
+	}
+	renderLoginPage(w, http.StatusForbidden, data)
+	return true
+}
+
+func authorizeInput(v url.Values) usecase.AuthorizeInput {
+	return usecase.AuthorizeInput{
+		ResponseType:        v.Get("response_type"),
+		ClientID:            v.Get("client_id"),
+		RedirectURI:         v.Get("redirect_uri"),
+		Scope:               v.Get("scope"),
+		State:               v.Get("state"),
+		CodeChallenge:       v.Get("code_challenge"),
+		CodeChallengeMethod: v.Get("code_challenge_method"),
+		Nonce:               v.Get("nonce"),
+	}
+}
+
+// redirectWith sends the user agent back to the client with params added to its redirect URI.
+func redirectWith(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		renderLoginPage(w, http.StatusBadRequest, loginPageData{Fatal: "Invalid redirect_uri"})
+		return
+	}
+	q := u.Query()
+	for k, vs := range params {
+		for _, v := range vs {
+			if v != "" {
+				q.Set(k, v)
+			}
+		}
+	}
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, redirectURI, state string, err error) {
+	var oe *domain.OAuthError
+	if !errors.As(err, &oe) {
+		oe = domain.NewOAuthError(domain.OAuthServerError, "internal error")
+	}
+	redirectWith(w, r, redirectURI, url.Values{
+		"error":             {oe.Code},
+		"error_description": {oe.Description},
+		"state":             {state},
+	})
+}
+
+// writeOAuthError maps a use case error to an RFC 6749 token endpoint error.
+func writeOAuthError(w http.ResponseWriter, err error) {
+	var oe *domain.OAuthError
+	if !errors.As(err, &oe) {
+		apierrors.OAuthError(w, http.StatusInternalServerError, domain.OAuthServerError, "internal error")
+		return
+	}
+	status := http.StatusBadRequest
+	if oe.Code == domain.OAuthInvalidClient {
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	apierrors.OAuthError(w, status, oe.Code, oe.Description)
+}
+
+// Authorize godoc
+// @Summary Authorization endpoint (authorization code + PKCE)
+// @Description Starts the OAuth 2.0 authorization code flow. Renders a sign-in page; after a successful sign-in the browser is redirected to redirect_uri with a single-use code and the state. PKCE with S256 is mandatory.
+// @Tags oauth
+// @Produce html
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string false "Registered redirect URI (optional when the client has exactly one)"
+// @Param scope query string false "Space-delimited scopes (defaults to the client's allowed scopes)"
+// @Param state query string false "Opaque value echoed back to the client"
+// @Param code_challenge query string true "BASE64URL(SHA256(code_verifier))"
+// @Param code_challenge_method query string true "Must be S256"
+// @Param nonce query string false "OIDC nonce, echoed in the ID token"
+// @Success 200 {string} string "Sign-in page"
+// @Failure 302 {string} string "Redirect to the client with an error"
+// @Failure 400 {string} string "Unknown client or redirect_uri"
+// @Router /oauth/authorize [get]
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	in := authorizeInput(r.URL.Query())
+
+	client, redirectURI, err := h.AuthCode.ResolveClient(in.ClientID, in.RedirectURI)
+	if err != nil {
+		renderLoginPage(w, http.StatusBadRequest, loginPageData{Fatal: err.(*domain.OAuthError).Description})
+		return
+	}
+	if _, err := h.AuthCode.ValidateRequest(client, in); err != nil {
+		redirectError(w, r, redirectURI, in.State, err)
+		return
+	}
+
+	renderLoginPage(w, http.StatusOK, loginPageData{In: in, ClientName: client.Name})
+}
+
+// AuthorizeSubmit godoc
+// @Summary Sign in and authorize (authorization code + PKCE)
+// @Description Submitted by the sign-in page. Users with MFA enabled get a second page asking for a TOTP or recovery code, posted back with mfa_token. On success redirects to redirect_uri with code and state; the code expires after AUTH_CODE_TTL and can be exchanged once at /oauth/token.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param email formData string false "User email (first step)"
+// @Param password formData string false "User password (first step)"
+// @Param mfa_token formData string false "MFA challenge from the first step"
+// @Param code formData string false "TOTP or recovery code (MFA step)"
+// @Param response_type formData string true "Must be code"
+// @Param client_id formData string true "Client ID"
+// @Param redirect_uri formData string false "Registered redirect URI"
+// @Param scope formData string false "Space-delimited scopes"
+// @Param state formData string false "Opaque value echoed back to the client"
+// @Param code_challenge formData string true "BASE64URL(SHA256(code_verifier))"
+// @Param code_challenge_method formData string true "Must be S256"
+// @Param nonce formData string false "OIDC nonce"
+// @Success 302 {string} string "Redirect to the client with the code"
+// @Failure 400 {string} string "Unknown client or redirect_uri"
+// @Failure 401 {string} string "Sign-in page with an error"
+// @Failure 403 {string} string "Sign-in page: the account may not sign in (disabled, unverified, or of another organization than the client)"
+// @Failure 423 {string} string "Account locked after too many failures; see Retry-After"
+// @Failure 429 {string} string "Too many failures from this IP; see Retry-After"
+// @Router /oauth/authorize [post]
+func (h *OAuthHandler) AuthorizeSubmit(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderLoginPage(w, http.StatusBadRequest, loginPageData{Fatal: "Invalid form"})
+		return
+	}
+	in := authorizeInput(r.PostForm)
+
+	client, redirectURI, err := h.AuthCode.ResolveClient(in.ClientID, in.RedirectURI)
+	if err != nil {
+		renderLoginPage(w, http.StatusBadRequest, loginPageData{Fatal: err.(*domain.OAuthError).Description})
+		return
+	}
+	scopes, err := h.AuthCode.ValidateRequest(client, in)
+	if err != nil {
+		redirectError(w, r, redirectURI, in.State, err)
+		return
+	}
+
+	var user *domain.User
+	if challenge := r.PostForm.Get("mfa_token"); challenge != "" && h.MFA != nil {
+		user, err = h.MFA.Verify(r.Context(), challenge, r.PostForm.Get("code"))
+		if renderLockout(w, err, loginPageData{In: in, ClientName: client.Name}) ||
+			renderSignInRefused(w, err, loginPageData{In: in, ClientName: client.Name}) {
+			return
+		}
+		switch {
+		case errors.Is(err, domain.ErrInvalidMFACode):
+			renderLoginPage(w, http.StatusUnauthorized, loginPageData{
+				In:         in,
+				ClientName: client.Name,
+				MFAToken:   challenge,
+				Error:      "Invalid verification code",
+			})
+			return
+		case errors.Is(err, domain.ErrMFAChallengeNotFound):
+			renderLoginPage(w, http.StatusUnauthorized, loginPageData{
+				In:         in,
+				ClientName: client.Name,
+				Error:      "Sign-in expired, please sign in again",
+			})
+			return
+		case err != nil:
+			redirectError(w, r, redirectURI, in.State, err)
+			return
+		}
+	} else {
+		email := r.PostForm.Get("email")
+		user, err = h.Login.Execute(r.Context(), email, r.PostForm.Get("password"), sessionMeta(r).IP)
+		if renderLockout(w, err, loginPageData{In: in, ClientName: client.Name, Email: email}) {
+			return
+		}
+		if renderSignInRefused(w, err, loginPageData{In: in, ClientName: client.Name, Email: email}) {
+			return
+		}
+		if err != nil || user.ID == "" {
+			renderLoginPage(w, http.StatusUnauthorized, loginPageData{
+				In:         in,
+				ClientName: client.Name,
+				Email:      email,
+				Error:      "Invalid email or password",
+			})
+			return
+		}
+
+		if h.MFA != nil {
+			enabled, err := h.MFA.Enabled(user.ID)
+			if err != nil {
+				redirectError(w, r, redirectURI, in.State, err)
+				return
+			}
+			if enabled {
+				challenge, err := h.MFA.Challenge(r.Context(), user.ID)
+				if err != nil {
+					redirectError(w, r, redirectURI, in.State, err)
+					return
+				}
+				renderLoginPage(w, http.StatusOK, loginPageData{In: in, ClientName: client.Name, MFAToken: challenge})
+				return
+			}
+		}
+	}
+
+	code, err := h.AuthCode.Issue(r.Context(), client, scopes, in, user)
+	if renderSignInRefused(w, err, loginPageData{In: in, ClientName: client.Name}) {
+		return
+	}
+	if err != nil {
+		redirectError(w, r, redirectURI, in.State, err)
+		return
+	}
+	redirectWith(w, r, redirectURI, url.Values{"code": {code}, "state": {in.State}})
+}
+
+// Token godoc
+// @Summary Token endpoint
+// @Description Exchanges a grant for tokens, dispatching on grant_type: authorization_code (with the PKCE code_verifier), refresh_token or client_credentials. Confidential clients authenticate with HTTP Basic (client_secret_basic) or client_id/client_secret in the body (client_secret_post); public clients send client_id only. Refresh tokens issued to a client can only be redeemed by that client. An OIDC id_token is included when the openid scope was granted. Errors use the RFC 6749 format.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token or client_credentials"
+// @Param client_id formData string false "Client ID (when not using HTTP Basic)"
+// @Param client_secret formData string false "Client secret (confidential clients not using HTTP Basic)"
+// @Param code formData string false "Authorization code (authorization_code)"
+// @Param redirect_uri formData string false "Redirect URI used at /oauth/authorize (authorization_code)"
+// @Param code_verifier formData string false "PKCE code verifier (authorization_code)"
+// @Param refresh_token formData string false "Refresh token (refresh_token)"
+// @Param scope formData string false "Space-delimited subset of the client's scopes (client_credentials)"
+// @Param audience formData string false "Space-delimited or repeated audiences (client_credentials)"
+// @Success 200 {object} OAuthTokenResponse
+// @Failure 400 {object} errors.OAuthErrorResponse
+// @Failure 401 {object} errors.OAuthErrorResponse
+// @Router /oauth/token [post]
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		apierrors.OAuthError(w, http.StatusBadRequest, domain.OAuthInvalidRequest, "malformed form body")
+		return
+	}
+
+	var (
+		pair   domain.TokenPair
+		nonce  string
+		action string
+		err    error
+	)
+	switch grantType := r.PostForm.Get("grant_type"); grantType {
+	case "authorization_code":
+		action = domain.AuditAuthCode
+		pair, nonce, err = h.authorizationCodeGrant(r)
+	case "refresh_token":
+		action = domain.AuditOAuthRefresh
+		pair, err = h.refreshTokenGrant(r)
+	case "client_credentials":
+		resp, err := h.ClientCredentials.grant(r)
+		if err != nil {
+			writeOAuthError(w, err)
+			return
+		}
+		writeTokenResponse(w, resp)
+		return
+	case "":
+		err = domain.NewOAuthError(domain.OAuthInvalidRequest, "grant_type is required")
+	default:
+		err = domain.NewOAuthError(domain.OAuthUnsupportedGrantType, "unsupported grant_type "+grantType)
+	}
+	if action != "" {
+		h.auditGrant(r, action, pair, err)
+	}
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	idToken, err := h.TokenService.IssueIDToken(pair.AccessToken, nonce)
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	writeTokenResponse(w, OAuthTokenResponse{
+		AccessToken:  pair.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    expiresIn(pair.AccessExp),
+		RefreshToken: pair.RefreshToken,
+		Scope:        strings.Join(pair.Scopes, " "),
+		IDToken:      idToken,
+	})
+}
+
+func (h *OAuthHandler) authorizationCodeGrant(r *http.Request) (domain.TokenPair, string, error) {
+	clientID, secret, err := clientCredentials(r)
+	if err != nil {
+		return domain.TokenPair{}, "", err
+	}
+	f := r.PostForm
+	if f.Get("code") == "" || clientID == "" {
+		return domain.TokenPair{}, "", domain.NewOAuthError(domain.OAuthInvalidRequest, "code and client_id are required")
+	}
+
+	principal, nonce, err := h.AuthCode.Exchange(r.Context(), usecase.ExchangeInput{
+		ClientID:     clientID,
+		ClientSecret: secret,
+		Code:         f.Get("code"),
+		RedirectURI:  f.Get("redirect_uri"),
+		CodeVerifier: f.Get("code_verifier"),
+	})
+	if err != nil {
+		return domain.TokenPair{}, "", err
+	}
+	pair, err := h.TokenService.IssuePair(principal, sessionMeta(r))
+	return pair, nonce, err
+}
+
+// auditGrant records an authorization_code or refresh_token grant. The subject
+// and session are read from the access token issued; a failed grant has only
+// the client_id it claimed.
+func (h *OAuthHandler) auditGrant(r *http.Request, action string, pair domain.TokenPair, err error) {
+	actor := auditActor(r)
+	details := map[string]string{}
+	if clientID, _, cerr := clientCredentials(r); cerr == nil && clientID != "" {
+		details["client_id"] = clientID
+	}
+	if err != nil {
+		var oerr *domain.OAuthError
+		if errors.As(err, &oerr) {
+			details["reason"] = oerr.Code
+		}
+		h.Audit.Record(r.Context(), actor, action, domain.AuditFailure, "", "", details)
+		return
+	}
+	var sessionID string
+	if claims, err := h.TokenService.VerifyAccess(pair.AccessToken); err == nil {
+		actor.Type, actor.ID, sessionID = claims.SubjectType, claims.SubjectID, claims.FamilyID
+	}
+	details["scope"] = strings.Join(pair.Scopes, " ")
+	h.Audit.Record(r.Context(), actor, action, domain.AuditSuccess, "session", sessionID, details)
+}
+
+// refreshTokenGrant rotates a refresh token. Tokens issued through the code
+// flow are bound to their client, which must authenticate; first-party tokens
+// (from /auth/login) are redeemed without client authentication.
+func (h *OAuthHandler) refreshTokenGrant(r *http.Request) (domain.TokenPair, error) {
+	refreshToken := r.PostForm.Get("refresh_token")
+	if refreshToken == "" {
+		return domain.TokenPair{}, domain.NewOAuthError(domain.OAuthInvalidRequest, "refresh_token is required")
+	}
+
+	clientID, secret, err := clientCredentials(r)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	if clientID != "" {
+		if _, err := h.AuthCode.AuthenticateClient(clientID, secret); err != nil {
+			return domain.TokenPair{}, err
+		}
+	}
+
+	pair, err := h.TokenService.RotateForClient(refreshToken, clientID, sessionMeta(r))
+	if errors.Is(err, domain.ErrRefreshReused) {
+		return domain.TokenPair{}, domain.NewOAuthError(domain.OAuthInvalidGrant, "refresh token already used; the session has been revoked")
+	}
+	if errors.Is(err, domain.ErrTokenClientMismatch) {
+		return domain.TokenPair{}, domain.NewOAuthError(domain.OAuthInvalidGrant, "refresh token was issued to another client")
+	}
+	if err != nil {
+		return domain.TokenPair{}, domain.NewOAuthError(domain.OAuthInvalidGrant, "invalid or expired refresh token")
+	}
+	return pair, nil
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-playground/validator/v10"
+)
+
+type PasswordHandler struct {
+	Reset    *usecase.PasswordResetUseCase
+	Change   *usecase.PasswordChangeUseCase
+	Validate *validator.Validate
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email" example:"user@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required" example:"n3w-passw0rd"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword     string `json:"current_password" validate:"required" example:"123456"`
+	NewPassword         string `json:"new_password" validate:"required" example:"n3w-passw0rd"`
+	RevokeOtherSessions bool   `json:"revoke_other_sessions"`
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Emails a single-use reset token to the account, if there is one. Always answers 202 so addresses can't be probed. Requesting again invalidates the previous token.
+// @Tags auth
+// @Accept json
+// @Param input body ForgotPasswordRequest true "Account email"
+// @Success 202 {string} string "Accepted"
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /auth/password/forgot [post]
+func (h *PasswordHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	h.Reset.Forgot(r.Context(), req.Email)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Summary Reset the password
+// @Description Sets a new password with the token from the reset email. The token is single-use and expires after PASSWORD_RESET_TTL. Every session of the user is revoked.
+// @Tags auth
+// @Accept json
+// @Param input body ResetPasswordRequest true "Reset token and new password"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /auth/password/reset [post]
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	err := h.Reset.Reset(r.Context(), req.Token, req.NewPassword)
+	if errors.Is(err, domain.ErrPasswordResetNotFound) {
+		apierrors.BadRequest(w, "Invalid or expired reset token")
+		return
+	}
+	if errors.Is(err, usecase.ErrWeakPassword) {
+		apierrors.ValidationError(w, "Password does not meet the policy", err.Error())
+		return
+	}
+	if err != nil {
+		apierrors.InternalError(w, "Failed to reset password")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePassword godoc
+// @Summary Change my password
+// @Description Changes the password of the authenticated user after checking the current one. The new password must follow the password policy. With revoke_other_sessions every other session is ended; the calling one stays signed in.
+// @Tags auth
+// @Accept json
+// @Security BearerAuth
+// @Param input body ChangePasswordRequest true "Current and new password"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Wrong current password"
+// @Failure 422 {object} map[string]string
+// @Failure 423 {object} map[string]string "Account locked; see Retry-After"
+// @Failure 429 {object} map[string]string "Too many failures from this IP; see Retry-After"
+// @Router /auth/password/change [post]
+func (h *PasswordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if p.Type != domain.PrincipalUser {
+		apierrors.Forbidden(w, "Only users have a password")
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	err := h.Change.Execute(r.Context(), usecase.PasswordChangeInput{
+		UserID:              p.ID,
+		CurrentPassword:     req.CurrentPassword,
+		NewPassword:         req.NewPassword,
+		IP:                  sessionMeta(r).IP,
+		RevokeOtherSessions: req.RevokeOtherSessions,
+		CurrentSessionID:    p.SessionID,
+	})
+	if writeLockout(w, err) {
+		return
+	}
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, usecase.ErrInvalidCurrentPassword):
+		apierrors.Forbidden(w, "Current password is incorrect")
+	case errors.Is(err, usecase.ErrPasswordUnchanged):
+		apierrors.ValidationError(w, "Password does not meet the policy", err.Error())
+	case errors.Is(err, usecase.ErrWeakPassword):
+		apierrors.ValidationError(w, "Password does not meet the policy", err.Error())
+	default:
+		apierrors.InternalError(w, "Failed to change password")
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+)
+
+type RevocationHandler struct {
+	ClientAuth   *usecase.ClientAuthUseCase
+	TokenService domain.TokenService
+}
+
+// Revoke godoc
+// @Summary Revoke a token
+// @Description RFC 7009 token revocation. The client authenticates (HTTP Basic or client_id/client_secret in the body; public clients send client_id only) and can only revoke tokens issued to it. Revoking an access token blacklists it; revoking a refresh token ends its session, including the access tokens issued from it. Unknown, invalid or already revoked tokens also get 200.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "Token to revoke"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "Client ID (when not using HTTP Basic)"
+// @Param client_secret formData string false "Client secret (when not using HTTP Basic)"
+// @Success 200 {string} string "Revoked (or nothing to revoke)"
+// @Failure 400 {object} errors.OAuthErrorResponse
+// @Failure 401 {object} errors.OAuthErrorResponse
+// @Failure 500 {object} errors.OAuthErrorResponse
+// @Router /oauth/revoke [post]
+func (h *RevocationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		apierrors.OAuthError(w, http.StatusBadRequest, domain.OAuthInvalidRequest, "malformed form body")
+		return
+	}
+
+	clientID, secret, err := clientCredentials(r)
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+	if _, err := h.ClientAuth.Authenticate(clientID, secret); err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		apierrors.OAuthError(w, http.StatusBadRequest, domain.OAuthInvalidRequest, "token is required")
+		return
+	}
+
+	err = h.TokenService.RevokeToken(token, r.PostForm.Get("token_type_hint"), clientID)
+	if errors.Is(err, domain.ErrTokenClientMismatch) {
+		apierrors.OAuthError(w, http.StatusBadRequest, domain.OAuthUnauthorizedClient, "token was issued to another client")
+		return
+	}
+	if err != nil {
+		apierrors.OAuthError(w, http.StatusServiceUnavailable, domain.OAuthServerError, "token revocation failed")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-chi/chi/v5"
+)
+
+type SessionHandler struct {
+	Sessions domain.SessionManager
+	Audit    *usecase.Auditor
+}
+
+// sessionMeta describes the device behind r for the session registry.
+func sessionMeta(r *http.Request) domain.SessionMeta {
+	return domain.SessionMeta{UserAgent: r.UserAgent(), IP: middleware.ClientIP(r)}
+}
+
+// @Summary      List my sessions
+// @Description  Returns the active sessions (one per login) of the authenticated user. The session of the calling access token is flagged as current.
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} domain.Session
+// @Failure      401 {object} map[string]string
+// @Router       /auth/sessions [get]
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.Sessions.ListSessions(r.Context(), p.ID)
+	if err != nil {
+		apierrors.InternalError(w, "Failed to list sessions")
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == p.SessionID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(sessions)
+}
+
+// @Summary      Revoke one of my sessions
+// @Description  Ends a session of the authenticated user: its refresh token stops working and its access tokens are rejected.
+// @Tags         auth
+// @Security     BearerAuth
+// @Param        id path string true "Session ID"
+// @Success      204
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /auth/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.Sessions.RevokeSession(r.Context(), p.ID, chi.URLParam(r, "id"))
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		apierrors.NotFound(w, "Session not found")
+		return
+	}
+	if err != nil {
+		apierrors.InternalError(w, "Failed to revoke session")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Log out everywhere
+// @Description  Ends every session of the authenticated user, including the current one.
+// @Tags         auth
+// @Security     BearerAuth
+// @Success      204
+// @Failure      401 {object} map[string]string
+// @Router       /auth/logout-all [post]
+func (h *SessionHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Sessions.RevokeAllSessions(r.Context(), p.ID); err != nil {
+		apierrors.InternalError(w, "Failed to revoke sessions")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      List a user's sessions
+// @Description  Returns the active sessions of any user.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Success      200 {array} domain.Session
+// @Router       /admin/users/{userId}/sessions [get]
+func (h *SessionHandler) AdminListSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.Sessions.ListSessions(r.Context(), chi.URLParam(r, "userId"))
+	if err != nil {
+		apierrors.InternalError(w, "Failed to list sessions")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(sessions)
+}
+
+// @Summary      Revoke a user's session
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Param        id path string true "Session ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /admin/users/{userId}/sessions/{id} [delete]
+func (h *SessionHandler) AdminRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, sessionID := chi.URLParam(r, "userId"), chi.URLParam(r, "id")
+	err := h.Sessions.RevokeSession(r.Context(), userID, sessionID)
+	outcome := domain.AuditSuccess
+	if err != nil {
+		outcome = domain.AuditFailure
+	}
+	h.Audit.Record(r.Context(), auditActor(r), domain.AuditSessionRevoke, outcome, "session", sessionID, map[string]string{"user_id": userID})
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		apierrors.NotFound(w, "Session not found")
+		return
+	}
+	if err != nil {
+		apierrors.InternalError(w, "Failed to revoke session")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Revoke all of a user's sessions
+// @Description  Logs the user out everywhere.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Success      204
+// @Router       /admin/users/{userId}/sessions [delete]
+func (h *SessionHandler) AdminRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userId")
+	err := h.Sessions.RevokeAllSessions(r.Context(), userID)
+	outcome := domain.AuditSuccess
+	if err != nil {
+		outcome = domain.AuditFailure
+	}
+	h.Audit.Record(r.Context(), auditActor(r), domain.AuditSessionRevokeAll, outcome, "user", userID, nil)
+	if err != nil {
+		apierrors.InternalError(w, "Failed to revoke sessions")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
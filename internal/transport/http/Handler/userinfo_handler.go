@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+)
+
+type UserInfoHandler struct {
+	UserRepo domain.UserRepository
+}
+
+// UserInfoResponse holds the OIDC standard claims of the user. Email claims are
+// only returned when the access token was granted the email scope.
+type UserInfoResponse struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// UserInfo godoc
+// @Summary OIDC UserInfo endpoint
+// @Description Returns standard claims about the user the access token was issued for. The token must carry the openid scope.
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} UserInfoResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /userinfo [get]
+func (h *UserInfoHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if p.Type != domain.PrincipalUser || !hasScope(p.Scopes, "openid") {
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		apierrors.Forbidden(w, "The access token was not granted the openid scope")
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(p.ID)
+	if err != nil {
+		apierrors.InternalError(w, "Failed to load user")
+		return
+	}
+	if user == nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		apierrors.Unauthorized(w, "User no longer exists")
+		return
+	}
+
+	resp := UserInfoResponse{Sub: user.ID}
+	if hasScope(p.Scopes, "email") {
+		resp.Email = user.Email
+		resp.EmailVerified = &user.Verified
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/service/webauthn"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
+	"github.com/go-playground/validator/v10"
+)
+
+type WebAuthnHandler struct {
+	UC       *usecase.WebAuthnUseCase
+	Validate *validator.Validate
+
+	// Auth issues the tokens of a passkey sign-in, exactly like a password
+	// login.
+	Auth *AuthHandler
+}
+
+type WebAuthnRegistrationOptionsResponse struct {
+	SessionID string                   `json:"session_id"`
+	PublicKey webauthn.CreationOptions `json:"publicKey"`
+}
+
+type WebAuthnRegisterFinishRequest struct {
+	SessionID  string                        `json:"session_id" validate:"required"`
+	Name       string                        `json:"name" validate:"max=100" example:"YubiKey 5"`
+	Credential webauthn.RegistrationResponse `json:"credential"`
+}
+
+type WebAuthnCredentialResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WebAuthnLoginBeginRequest struct {
+	Email string `json:"email" validate:"omitempty,email" example:"user@example.com"`
+}
+
+type WebAuthnLoginOptionsResponse struct {
+	SessionID string                  `json:"session_id"`
+	PublicKey webauthn.RequestOptions `json:"publicKey"`
+}
+
+type WebAuthnLoginFinishRequest struct {
+	SessionID  string                     `json:"session_id" validate:"required"`
+	Credential webauthn.AssertionResponse `json:"credential"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// BeginRegistration godoc
+// @Summary Start registering a passkey
+// @Description Returns the options for navigator.credentials.create() and a session_id to send back with the result. The challenge expires after WEBAUTHN_TIMEOUT. User verification is required.
+// @Tags webauthn
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} WebAuthnRegistrationOptionsResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Not a user token"
+// @Router /auth/webauthn/register/begin [post]
+func (h *WebAuthnHandler) BeginRegistration(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if p.Type != domain.PrincipalUser {
+		apierrors.Forbidden(w, "Only users can register passkeys")
+		return
+	}
+
+	sessionID, opts, err := h.UC.BeginRegistration(r.Context(), p.ID)
+	if err != nil {
+		apierrors.InternalError(w, "Failed to start passkey registration")
+		return
+	}
+	writeJSON(w, http.StatusOK, WebAuthnRegistrationOptionsResponse{SessionID: sessionID, PublicKey: opts})
+}
+
+// FinishRegistration godoc
+// @Summary Finish registering a passkey
+// @Description Verifies the credential returned by navigator.credentials.create() and adds it to the account.
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body WebAuthnRegisterFinishRequest true "Session and credential"
+// @Success 201 {object} WebAuthnCredentialResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Not a user token"
+// @Failure 409 {object} map[string]string "Credential already registered"
+// @Failure 422 {object} map[string]string
+// @Router /auth/webauthn/register/finish [post]
+func (h *WebAuthnHandler) FinishRegistration(w http.ResponseWriter, r *http.Request) {
+	p, ok := middleware.MustPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if p.Type != domain.PrincipalUser {
+		apierrors.Forbidden(w, "Only users can register passkeys")
+		return
+	}
+
+	var req WebAuthnRegisterFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	cred, err := h.UC.FinishRegistration(r.Context(), p.ID, req.SessionID, req.Name, req.Credential)
+	switch {
+	case errors.Is(err, domain.ErrWebAuthnSessionNotFound):
+		apierrors.BadRequest(w, "Invalid or expired registration; start again")
+		return
+	case errors.Is(err, webauthn.ErrVerification):
+		apierrors.ValidationError(w, "Credential verification failed", err.Error())
+		return
+	case errors.Is(err, domain.ErrWebAuthnCredentialExists):
+		apierrors.Conflict(w, "Credential already registered")
+		return
+	case err != nil:
+		apierrors.InternalError(w, "Failed to register the passkey")
+		return
+	}
+	writeJSON(w, http.StatusCreated, WebAuthnCredentialResponse{ID: cred.ID, Name: cred.Name})
+}
+
+// BeginLogin godoc
+// @Summary Start a passkey sign-in
+// @Description Returns the options for navigator.credentials.get() and a session_id. With an email the browser is offered that account's credentials; without one (or for an unknown email) it offers discoverable passkeys.
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param input body WebAuthnLoginBeginRequest false "Optional account email"
+// @Success 200 {object} WebAuthnLoginOptionsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /auth/webauthn/login/begin [post]
+func (h *WebAuthnHandler) BeginLogin(w http.ResponseWriter, r *http.Request) {
+	var req WebAuthnLoginBeginRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierrors.BadRequest(w, "Invalid JSON payload")
+			return
+		}
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	sessionID, opts, err := h.UC.BeginLogin(r.Context(), req.Email)
+	if err != nil {
+		apierrors.InternalError(w, "Failed to start passkey sign-in")
+		return
+	}
+	writeJSON(w, http.StatusOK, WebAuthnLoginOptionsResponse{SessionID: sessionID, PublicKey: opts})
+}
+
+// FinishLogin godoc
+// @Summary Finish a passkey sign-in
+// @Description Verifies the assertion returned by navigator.credentials.get() and returns an access+refresh pair, like /auth/login. A user-verified passkey stands in for the password and the TOTP step.
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param input body WebAuthnLoginFinishRequest true "Session and assertion"
+// @Success 200 {object} AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Account disabled or password reset required"
+// @Router /auth/webauthn/login/finish [post]
+func (h *WebAuthnHandler) FinishLogin(w http.ResponseWriter, r *http.Request) {
+	var req WebAuthnLoginFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierrors.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		apierrors.ValidationError(w, "Validation failed", err.Error())
+		return
+	}
+
+	user, err := h.UC.FinishLogin(r.Context(), req.SessionID, req.Credential)
+	if writeSignInRefused(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrWebAuthnSessionNotFound):
+		apierrors.Unauthorized(w, "Invalid or expired sign-in; start again")
+		return
+	case errors.Is(err, domain.ErrWebAuthnCredentialUnknown), errors.Is(err, webauthn.ErrVerification):
+		apierrors.Unauthorized(w, "Passkey verification failed")
+		return
+	case err != nil:
+		apierrors.InternalError(w, "Failed to verify the passkey")
+		return
+	}
+
+	h.Auth.issueUserTokens(w, r, user)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+type WellKnownHandler struct {
+	TokenService domain.TokenService
+	Issuer       string // JWT_ISSUER: the public base URL, also the iss of ID tokens
+	SigningAlg   string // algorithm of access and ID tokens
+}
+
+// OpenIDConfiguration is the OIDC discovery document (OpenID Connect Discovery 1.0).
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer" example:"https://auth.example.com"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Publishes the public keys that sign access tokens (RFC 7517). Resource servers pick the key by the `kid` JWT header and verify tokens offline. Empty when the service signs with HS256.
+// @Tags well-known
+// @Produce json
+// @Success 200 {object} domain.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *WellKnownHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = json.NewEncoder(w).Encode(h.TokenService.JWKS())
+}
+
+// OpenIDConfiguration godoc
+// @Summary OpenID Connect discovery document
+// @Description Describes the endpoints, grants and algorithms supported by this provider so OIDC client libraries can configure themselves. The issuer and every endpoint derive from JWT_ISSUER, the public base URL of the service, which is also the iss of ID tokens.
+// @Tags well-known
+// @Produce json
+// @Success 200 {object} OpenIDConfiguration
+// @Router /.well-known/openid-configuration [get]
+func (h *WellKnownHandler) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	base := h.Issuer
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = json.NewEncoder(w).Encode(OpenIDConfiguration{
+		Issuer:                            h.Issuer,
+		AuthorizationEndpoint:             base + "/oauth/authorize",
+		TokenEndpoint:                     base + "/oauth/token",
+		UserinfoEndpoint:                  base + "/userinfo",
+		IntrospectionEndpoint:             base + "/oauth/introspect",
+		RevocationEndpoint:                base + "/oauth/revoke",
+		JWKSURI:                           base + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{h.SigningAlg},
+		ScopesSupported:                   []string{"openid", "email", "roles"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "at_hash", "sid", "email", "email_verified"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	})
+}
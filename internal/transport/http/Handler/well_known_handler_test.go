@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/service/token"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestIDTokenIssuerMatchesDiscovery(t *testing.T) {
+	metrics.MustRegister()
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	const issuer = "https://auth.example.com"
+	svc := token.NewService(token.Config{
+		AccessSecret:  []byte("access-secret-access-secret-0123"),
+		RefreshSecret: []byte("refresh-secret-refresh-secret-01"),
+		AccessTTL:     time.Minute,
+		RefreshTTL:    time.Hour,
+		Issuer:        issuer,
+	}, rdb)
+	h := &WellKnownHandler{TokenService: svc, Issuer: issuer, SigningAlg: token.AlgHS256}
+
+	pair, err := svc.IssuePair(domain.Principal{Type: domain.PrincipalUser, ID: "u1", ClientID: "app", Scopes: []string{"openid"}}, domain.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	idToken, err := svc.IssueIDToken(pair.AccessToken, "n-0S6_WzA2Mj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims jwt.MapClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(idToken, &claims); err != nil {
+		t.Fatal(err)
+	}
+
+	// The request host must not leak into the document.
+	req := httptest.NewRequest(http.MethodGet, "http://attacker.example/.well-known/openid-configuration", nil)
+	rec := httptest.NewRecorder()
+	h.OpenIDConfiguration(rec, req)
+	var doc OpenIDConfiguration
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if claims["iss"] != doc.Issuer || doc.Issuer != issuer {
+		t.Errorf("id_token iss = %v, discovery issuer = %q, want both %q", claims["iss"], doc.Issuer, issuer)
+	}
+	if doc.JWKSURI != issuer+"/.well-known/jwks.json" || doc.TokenEndpoint != issuer+"/oauth/token" {
+		t.Errorf("endpoints not derived from the issuer: %+v", doc)
+	}
+}
@@ -1,15 +1,23 @@
 package http
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	_ "github.com/YuriGarciaRibeiro/auth-microservice-go/docs"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/cache"
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/db"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/mail"
 	tokenSvc "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/service/token"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/service/webauthn"
 	handler "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/http/handler"
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/transport/middleware"
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/usecase"
@@ -34,6 +42,37 @@ func mustDuration(envKey, def string) time.Duration {
 	return d
 }
 
+func mustInt(envKey string, def int) int {
+	v := os.Getenv(envKey)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		panic("invalid integer for " + envKey + ": " + err.Error())
+	}
+	return n
+}
+
+// mustRateLimit reads a "<requests>/<window>" limit such as "10/1m"; "0"
+// disables it.
+func mustRateLimit(envKey, def string) domain.RateLimit {
+	v := os.Getenv(envKey)
+	if v == "" {
+		v = def
+	}
+	if v == "0" {
+		return domain.RateLimit{}
+	}
+	n, w, ok := strings.Cut(v, "/")
+	limit, err1 := strconv.Atoi(strings.TrimSpace(n))
+	window, err2 := time.ParseDuration(strings.TrimSpace(w))
+	if !ok || err1 != nil || err2 != nil || limit < 0 || window < time.Millisecond {
+		panic("invalid rate limit for " + envKey + ": want <requests>/<window>, got " + v)
+	}
+	return domain.RateLimit{Limit: limit, Window: window}
+}
+
 func splitCSV(s string) []string {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -54,7 +93,75 @@ func newGoRedisFromEnv() *redis.Client {
 	return redis.NewClient(&redis.Options{Addr: addr})
 }
 
-func NewRouter(logger *zap.SugaredLogger, appCache *cache.RedisClient) http.Handler {
+// signingKeysFromEnv builds the asymmetric key set for access tokens from
+// JWT_SIGNING_ALG / JWT_SIGNING_KEY_FILES. HS256 (the default) returns nil.
+func signingKeysFromEnv(logger *zap.SugaredLogger) ([]tokenSvc.Key, error) {
+	alg := os.Getenv("JWT_SIGNING_ALG")
+	if alg == "" || alg == tokenSvc.AlgHS256 {
+		return nil, nil
+	}
+	if !tokenSvc.IsAsymmetric(alg) {
+		return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %q", alg)
+	}
+
+	files := splitCSV(os.Getenv("JWT_SIGNING_KEY_FILES"))
+	if len(files) == 0 {
+		logger.Warnw("no JWT_SIGNING_KEY_FILES set, generating an ephemeral signing key; tokens will not survive a restart", "alg", alg)
+		k, err := tokenSvc.GenerateKey(alg)
+		if err != nil {
+			return nil, err
+		}
+		return []tokenSvc.Key{k}, nil
+	}
+
+	keys := make([]tokenSvc.Key, 0, len(files))
+	for _, f := range files {
+		k, err := tokenSvc.LoadKeyFile(f)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if keys[0].Algorithm != alg {
+		return nil, fmt.Errorf("active signing key %s is %s, expected %s", files[0], keys[0].Algorithm, alg)
+	}
+	return keys, nil
+}
+
+// issuerFromEnv reads JWT_ISSUER. It is the iss of every token and the OIDC
+// issuer, which must be the https URL of the service (OIDC Discovery section 3).
+func issuerFromEnv() (string, error) {
+	v := os.Getenv("JWT_ISSUER")
+	if v == "" {
+		v = "http://localhost:8080"
+	}
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("JWT_ISSUER must be an absolute http(s) URL without query or fragment, got %q", v)
+	}
+	return strings.TrimSuffix(v, "/"), nil
+}
+
+// mailerFromEnv picks the Mailer: MAILER=smtp relays through SMTP_*, anything
+// else logs emails (and writes them to MAIL_OUTBOX_DIR when set).
+func mailerFromEnv() domain.Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+	if os.Getenv("MAILER") == "smtp" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return mail.NewSMTPMailer(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASS"), from)
+	}
+	return mail.NewLogMailer(os.Getenv("MAIL_OUTBOX_DIR"), from)
+}
+
+// NewRouter wires the service. The returned function flushes what is still
+// buffered (audit events); call it once the server stopped serving.
+func NewRouter(logger *zap.SugaredLogger, appCache *cache.RedisClient) (http.Handler, func(context.Context) error) {
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
@@ -77,22 +184,49 @@ func NewRouter(logger *zap.SugaredLogger, appCache *cache.RedisClient) http.Hand
 		logger.Fatal("ACCESS_SECRET and REFRESH_SECRET must be set")
 	}
 
+	kek, err := base64.StdEncoding.DecodeString(os.Getenv("JWT_KEY_ENCRYPTION_KEY"))
+	if err != nil || len(kek) != 32 {
+		logger.Fatal("JWT_KEY_ENCRYPTION_KEY must be 32 bytes, base64-encoded")
+	}
+
+	issuer, err := issuerFromEnv()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	signingKeys, err := signingKeysFromEnv(logger)
+	if err != nil {
+		logger.Fatalw("failed to load signing keys", "error", err)
+	}
+
 	cfg := tokenSvc.Config{
-		AccessSecret:    accessSecret,
-		RefreshSecret:   refreshSecret,
-		AccessTTL:       mustDuration("ACCESS_TOKEN_TTL", "15m"),
-		RefreshTTL:      mustDuration("REFRESH_TOKEN_TTL", "168h"),
-		Issuer:          os.Getenv("JWT_ISSUER"),
-		DefaultAudience: splitCSV(os.Getenv("JWT_AUDIENCE")),
+		AccessSecret:     accessSecret,
+		RefreshSecret:    refreshSecret,
+		AccessTTL:        mustDuration("ACCESS_TOKEN_TTL", "15m"),
+		RefreshTTL:       mustDuration("REFRESH_TOKEN_TTL", "168h"),
+		Issuer:           issuer,
+		DefaultAudience:  splitCSV(os.Getenv("JWT_AUDIENCE")),
+		SigningKeys:      signingKeys,
+		KeyEncryptionKey: kek,
 	}
 
 	// Raw go-redis client for TokenService.
 	rawRedis := newGoRedisFromEnv()
 	tokenService := tokenSvc.NewService(cfg, rawRedis)
 
+	// Share the signing keyrings through Redis and keep them rotating.
+	syncCtx, cancelSync := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelSync()
+	if err := tokenService.SyncKeys(syncCtx); err != nil {
+		logger.Fatalw("failed to sync signing keys", "error", err)
+	}
+	go tokenService.RunKeyRotation(context.Background(), mustDuration("KEY_ROTATION_INTERVAL", "0"))
+
 	// Repositories.
 	userRepo := db.NewGormUserRepository(gormDb)
 	clientRepo := db.NewGormClientRepository(gormDb)
+	orgRepo := db.NewGormOrganizationRepository(gormDb)
+	policyRepo := db.NewGormPolicyRepository(gormDb)
 	permRepo := db.NewGormPermissionRepositoryWithCache(
 		gormDb,
 		rawRedis,
@@ -100,10 +234,89 @@ func NewRouter(logger *zap.SugaredLogger, appCache *cache.RedisClient) http.Hand
 	)
 
 	// Use cases.
-	signUpUC := usecase.NewSignupUseCase(userRepo)
-	loginUC := usecase.NewLoginUseCase(userRepo)
+	auditKey, err := base64.StdEncoding.DecodeString(os.Getenv("AUDIT_HMAC_KEY"))
+	if err != nil || len(auditKey) < 32 {
+		logger.Fatal("AUDIT_HMAC_KEY must be at least 32 bytes, base64-encoded")
+	}
+	auditor := usecase.NewAuditor(db.NewGormAuditRepository(gormDb, auditKey), auditKey)
+	auditor.Start(mustInt("AUDIT_QUEUE_SIZE", 1024))
+	mailer := mailerFromEnv()
+	verificationUC := usecase.NewEmailVerificationUseCase(
+		userRepo,
+		tokenService,
+		mailer,
+		mustDuration("EMAIL_VERIFICATION_TTL", "24h"),
+		os.Getenv("EMAIL_VERIFICATION_URL"),
+	)
+	passwordPolicy := usecase.NewPasswordPolicy(mustInt("PASSWORD_MIN_LENGTH", 6))
+	signUpUC := usecase.NewSignupUseCase(userRepo, verificationUC, passwordPolicy)
+	signUpUC.Audit = auditor
+	loginUC := usecase.NewLoginUseCase(userRepo, os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true")
+	loginGuard := usecase.NewLoginGuard(
+		cache.NewLoginAttemptStore(rawRedis),
+		mustInt("LOGIN_MAX_FAILURES", 5),
+		mustInt("LOGIN_IP_MAX_FAILURES", 20),
+		mustDuration("LOGIN_FAILURE_WINDOW", "15m"),
+		mustDuration("LOGIN_LOCKOUT_DURATION", "5m"),
+		mustDuration("LOGIN_LOCKOUT_MAX", "24h"),
+	)
+	loginUC.Guard = loginGuard
+	loginUC.Audit = auditor
+	passwordResetUC := usecase.NewPasswordResetUseCase(
+		userRepo,
+		cache.NewPasswordResetStore(rawRedis),
+		mailer,
+		tokenService,
+		passwordPolicy,
+		mustDuration("PASSWORD_RESET_TTL", "30m"),
+		os.Getenv("PASSWORD_RESET_URL"),
+	)
+	mfaIssuer := os.Getenv("MFA_ISSUER")
+	if mfaIssuer == "" {
+		u, _ := url.Parse(cfg.Issuer)
+		mfaIssuer = u.Hostname()
+	}
+	mfaUC := usecase.NewMFAUseCase(
+		userRepo,
+		db.NewGormMFARepository(gormDb),
+		cache.NewMFAChallengeStore(rawRedis),
+		mfaIssuer,
+		mustDuration("MFA_CHALLENGE_TTL", "5m"),
+		mustInt("MFA_MAX_ATTEMPTS", 5),
+	)
+	mfaUC.Guard = loginGuard
+	rp := &webauthn.RelyingParty{
+		ID:      os.Getenv("WEBAUTHN_RP_ID"),
+		Name:    os.Getenv("WEBAUTHN_RP_NAME"),
+		Origins: splitCSV(os.Getenv("WEBAUTHN_ORIGINS")),
+	}
+	if rp.ID == "" {
+		rp.ID = "localhost"
+	}
+	if rp.Name == "" {
+		rp.Name = mfaIssuer
+	}
+	if len(rp.Origins) == 0 {
+		rp.Origins = []string{"http://localhost:8080"}
+	}
+	webAuthnUC := usecase.NewWebAuthnUseCase(
+		userRepo,
+		db.NewGormWebAuthnRepository(gormDb),
+		cache.NewWebAuthnSessionStore(rawRedis),
+		rp,
+		mustDuration("WEBAUTHN_TIMEOUT", "2m"),
+	)
 	clientUC := usecase.NewClientCredentialsUseCase(clientRepo)
 	permUC := usecase.NewPermAdminUseCase(permRepo)
+	permUC.Audit = auditor
+	clientAuthUC := usecase.NewClientAuthUseCase(clientRepo)
+	authCodeUC := usecase.NewAuthorizationCodeUseCase(
+		clientRepo,
+		cache.NewAuthCodeStore(rawRedis),
+		userRepo,
+		permRepo,
+		mustDuration("AUTH_CODE_TTL", "1m"),
+	)
 
 	// Handlers.
 	authHandler := &handler.AuthHandler{
@@ -113,48 +326,277 @@ func NewRouter(logger *zap.SugaredLogger, appCache *cache.RedisClient) http.Hand
 		TokenService:         tokenService,
 		Cache:                appCache,
 		PermissionRepository: permRepo,
+		MFA:                  mfaUC,
+		Audit:                auditor,
+	}
+
+	mfaHandler := &handler.MFAHandler{
+		UC:       mfaUC,
+		Validate: validate,
+	}
+
+	webAuthnHandler := &handler.WebAuthnHandler{
+		UC:       webAuthnUC,
+		Validate: validate,
+		Auth:     authHandler,
+	}
+
+	passwordChangeUC := usecase.NewPasswordChangeUseCase(userRepo, tokenService, passwordPolicy)
+	passwordChangeUC.Guard = loginGuard
+	passwordHandler := &handler.PasswordHandler{
+		Reset:    passwordResetUC,
+		Change:   passwordChangeUC,
+		Validate: validate,
+	}
+
+	emailVerificationHandler := &handler.EmailVerificationHandler{
+		UC:       verificationUC,
+		Validate: validate,
 	}
 
 	clientTokenHandler := &handler.ClientTokenHandler{
-		Validate:             validate,
 		UC:                   clientUC,
 		TokenService:         tokenService,
 		PermissionRepository: permRepo,
+		Audit:                auditor,
+	}
+
+	introspectionHandler := &handler.IntrospectionHandler{
+		ClientAuth:   clientAuthUC,
+		TokenService: tokenService,
+	}
+
+	revocationHandler := &handler.RevocationHandler{
+		ClientAuth:   clientAuthUC,
+		TokenService: tokenService,
 	}
 
 	adminHandler := &handler.AdminPermHandler{UC: permUC, Validate: validate}
+	adminGroupHandler := &handler.AdminGroupHandler{UC: permUC, Validate: validate}
+
+	adminKeyHandler := &handler.AdminKeyHandler{Keys: tokenService, Audit: auditor}
+
+	adminLockoutHandler := &handler.AdminLockoutHandler{UserRepo: userRepo, Guard: loginGuard, Audit: auditor}
+
+	adminAuditHandler := &handler.AdminAuditHandler{Audit: auditor}
+
+	adminUserUC := usecase.NewAdminUserUseCase(userRepo, tokenService, permRepo, orgRepo, passwordResetUC)
+	adminUserUC.Audit = auditor
+	adminUserHandler := &handler.AdminUserHandler{UC: adminUserUC, Validate: validate}
+	adminClientUC := usecase.NewAdminClientUseCase(clientRepo, mustDuration("CLIENT_SECRET_GRACE_PERIOD", "24h"))
+	adminClientUC.Audit = auditor
+	adminClientHandler := &handler.AdminClientHandler{UC: adminClientUC, Validate: validate}
+	orgUC := usecase.NewOrganizationUseCase(orgRepo)
+	orgUC.Audit = auditor
+	adminOrgHandler := &handler.AdminOrgHandler{UC: orgUC, Validate: validate}
+	policyUC := usecase.NewPolicyUseCase(policyRepo)
+	policyUC.Audit = auditor
+	adminPolicyHandler := &handler.AdminPolicyHandler{UC: policyUC, Validate: validate}
+	authzHandler := &handler.AuthzHandler{
+		UC:       usecase.NewAuthzUseCase(policyRepo, tokenService, userRepo, clientRepo, permRepo),
+		Validate: validate,
+	}
+
+	sessionHandler := &handler.SessionHandler{Sessions: tokenService, Audit: auditor}
+
+	oauthHandler := &handler.OAuthHandler{
+		Login:        loginUC,
+		AuthCode:     authCodeUC,
+		TokenService: tokenService,
+		MFA:          mfaUC,
+
+		ClientCredentials: clientTokenHandler,
+		Audit:             auditor,
+	}
+
+	signingAlg := os.Getenv("JWT_SIGNING_ALG")
+	if signingAlg == "" {
+		signingAlg = tokenSvc.AlgHS256
+	}
+	wellKnownHandler := &handler.WellKnownHandler{
+		TokenService: tokenService,
+		Issuer:       cfg.Issuer,
+		SigningAlg:   signingAlg,
+	}
+
+	userInfoHandler := &handler.UserInfoHandler{UserRepo: userRepo}
+
+	// Rate limits, on Redis with per-instance buckets when it is unreachable.
+	limiter := cache.NewFallbackRateLimiter(cache.NewRedisRateLimiter(rawRedis), cache.NewMemoryRateLimiter())
+	rateLimit := func(name, envKey, def string, key middleware.RateLimitKey) func(http.Handler) http.Handler {
+		return middleware.RateLimit(limiter, middleware.RateLimitRule{
+			Name:  name,
+			Limit: mustRateLimit(envKey, def),
+			Key:   key,
+		})
+	}
+	signupLimit := rateLimit("signup", "RATE_LIMIT_SIGNUP", "10/1h", middleware.KeyByIP)
+	loginLimit := rateLimit("login", "RATE_LIMIT_LOGIN", "30/1m", middleware.KeyByIP)
+	emailLimit := rateLimit("email", "RATE_LIMIT_EMAIL", "5/15m", middleware.KeyByIP)
+	authLimit := rateLimit("auth", "RATE_LIMIT_AUTH", "60/1m", middleware.KeyByIP)
+	refreshLimit := rateLimit("refresh", "RATE_LIMIT_REFRESH", "30/1m", middleware.KeyByIP)
+	tokenLimit := rateLimit("token", "RATE_LIMIT_TOKEN", "60/1m", middleware.KeyByClientID)
+	introspectLimit := rateLimit("introspect", "RATE_LIMIT_INTROSPECT", "600/1m", middleware.KeyByClientID)
+	clientIPLimit := rateLimit("client-ip", "RATE_LIMIT_CLIENT_IP", "1200/1m", middleware.KeyByIP)
+	userLimit := rateLimit("user", "RATE_LIMIT_USER", "120/1m", middleware.KeyBySubject)
+	authzLimit := rateLimit("authz", "RATE_LIMIT_AUTHZ", "600/1m", middleware.KeyBySubject)
 
 	health := NewHealthHandler(gormDb, rawRedis, 2*time.Second, 1*time.Second)
 
 	// Routes.
 	r.Route("/auth", func(r chi.Router) {
-		r.Post("/signup", authHandler.SignUpHandler)
-		r.Post("/login", authHandler.LoginHandler)
-		r.Post("/logout", authHandler.LogoutHandler)
-		r.Post("/refresh", authHandler.RefreshHandler)
-		r.Post("/introspect", authHandler.IntrospectHandler)
-		r.Post("/token", clientTokenHandler.ServeHTTP)
+		r.With(signupLimit).Post("/signup", authHandler.SignUpHandler)
+		r.With(loginLimit).Post("/login", authHandler.LoginHandler)
+		r.With(authLimit).Post("/logout", authHandler.LogoutHandler)
+		r.With(refreshLimit).Post("/refresh", authHandler.RefreshHandler)
+		r.With(authLimit).Post("/verify-email", emailVerificationHandler.VerifyEmail)
+		r.With(emailLimit).Post("/verify-email/resend", emailVerificationHandler.ResendVerification)
+		r.With(emailLimit).Post("/password/forgot", passwordHandler.ForgotPassword)
+		r.With(authLimit).Post("/password/reset", passwordHandler.ResetPassword)
+		r.With(loginLimit).Post("/mfa/verify", authHandler.VerifyMFAHandler)
+		r.With(loginLimit).Post("/webauthn/login/begin", webAuthnHandler.BeginLogin)
+		r.With(loginLimit).Post("/webauthn/login/finish", webAuthnHandler.FinishLogin)
+		r.With(clientIPLimit, introspectLimit).Post("/introspect", introspectionHandler.IntrospectHandler)
+		r.With(clientIPLimit, tokenLimit).Post("/token", clientTokenHandler.ServeHTTP)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Authn(tokenService))
+			r.Use(userLimit)
+
+			r.Get("/sessions", sessionHandler.ListSessions)
+			r.Delete("/sessions/{id}", sessionHandler.RevokeSession)
+			r.Post("/logout-all", sessionHandler.LogoutAll)
+			r.Post("/password/change", passwordHandler.ChangePassword)
+
+			r.Post("/mfa/totp/enroll", mfaHandler.EnrollTOTP)
+			r.Post("/mfa/totp/confirm", mfaHandler.ConfirmTOTP)
+			r.Post("/mfa/totp/disable", mfaHandler.DisableTOTP)
+
+			r.Post("/webauthn/register/begin", webAuthnHandler.BeginRegistration)
+			r.Post("/webauthn/register/finish", webAuthnHandler.FinishRegistration)
+		})
 	})
 
-	r.Route("/admin", func(r chi.Router) {
-		r.Use(middleware.Authn(tokenService))
-		r.Use(middleware.RequireRoles("admin"))
+	r.Route("/oauth", func(r chi.Router) {
+		r.Get("/authorize", oauthHandler.Authorize)
+		r.With(loginLimit).Post("/authorize", oauthHandler.AuthorizeSubmit)
+		r.With(clientIPLimit, tokenLimit).Post("/token", oauthHandler.Token)
+		r.With(clientIPLimit, introspectLimit).Post("/introspect", introspectionHandler.IntrospectHandler)
+		r.With(clientIPLimit, introspectLimit).Post("/revoke", revocationHandler.Revoke)
+	})
 
+	// Catalog, user, client and policy management: platform-wide under /admin, and
+	// for its own organization under /orgs/{orgId}.
+	manage := func(r chi.Router) {
 		r.Post("/scopes", adminHandler.CreateScope)
 		r.Get("/scopes", adminHandler.ListScopes)
+		r.Delete("/scopes/{scopeId}", adminHandler.DeleteScope)
 
 		r.Post("/roles", adminHandler.CreateRole)
 		r.Get("/roles", adminHandler.ListRoles)
+		r.Delete("/roles/{roleId}", adminHandler.DeleteRole)
 		r.Post("/roles/{roleId}/scopes", adminHandler.AddScopesToRole)
+		r.Delete("/roles/{roleId}/scopes/{scopeId}", adminHandler.RemoveScopeFromRole)
+		r.Post("/roles/{roleId}/parents", adminHandler.AddParentRoles)
+		r.Delete("/roles/{roleId}/parents/{parentId}", adminHandler.RemoveParentRole)
+		r.Get("/roles/{roleId}/tree", adminHandler.RoleTree)
+
+		r.Post("/groups", adminGroupHandler.CreateGroup)
+		r.Get("/groups", adminGroupHandler.ListGroups)
+		r.Get("/groups/{groupId}", adminGroupHandler.GetGroup)
+		r.Delete("/groups/{groupId}", adminGroupHandler.DeleteGroup)
+		r.Post("/groups/{groupId}/members", adminGroupHandler.AddMembers)
+		r.Delete("/groups/{groupId}/members/{userId}", adminGroupHandler.RemoveMember)
+		r.Post("/groups/{groupId}/roles", adminGroupHandler.AddRoles)
+		r.Delete("/groups/{groupId}/roles/{roleId}", adminGroupHandler.RemoveRole)
+		r.Post("/groups/{groupId}/scopes", adminGroupHandler.AddScopes)
+		r.Delete("/groups/{groupId}/scopes/{scopeId}", adminGroupHandler.RemoveScope)
+
+		r.Get("/users", adminUserHandler.ListUsers)
+		r.Get("/users/{userId}", adminUserHandler.GetUser)
+		r.Patch("/users/{userId}", adminUserHandler.UpdateUser)
+		r.Delete("/users/{userId}", adminUserHandler.DeleteUser)
+		r.Post("/users/{userId}/disable", adminUserHandler.DisableUser)
+		r.Post("/users/{userId}/enable", adminUserHandler.EnableUser)
+		r.Post("/users/{userId}/password/reset", adminUserHandler.ForcePasswordReset)
 
 		r.Post("/users/{userId}/roles", adminHandler.AddRolesToUser)
 		r.Get("/users/{userId}/roles", adminHandler.ListUserRoles)
+		r.Delete("/users/{userId}/roles/{roleId}", adminHandler.RemoveRoleFromUser)
+		r.Get("/users/{userId}/groups", adminGroupHandler.ListUserGroups)
 		r.Get("/users/{userId}/scopes", adminHandler.ListUserEffective)
 		r.Post("/users/{userId}/scopes/grant", adminHandler.GrantUserScope)
 		r.Post("/users/{userId}/scopes/revoke", adminHandler.RevokeUserScope)
 
+		r.Post("/clients", adminClientHandler.CreateClient)
+		r.Get("/clients", adminClientHandler.ListClients)
+		r.Get("/clients/{clientId}", adminClientHandler.GetClient)
+		r.Patch("/clients/{clientId}", adminClientHandler.UpdateClient)
+		r.Post("/clients/{clientId}/deactivate", adminClientHandler.DeactivateClient)
+		r.Post("/clients/{clientId}/activate", adminClientHandler.ActivateClient)
+		r.Post("/clients/{clientId}/secret/rotate", adminClientHandler.RotateSecret)
+
 		r.Post("/clients/{clientId}/scopes", adminHandler.AddScopesToClient)
 		r.Get("/clients/{clientId}/scopes", adminHandler.ListClientScopes)
+		r.Delete("/clients/{clientId}/scopes/{scopeId}", adminHandler.RemoveScopeFromClient)
+
+		r.Post("/policies", adminPolicyHandler.CreatePolicy)
+		r.Get("/policies", adminPolicyHandler.ListPolicies)
+		r.Get("/policies/{policyId}", adminPolicyHandler.GetPolicy)
+		r.Delete("/policies/{policyId}", adminPolicyHandler.DeletePolicy)
+	}
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.Authn(tokenService))
+		r.Use(middleware.RequirePlatform())
+		r.Use(middleware.RequireRoles("admin"))
+
+		manage(r)
+
+		r.Post("/orgs", adminOrgHandler.CreateOrganization)
+		r.Get("/orgs", adminOrgHandler.ListOrganizations)
+		r.Get("/orgs/{orgId}", adminOrgHandler.GetOrganization)
+
+		r.Get("/users/{userId}/sessions", sessionHandler.AdminListSessions)
+		r.Delete("/users/{userId}/sessions", sessionHandler.AdminRevokeAllSessions)
+		r.Post("/users/{userId}/unlock", adminLockoutHandler.UnlockUser)
+		r.Delete("/users/{userId}/sessions/{id}", sessionHandler.AdminRevokeSession)
+
+		r.Get("/audit", adminAuditHandler.ListEvents)
+		r.Get("/audit/verify", adminAuditHandler.VerifyChain)
+
+		r.Get("/keys", adminKeyHandler.ListKeys)
+		r.Post("/keys/rotate", adminKeyHandler.RotateKeys)
+		r.Delete("/keys/{kid}", adminKeyHandler.RemoveKey)
+	})
+
+	// Administrators of an organization hold its own "admin" role.
+	r.Route("/orgs/{orgId}", func(r chi.Router) {
+		r.Use(middleware.Authn(tokenService))
+		r.Use(middleware.RequireTenant("orgId"))
+		r.Use(middleware.RequireRoles("admin"))
+
+		manage(r)
+	})
+
+	// Policy decisions for the bearer of the access token or, in batches, for
+	// a subject named by a resource server.
+	r.Route("/authz", func(r chi.Router) {
+		r.Use(middleware.Authn(tokenService))
+		r.Use(authzLimit)
+
+		r.Post("/check", authzHandler.Check)
+		r.Post("/batch", authzHandler.CheckBatch)
+	})
+
+	r.Get("/.well-known/jwks.json", wellKnownHandler.JWKS)
+	r.Get("/.well-known/openid-configuration", wellKnownHandler.OpenIDConfiguration)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Authn(tokenService))
+
+		r.Get("/userinfo", userInfoHandler.UserInfo)
+		r.Post("/userinfo", userInfoHandler.UserInfo)
 	})
 
 	r.Handle("/metrics", promhttp.Handler())
@@ -166,5 +608,5 @@ func NewRouter(logger *zap.SugaredLogger, appCache *cache.RedisClient) http.Hand
 	r.Get("/readyz", health.Readyz)
 	r.Get("/buildz", health.Buildz)
 
-	return r
+	return r, auditor.Close
 }
@@ -54,16 +54,7 @@ func Authn(tokens domain.TokenService) func(http.Handler) http.Handler {
 				apierrors.Unauthorized(w, "Invalid or expired token")
 				return
 			}
-			principal := domain.Principal{
-				Type:     claims.SubjectType,
-				ID:       claims.SubjectID,
-				Email:    claims.Email,
-				Roles:    claims.Roles,
-				Scopes:   claims.Scopes,
-				ClientID: claims.ClientID,
-				Audience: claims.Audience,
-			}
-			ctx := context.WithValue(r.Context(), principalCtxKey, principal)
+			ctx := context.WithValue(r.Context(), principalCtxKey, claims.Principal())
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
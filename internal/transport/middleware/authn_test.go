@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+type stubTokens struct {
+	domain.TokenService
+	claims map[string]*domain.TokenClaims
+}
+
+func (s stubTokens) VerifyAccess(token string) (*domain.TokenClaims, error) {
+	c, ok := s.claims[token]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return c, nil
+}
+
+func TestAuthn(t *testing.T) {
+	authTime := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tokens := stubTokens{claims: map[string]*domain.TokenClaims{"good": {
+		SubjectType: domain.PrincipalUser,
+		SubjectID:   "u1",
+		Email:       "u1@example.com",
+		Roles:       []string{"editor"},
+		Scopes:      []string{"docs:read"},
+		ClientID:    "app",
+		Audience:    []string{"api"},
+		TenantID:    "org-1",
+		FamilyID:    "session-1",
+		AuthTime:    authTime,
+	}}}
+	want := domain.Principal{
+		Type:      domain.PrincipalUser,
+		ID:        "u1",
+		Email:     "u1@example.com",
+		Roles:     []string{"editor"},
+		Scopes:    []string{"docs:read"},
+		ClientID:  "app",
+		Audience:  []string{"api"},
+		TenantID:  "org-1",
+		SessionID: "session-1",
+		AuthTime:  authTime,
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "valid token", authorization: "Bearer good", wantStatus: http.StatusNoContent},
+		{name: "no header", wantStatus: http.StatusUnauthorized},
+		{name: "other scheme", authorization: "Basic good", wantStatus: http.StatusUnauthorized},
+		{name: "empty token", authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", authorization: "Bearer forged", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got domain.Principal
+			h := Authn(tokens)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = GetPrincipal(r)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Code == http.StatusNoContent && !reflect.DeepEqual(got, want) {
+				t.Errorf("principal = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
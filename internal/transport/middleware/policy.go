@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+)
+
+// ResourceFunc describes the resource a request acts on, as the attributes
+// policies match on (e.g. {"type": "document", "owner": chi.URLParam(r, "owner")}).
+// It may return nil for requests on no particular resource.
+type ResourceFunc func(r *http.Request) map[string]string
+
+// RequirePolicy lets through requests the policy engine allows to perform
+// action on the resource described by resource. Must run after Authn.
+func RequirePolicy(engine domain.PolicyEngine, action string, resource ResourceFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := GetPrincipal(r)
+			if !ok || p.ID == "" {
+				apierrors.Unauthorized(w, "Authentication required")
+				return
+			}
+			req := domain.AccessRequest{Subject: p, Action: action, IP: ClientIP(r)}
+			if resource != nil {
+				req.Resource = resource(r)
+			}
+			d, err := engine.Evaluate(r.Context(), req)
+			if err != nil {
+				apierrors.InternalError(w, "Failed to evaluate policies")
+				return
+			}
+			if !d.Allowed {
+				apierrors.Forbidden(w, "Insufficient permissions: denied by policy")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
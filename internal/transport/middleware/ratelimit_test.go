@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/cache"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
+)
+
+func formRequest(remoteAddr string, form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.RemoteAddr = remoteAddr
+	return r
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		remoteAddr string
+		want       string
+	}{
+		{remoteAddr: "10.0.0.1:5000", want: "10.0.0.1"},
+		{remoteAddr: "[2001:db8::1]:443", want: "2001:db8::1"},
+		{remoteAddr: "10.0.0.1", want: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.remoteAddr, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if got := ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyByClientID(t *testing.T) {
+	basic := formRequest("10.0.0.1:5000", nil)
+	basic.SetBasicAuth(url.QueryEscape("app one"), "secret")
+
+	tests := []struct {
+		name string
+		r    *http.Request
+		want string
+	}{
+		{name: "client_id form field", r: formRequest("10.0.0.1:5000", url.Values{"client_id": {"app"}}), want: "client:app@10.0.0.1"},
+		{name: "same client, other IP", r: formRequest("10.0.0.2:5000", url.Values{"client_id": {"app"}}), want: "client:app@10.0.0.2"},
+		{name: "HTTP Basic", r: basic, want: "client:app one@10.0.0.1"},
+		{name: "no client", r: formRequest("10.0.0.1:5000", url.Values{"grant_type": {"refresh_token"}}), want: "ip:10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KeyByClientID(tt.r); got != tt.want {
+				t.Errorf("KeyByClientID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// The handler still sees the form.
+	r := formRequest("10.0.0.1:5000", url.Values{"client_id": {"app"}, "code": {"abc"}})
+	KeyByClientID(r)
+	if r.PostForm.Get("code") != "abc" {
+		t.Errorf("form lost after keying: %v", r.PostForm)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	metrics.MustRegister()
+	limiter := cache.NewMemoryRateLimiter()
+	h := RateLimit(limiter, RateLimitRule{Name: "token", Limit: domain.RateLimit{Limit: 1, Window: time.Minute}, Key: KeyByClientID})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	do := func(remoteAddr, clientID string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, formRequest(remoteAddr, url.Values{"client_id": {clientID}}))
+		return rec
+	}
+
+	first := do("10.0.0.1:1", "app")
+	if first.Code != http.StatusOK || first.Header().Get("X-RateLimit-Limit") != "1" || first.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("first request: %d %v", first.Code, first.Header())
+	}
+	second := do("10.0.0.1:2", "app")
+	if second.Code != http.StatusTooManyRequests || second.Header().Get("Retry-After") != "60" {
+		t.Fatalf("second request: %d %v", second.Code, second.Header())
+	}
+	// Naming the client from elsewhere does not drain its budget.
+	if rec := do("10.0.0.9:1", "app"); rec.Code != http.StatusOK {
+		t.Errorf("other IP, same client_id: %d", rec.Code)
+	}
+
+	off := RateLimit(limiter, RateLimitRule{Name: "off", Key: KeyByIP})(http.NotFoundHandler())
+	rec := httptest.NewRecorder()
+	off.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Header().Get("X-RateLimit-Limit") != "" {
+		t.Error("disabled rule set rate limit headers")
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	apierrors "github.com/YuriGarciaRibeiro/auth-microservice-go/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const tenantCtxKey ctxKey = "auth.tenant"
+
+// GetTenant returns the tenant RequireTenant enforced on the request.
+func GetTenant(r *http.Request) (string, bool) {
+	t, ok := r.Context().Value(tenantCtxKey).(string)
+	return t, ok
+}
+
+// RequireTenant lets through principals whose tenant_id is the route
+// parameter param, and makes it the tenant of the request (see GetTenant).
+// Must run after Authn.
+func RequireTenant(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := GetPrincipal(r)
+			if !ok || p.ID == "" {
+				apierrors.Unauthorized(w, "Authentication required")
+				return
+			}
+			tenant := chi.URLParam(r, param)
+			if tenant == "" || p.TenantID != tenant {
+				apierrors.Forbidden(w, "Insufficient permissions: token belongs to another organization")
+				return
+			}
+			ctx := context.WithValue(r.Context(), tenantCtxKey, tenant)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequirePlatform lets through principals of the platform tenant only, so
+// that an organization's own "admin" role never opens the platform API.
+func RequirePlatform() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := GetPrincipal(r)
+			if !ok || p.ID == "" {
+				apierrors.Unauthorized(w, "Authentication required")
+				return
+			}
+			if p.TenantID != domain.PlatformTenant {
+				apierrors.Forbidden(w, "Insufficient permissions: organization tokens cannot use the platform API")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
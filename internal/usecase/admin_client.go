@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AdminClientUseCase struct {
+	Clients domain.ClientRepository
+
+	// GracePeriod is how long a rotated secret keeps working when the
+	// rotation doesn't say otherwise.
+	GracePeriod time.Duration
+
+	Now func() time.Time
+
+	// Audit, when set, records every change with its actor.
+	Audit *Auditor
+
+	tenant *string // see ForTenant
+}
+
+func NewAdminClientUseCase(clients domain.ClientRepository, gracePeriod time.Duration) *AdminClientUseCase {
+	return &AdminClientUseCase{
+		Clients:     clients,
+		GracePeriod: gracePeriod,
+		Now:         time.Now,
+	}
+}
+
+// ForTenant returns a copy of the use case that only sees the clients of one
+// tenant and registers new ones in it.
+func (uc *AdminClientUseCase) ForTenant(tenantID string) *AdminClientUseCase {
+	v := *uc
+	v.tenant = &tenantID
+	return &v
+}
+
+func (uc *AdminClientUseCase) clients() domain.ClientRepository {
+	if uc.tenant == nil {
+		return uc.Clients
+	}
+	return uc.Clients.ForTenant(*uc.tenant)
+}
+
+// NewClient describes a client to register.
+type NewClient struct {
+	ClientID        string
+	Name            string
+	AllowedScopes   []string
+	AllowedAudience []string
+	RedirectURIs    []string
+	Public          bool
+}
+
+// ClientUpdate holds the fields an administrator may change. Nil means
+// unchanged; an empty slice clears the list.
+type ClientUpdate struct {
+	Name            *string
+	AllowedScopes   []string
+	AllowedAudience []string
+	RedirectURIs    []string
+}
+
+func (uc *AdminClientUseCase) find(clientID string) (*domain.Client, error) {
+	c, err := uc.clients().FindByClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, domain.ErrClientNotFound
+	}
+	return c, nil
+}
+
+// newClientSecret returns a random secret and its bcrypt hash.
+func newClientSecret() (secret, hash string, err error) {
+	secret, err = newOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	h, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, string(h), nil
+}
+
+// Create registers an active client. Confidential clients get a generated
+// secret, returned here and never again; public clients get none.
+func (uc *AdminClientUseCase) Create(ctx context.Context, actor domain.Actor, in NewClient) (*domain.Client, string, error) {
+	clientID := strings.TrimSpace(in.ClientID)
+	// client_id is unique across tenants.
+	existing, err := uc.Clients.FindByClientID(clientID)
+	if err != nil {
+		return nil, "", err
+	}
+	if existing != nil {
+		return nil, "", domain.ErrClientIDInUse
+	}
+
+	c := &domain.Client{
+		ClientID:        clientID,
+		Name:            strings.TrimSpace(in.Name),
+		AllowedScopes:   unique(in.AllowedScopes),
+		AllowedAudience: unique(in.AllowedAudience),
+		RedirectURIs:    unique(in.RedirectURIs),
+		Public:          in.Public,
+		Active:          true,
+	}
+	var secret string
+	if !c.Public {
+		if secret, c.SecretHash, err = newClientSecret(); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := uc.clients().Create(c); err != nil {
+		return nil, "", err
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditClientCreate, domain.AuditSuccess, "client", c.ClientID, map[string]string{
+		"public": strconv.FormatBool(c.Public),
+	})
+	return c, secret, nil
+}
+
+func (uc *AdminClientUseCase) List() ([]*domain.Client, error) {
+	return uc.clients().List()
+}
+
+func (uc *AdminClientUseCase) Get(clientID string) (*domain.Client, error) {
+	return uc.find(clientID)
+}
+
+// Update changes the name and the allowed scopes, audiences and redirect URIs.
+func (uc *AdminClientUseCase) Update(ctx context.Context, actor domain.Actor, clientID string, in ClientUpdate) (*domain.Client, error) {
+	c, err := uc.find(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]string{}
+	if in.Name != nil {
+		c.Name = strings.TrimSpace(*in.Name)
+		details["name"] = c.Name
+	}
+	if in.AllowedScopes != nil {
+		c.AllowedScopes = unique(in.AllowedScopes)
+		details["allowed_scopes"] = strings.Join(c.AllowedScopes, " ")
+	}
+	if in.AllowedAudience != nil {
+		c.AllowedAudience = unique(in.AllowedAudience)
+		details["allowed_audience"] = strings.Join(c.AllowedAudience, " ")
+	}
+	if in.RedirectURIs != nil {
+		c.RedirectURIs = unique(in.RedirectURIs)
+		details["redirect_uris"] = strings.Join(c.RedirectURIs, " ")
+	}
+
+	if err := uc.clients().Update(c); err != nil {
+		return nil, err
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditClientUpdate, domain.AuditSuccess, "client", c.ClientID, details)
+	return c, nil
+}
+
+// SetActive deactivates or reactivates a client. Inactive clients fail
+// authentication on every endpoint; access tokens already issued stay valid
+// until they expire.
+func (uc *AdminClientUseCase) SetActive(ctx context.Context, actor domain.Actor, clientID string, active bool) error {
+	c, err := uc.find(clientID)
+	if err != nil {
+		return err
+	}
+	c.Active = active
+	if err := uc.clients().Update(c); err != nil {
+		return err
+	}
+	action := domain.AuditClientActivate
+	if !active {
+		action = domain.AuditClientDeactivate
+	}
+	uc.Audit.Record(ctx, actor, action, domain.AuditSuccess, "client", c.ClientID, nil)
+	return nil
+}
+
+// RotateSecret replaces the secret of a confidential client and returns the
+// new one. The old secret keeps working for grace (GracePeriod when nil); a
+// zero grace revokes it at once. A secret still in its grace period from an
+// earlier rotation is dropped.
+func (uc *AdminClientUseCase) RotateSecret(ctx context.Context, actor domain.Actor, clientID string, grace *time.Duration) (*domain.Client, string, error) {
+	c, err := uc.find(clientID)
+	if err != nil {
+		return nil, "", err
+	}
+	if c.Public {
+		return nil, "", domain.ErrPublicClient
+	}
+
+	secret, hash, err := newClientSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	g := uc.GracePeriod
+	if grace != nil {
+		g = *grace
+	}
+	c.PreviousSecretHash, c.PreviousSecretExpiresAt = "", nil
+	if g > 0 {
+		expires := uc.Now().Add(g)
+		c.PreviousSecretHash, c.PreviousSecretExpiresAt = c.SecretHash, &expires
+	}
+	c.SecretHash = hash
+
+	if err := uc.clients().Update(c); err != nil {
+		return nil, "", err
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditClientSecretRotate, domain.AuditSuccess, "client", c.ClientID, map[string]string{
+		"grace_period": g.String(),
+	})
+	return c, secret, nil
+}
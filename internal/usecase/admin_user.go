@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+// ErrSelfModification keeps administrators from disabling or deleting their
+// own account.
+var ErrSelfModification = errors.New("administrators cannot disable or delete themselves")
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+)
+
+type AdminUserUseCase struct {
+	Users    domain.UserRepository
+	Sessions domain.SessionManager
+	Perms    domain.PermissionRepository
+	Orgs     domain.OrganizationRepository
+
+	// Resets, when set, emails a reset link on a forced password reset.
+	Resets *PasswordResetUseCase
+
+	// Audit, when set, records every change with its actor.
+	Audit *Auditor
+
+	tenant *string // see ForTenant
+}
+
+func NewAdminUserUseCase(users domain.UserRepository, sessions domain.SessionManager, perms domain.PermissionRepository, orgs domain.OrganizationRepository, resets *PasswordResetUseCase) *AdminUserUseCase {
+	return &AdminUserUseCase{
+		Users:    users,
+		Sessions: sessions,
+		Perms:    perms,
+		Orgs:     orgs,
+		Resets:   resets,
+	}
+}
+
+// ForTenant returns a copy of the use case that only sees the users of one
+// tenant and can't move them out of it.
+func (uc *AdminUserUseCase) ForTenant(tenantID string) *AdminUserUseCase {
+	v := *uc
+	v.tenant = &tenantID
+	return &v
+}
+
+func (uc *AdminUserUseCase) users() domain.UserRepository {
+	if uc.tenant == nil {
+		return uc.Users
+	}
+	return uc.Users.ForTenant(*uc.tenant)
+}
+
+// UserUpdate holds the fields an administrator may change. Nil means unchanged.
+type UserUpdate struct {
+	Email    *string
+	Verified *bool
+
+	// TenantID moves the user to another organization (or to the platform
+	// when empty), dropping its role, scope and group assignments.
+	TenantID *string
+}
+
+func (uc *AdminUserUseCase) find(id string) (*domain.User, error) {
+	user, err := uc.users().FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (uc *AdminUserUseCase) List(f domain.UserFilter) ([]*domain.User, int64, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultUserPageSize
+	}
+	f.Limit = min(f.Limit, maxUserPageSize)
+	f.Offset = max(f.Offset, 0)
+	return uc.users().List(f)
+}
+
+func (uc *AdminUserUseCase) Get(id string) (*domain.User, error) {
+	return uc.find(id)
+}
+
+// Update changes the email, verified flag or tenant. A new email is
+// unverified unless the update says otherwise. Moving a user ends all their
+// sessions, so that no token keeps the old tenant_id.
+func (uc *AdminUserUseCase) Update(ctx context.Context, actor domain.Actor, id string, in UserUpdate) (*domain.User, error) {
+	user, err := uc.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	move := in.TenantID != nil && *in.TenantID != user.TenantID
+	if move {
+		if uc.tenant != nil {
+			return nil, domain.ErrCrossTenant
+		}
+		if *in.TenantID != domain.PlatformTenant {
+			org, err := uc.Orgs.FindByID(*in.TenantID)
+			if err != nil {
+				return nil, err
+			}
+			if org == nil {
+				return nil, domain.ErrOrganizationNotFound
+			}
+		}
+	}
+
+	details := map[string]string{}
+	if in.Email != nil {
+		email := strings.TrimSpace(*in.Email)
+		if !strings.EqualFold(email, user.Email) {
+			// Emails are unique across tenants.
+			other, err := uc.Users.FindByEmail(email)
+			if err != nil {
+				return nil, err
+			}
+			if other != nil && other.ID != user.ID {
+				return nil, domain.ErrEmailInUse
+			}
+			details["old_email"], details["email"] = user.Email, email
+			user.Verified = false
+		}
+		user.Email = email
+	}
+	if in.Verified != nil {
+		user.Verified = *in.Verified
+		details["verified"] = strconv.FormatBool(user.Verified)
+	}
+
+	if err := uc.users().Update(user); err != nil {
+		return nil, err
+	}
+	if move {
+		if err := uc.Users.SetTenant(user.ID, *in.TenantID); err != nil {
+			return nil, err
+		}
+		if err := uc.Sessions.RevokeAllSessions(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		if err := uc.Perms.InvalidateUser(user.ID); err != nil {
+			return nil, err
+		}
+		details["old_tenant_id"], details["tenant_id"] = user.TenantID, *in.TenantID
+		user.TenantID = *in.TenantID
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditUserUpdate, domain.AuditSuccess, "user", user.ID, details)
+	return user, nil
+}
+
+// SetDisabled disables or re-enables an account. Disabling ends every session
+// of the user at once.
+func (uc *AdminUserUseCase) SetDisabled(ctx context.Context, actor domain.Actor, id string, disabled bool) error {
+	if disabled && actor.ID == id {
+		return ErrSelfModification
+	}
+	user, err := uc.find(id)
+	if err != nil {
+		return err
+	}
+	if err := uc.users().SetDisabled(user.ID, disabled); err != nil {
+		return err
+	}
+	action := domain.AuditUserEnable
+	if disabled {
+		action = domain.AuditUserDisable
+		if err := uc.Sessions.RevokeAllSessions(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	uc.Audit.Record(ctx, actor, action, domain.AuditSuccess, "user", user.ID, nil)
+	return nil
+}
+
+// Delete removes the account, its assignments and its sessions.
+func (uc *AdminUserUseCase) Delete(ctx context.Context, actor domain.Actor, id string) error {
+	if actor.ID == id {
+		return ErrSelfModification
+	}
+	user, err := uc.find(id)
+	if err != nil {
+		return err
+	}
+	// Sessions first: a failure leaves an account that can't be used rather
+	// than tokens of a deleted one.
+	if err := uc.Sessions.RevokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.users().Delete(user.ID); err != nil {
+		return err
+	}
+	if err := uc.Perms.InvalidateUser(user.ID); err != nil {
+		return err
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditUserDelete, domain.AuditSuccess, "user", user.ID, map[string]string{"email": user.Email})
+	return nil
+}
+
+// ForcePasswordReset blocks sign-in until the user resets the password, ends
+// every session and emails a reset link.
+func (uc *AdminUserUseCase) ForcePasswordReset(ctx context.Context, actor domain.Actor, id string) error {
+	user, err := uc.find(id)
+	if err != nil {
+		return err
+	}
+	user.PasswordResetRequired = true
+	if err := uc.users().Update(user); err != nil {
+		return err
+	}
+	if err := uc.Sessions.RevokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditUserPasswordReset, domain.AuditSuccess, "user", user.ID, nil)
+
+	if uc.Resets != nil {
+		return uc.Resets.Send(ctx, user)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+	auditVerifyBatch     = 1000
+)
+
+// Auditor writes and reads the audit log. Use cases and handlers hold an
+// optional *Auditor: recording on a nil one does nothing.
+//
+// Appends are serialized across instances to keep the chain linear, so they
+// are slow under load. Start moves them to a background writer; until then, or
+// while its queue is full, Record writes inline.
+type Auditor struct {
+	Log domain.AuditLog
+	Key []byte // HMAC key of the hash chain, as given to Log
+	Now func() time.Time
+
+	mu      sync.RWMutex
+	queue   chan *domain.AuditEvent
+	written chan struct{} // closed once the writer has drained the queue
+}
+
+func NewAuditor(log domain.AuditLog, key []byte) *Auditor {
+	return &Auditor{Log: log, Key: key, Now: time.Now}
+}
+
+// Start hands appends to a background writer holding up to buffer events.
+func (a *Auditor) Start(buffer int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.queue != nil {
+		return
+	}
+	a.queue = make(chan *domain.AuditEvent, buffer)
+	a.written = make(chan struct{})
+	go func(queue <-chan *domain.AuditEvent, written chan<- struct{}) {
+		defer close(written)
+		for e := range queue {
+			a.append(context.Background(), e)
+		}
+	}(a.queue, a.written)
+}
+
+// Close stops the background writer, waiting until the queued events are
+// written or ctx is done. Later events are written inline.
+func (a *Auditor) Close(ctx context.Context) error {
+	a.mu.Lock()
+	queue, written := a.queue, a.written
+	a.queue = nil
+	a.mu.Unlock()
+	if queue == nil {
+		return nil
+	}
+	close(queue)
+	select {
+	case <-written:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("audit queue not drained: %w", ctx.Err())
+	}
+}
+
+// Record appends an event done by actor. A failure is logged but never fails
+// the operation being audited.
+func (a *Auditor) Record(ctx context.Context, actor domain.Actor, action, outcome, targetType, targetID string, details map[string]string) {
+	if a == nil {
+		return
+	}
+	e := &domain.AuditEvent{
+		Time:       a.Now(),
+		Action:     action,
+		Outcome:    outcome,
+		ActorType:  string(actor.Type),
+		ActorID:    actor.ID,
+		IP:         actor.IP,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+	}
+
+	a.mu.RLock()
+	select {
+	case a.queue <- e: // a nil queue never receives
+		a.mu.RUnlock()
+		return
+	default:
+	}
+	a.mu.RUnlock()
+	// The caller's request may be over (or cancelled) by now; the entry
+	// should land anyway.
+	a.append(context.WithoutCancel(ctx), e)
+}
+
+func (a *Auditor) append(ctx context.Context, e *domain.AuditEvent) {
+	if err := a.Log.Append(ctx, e); err != nil {
+		zap.L().Error("failed to write audit event", zap.String("action", e.Action), zap.Error(err))
+	}
+}
+
+// List returns a page of events, newest first, and the cursor of the next
+// page (0 on the last one).
+func (a *Auditor) List(ctx context.Context, f domain.AuditFilter) ([]domain.AuditEvent, int64, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultAuditPageSize
+	}
+	f.Limit = min(f.Limit, maxAuditPageSize)
+
+	events, err := a.Log.List(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	var next int64
+	if len(events) == f.Limit {
+		next = events[len(events)-1].Seq
+	}
+	return events, next, nil
+}
+
+// AuditVerification is the result of walking the hash chain.
+type AuditVerification struct {
+	Valid    bool  `json:"valid"`
+	Checked  int64 `json:"checked"`
+	BrokenAt int64 `json:"broken_at,omitempty"` // first event that doesn't chain
+}
+
+// Verify recomputes the hash chain from the first event.
+func (a *Auditor) Verify(ctx context.Context) (AuditVerification, error) {
+	var (
+		res  AuditVerification
+		seq  int64
+		prev string
+	)
+	for {
+		batch, err := a.Log.Range(ctx, seq, auditVerifyBatch)
+		if err != nil {
+			return AuditVerification{}, err
+		}
+		for _, e := range batch {
+			if e.Seq != seq+1 || e.PrevHash != prev || e.ComputeHash(a.Key) != e.Hash {
+				res.BrokenAt = e.Seq
+				return res, nil
+			}
+			res.Checked++
+			seq, prev = e.Seq, e.Hash
+		}
+		if len(batch) < auditVerifyBatch {
+			res.Valid = true
+			return res, nil
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+var testAuditKey = []byte("audit-key-audit-key-audit-key-01")
+
+// memAuditLog chains events the way the database log does.
+type memAuditLog struct {
+	mu     sync.Mutex
+	events []domain.AuditEvent
+}
+
+func (m *memAuditLog) Append(_ context.Context, e *domain.AuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.Seq = int64(len(m.events)) + 1
+	if len(m.events) > 0 {
+		e.PrevHash = m.events[len(m.events)-1].Hash
+	}
+	e.Hash = e.ComputeHash(testAuditKey)
+	m.events = append(m.events, *e)
+	return nil
+}
+
+func (m *memAuditLog) List(context.Context, domain.AuditFilter) ([]domain.AuditEvent, error) {
+	return m.events, nil
+}
+
+func (m *memAuditLog) Range(_ context.Context, after int64, limit int) ([]domain.AuditEvent, error) {
+	var out []domain.AuditEvent
+	for _, e := range m.events {
+		if e.Seq > after && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func TestAuditorVerify(t *testing.T) {
+	tests := []struct {
+		name         string
+		events       int
+		tamper       func(events []domain.AuditEvent) []domain.AuditEvent
+		wantValid    bool
+		wantChecked  int64
+		wantBrokenAt int64
+	}{
+		{name: "empty log", wantValid: true},
+		{name: "untouched", events: 5, wantValid: true, wantChecked: 5},
+		{name: "spans several batches", events: auditVerifyBatch + 3, wantValid: true, wantChecked: auditVerifyBatch + 3},
+		{name: "outcome edited", events: 5, tamper: func(ev []domain.AuditEvent) []domain.AuditEvent {
+			ev[2].Outcome = domain.AuditSuccess
+			return ev
+		}, wantChecked: 2, wantBrokenAt: 3},
+		{name: "details edited", events: 5, tamper: func(ev []domain.AuditEvent) []domain.AuditEvent {
+			ev[1].Details["reason"] = "nothing to see"
+			return ev
+		}, wantChecked: 1, wantBrokenAt: 2},
+		{name: "edited and rehashed without the key", events: 5, tamper: func(ev []domain.AuditEvent) []domain.AuditEvent {
+			ev[1].ActorID = "someone-else"
+			ev[1].Hash = ev[1].ComputeHash(nil)
+			return ev
+		}, wantChecked: 1, wantBrokenAt: 2},
+		{name: "edited and rehashed with the key", events: 5, tamper: func(ev []domain.AuditEvent) []domain.AuditEvent {
+			ev[1].ActorID = "someone-else"
+			ev[1].Hash = ev[1].ComputeHash(testAuditKey)
+			return ev
+		}, wantChecked: 2, wantBrokenAt: 3},
+		{name: "whole chain rehashed without the key", events: 5, tamper: func(ev []domain.AuditEvent) []domain.AuditEvent {
+			ev = ev[:3]
+			for i := range ev {
+				if i > 0 {
+					ev[i].PrevHash = ev[i-1].Hash
+				}
+				ev[i].Hash = ev[i].ComputeHash([]byte("guessed"))
+			}
+			return ev
+		}, wantChecked: 0, wantBrokenAt: 1},
+		{name: "entry deleted", events: 5, tamper: func(ev []domain.AuditEvent) []domain.AuditEvent {
+			return append(ev[:2], ev[3:]...)
+		}, wantChecked: 2, wantBrokenAt: 4},
+		{name: "entry deleted and renumbered", events: 5, tamper: func(ev []domain.AuditEvent) []domain.AuditEvent {
+			ev = append(ev[:2], ev[3:]...)
+			for i := range ev {
+				ev[i].Seq = int64(i) + 1
+			}
+			return ev
+		}, wantChecked: 2, wantBrokenAt: 3},
+		{name: "entries reordered", events: 5, tamper: func(ev []domain.AuditEvent) []domain.AuditEvent {
+			ev[1], ev[2] = ev[2], ev[1]
+			ev[1].Seq, ev[2].Seq = 2, 3
+			return ev
+		}, wantChecked: 1, wantBrokenAt: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &memAuditLog{}
+			a := NewAuditor(log, testAuditKey)
+			for i := range tt.events {
+				outcome := domain.AuditSuccess
+				if i%2 == 0 {
+					outcome = domain.AuditFailure
+				}
+				a.Record(context.Background(), domain.Actor{Type: domain.PrincipalUser, ID: "u1", IP: "10.0.0.1"},
+					domain.AuditLogin, outcome, "user", "u1", map[string]string{"reason": "bad_password"})
+			}
+			if tt.tamper != nil {
+				log.events = tt.tamper(log.events)
+			}
+
+			res, err := a.Verify(context.Background())
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			want := AuditVerification{Valid: tt.wantValid, Checked: tt.wantChecked, BrokenAt: tt.wantBrokenAt}
+			if res != want {
+				t.Errorf("Verify() = %+v, want %+v", res, want)
+			}
+		})
+	}
+}
+
+func TestAuditEventHashIgnoresTimeZone(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := domain.AuditEvent{Seq: 1, Time: at, Action: domain.AuditLogin, Outcome: domain.AuditSuccess}
+	local := e
+	local.Time = at.In(time.FixedZone("BRT", -3*60*60))
+	if e.ComputeHash(testAuditKey) != local.ComputeHash(testAuditKey) {
+		t.Error("the same instant hashes differently across time zones")
+	}
+}
+
+func TestAuditorBackgroundWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		buffer int
+	}{
+		{name: "queue holds every event", buffer: 100},
+		{name: "full queue falls back to inline writes", buffer: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &memAuditLog{}
+			a := NewAuditor(log, testAuditKey)
+			a.Start(tt.buffer)
+
+			var wg sync.WaitGroup
+			for i := range 50 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					a.Record(context.Background(), domain.Actor{Type: domain.PrincipalUser, ID: "u1"},
+						domain.AuditLogin, domain.AuditSuccess, "user", "u1", map[string]string{"n": strconv.Itoa(i)})
+				}()
+			}
+			wg.Wait()
+			if err := a.Close(context.Background()); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			// Recording after Close writes inline.
+			a.Record(context.Background(), domain.Actor{}, domain.AuditLogout, domain.AuditSuccess, "", "", nil)
+
+			res, err := a.Verify(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := (AuditVerification{Valid: true, Checked: 51}); res != want {
+				t.Errorf("Verify() = %+v, want %+v", res, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+// claimScopes are granted on request (if the client allows them) instead of being
+// checked against the user's permissions: they select claims. "openid" and
+// "email" are the OIDC ones (ID token / UserInfo); "roles" puts the user's roles
+// in the access token, which otherwise only carries the granted scopes, so a
+// client learns the roles of its users only when it was allowed to.
+var claimScopes = []string{"openid", "email", ScopeRoles}
+
+// ScopeRoles asks for the user's roles in access tokens of the code flow.
+const ScopeRoles = "roles"
+
+// pkceVerifier matches RFC 7636 code verifiers; S256 challenges have the same alphabet.
+var pkceVerifier = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// AuthorizationCodeUseCase implements the OAuth 2.0 authorization code grant
+// with mandatory PKCE (S256).
+type AuthorizationCodeUseCase struct {
+	Clients domain.ClientRepository
+	Codes   domain.AuthCodeStore
+	Users   domain.UserRepository
+	Perms   domain.PermissionRepository
+	CodeTTL time.Duration
+}
+
+func NewAuthorizationCodeUseCase(clients domain.ClientRepository, codes domain.AuthCodeStore, users domain.UserRepository, perms domain.PermissionRepository, codeTTL time.Duration) *AuthorizationCodeUseCase {
+	return &AuthorizationCodeUseCase{
+		Clients: clients,
+		Codes:   codes,
+		Users:   users,
+		Perms:   perms,
+		CodeTTL: codeTTL,
+	}
+}
+
+type AuthorizeInput struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string // space-delimited
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string // OIDC
+}
+
+type ExchangeInput struct {
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+}
+
+// ResolveClient checks the client and picks the redirect URI. Its errors must be
+// shown to the user and never redirected, since the redirect URI is not trusted.
+func (uc *AuthorizationCodeUseCase) ResolveClient(clientID, redirectURI string) (*domain.Client, string, error) {
+	c, err := uc.Clients.FindByClientID(clientID)
+	if err != nil || c == nil || !c.Active {
+		return nil, "", domain.NewOAuthError(domain.OAuthInvalidClient, "unknown client")
+	}
+	if redirectURI == "" {
+		if len(c.RedirectURIs) != 1 {
+			return nil, "", domain.NewOAuthError(domain.OAuthInvalidRequest, "redirect_uri is required")
+		}
+		return c, c.RedirectURIs[0], nil
+	}
+	for _, u := range c.RedirectURIs {
+		if u == redirectURI {
+			return c, redirectURI, nil
+		}
+	}
+	return nil, "", domain.NewOAuthError(domain.OAuthInvalidRequest, "redirect_uri is not registered for this client")
+}
+
+// ValidateRequest checks the remaining authorization parameters and returns the
+// scopes being requested. Its errors are redirected back to the client.
+func (uc *AuthorizationCodeUseCase) ValidateRequest(c *domain.Client, in AuthorizeInput) ([]string, error) {
+	if in.ResponseType != "code" {
+		return nil, domain.NewOAuthError(domain.OAuthUnsupportedResponseType, "only response_type=code is supported")
+	}
+	if in.CodeChallenge == "" {
+		return nil, domain.NewOAuthError(domain.OAuthInvalidRequest, "code_challenge is required")
+	}
+	if in.CodeChallengeMethod != domain.CodeChallengeS256 {
+		return nil, domain.NewOAuthError(domain.OAuthInvalidRequest, "code_challenge_method must be S256")
+	}
+	if !pkceVerifier.MatchString(in.CodeChallenge) {
+		return nil, domain.NewOAuthError(domain.OAuthInvalidRequest, "malformed code_challenge")
+	}
+
+	requested := strings.Fields(in.Scope)
+	if len(requested) == 0 {
+		return trimAll(c.AllowedScopes), nil
+	}
+	if !containsAll(trimAll(c.AllowedScopes), requested) {
+		return nil, domain.NewOAuthError(domain.OAuthInvalidScope, "scope not allowed for this client")
+	}
+	return unique(requested), nil
+}
+
+// Issue stores a single-use code for the user who approved the request. Users
+// only sign in to clients of their own tenant (ErrCrossTenant): a client of an
+// organization must never obtain a token of a platform user or of another
+// organization.
+func (uc *AuthorizationCodeUseCase) Issue(ctx context.Context, c *domain.Client, scopes []string, in AuthorizeInput, user *domain.User) (string, error) {
+	if user.TenantID != c.TenantID {
+		return "", domain.ErrCrossTenant
+	}
+	code, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.Codes.Save(ctx, code, domain.AuthorizationCode{
+		ClientID:      c.ClientID,
+		UserID:        user.ID,
+		Email:         user.Email,
+		TenantID:      user.TenantID,
+		RedirectURI:   in.RedirectURI, // as sent: the token request must repeat it only if it was present
+		Scopes:        scopes,
+		CodeChallenge: in.CodeChallenge,
+		Nonce:         in.Nonce,
+		AuthTime:      time.Now().UTC(),
+	}, uc.CodeTTL)
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// AuthenticateClient authenticates a client at the token endpoint for grants
+// that are bound to it, such as refreshing tokens issued through this flow.
+func (uc *AuthorizationCodeUseCase) AuthenticateClient(clientID, secret string) (*domain.Client, error) {
+	c, err := authenticateClient(uc.Clients, clientID, secret)
+	if err != nil {
+		return nil, domain.NewOAuthError(domain.OAuthInvalidClient, "client authentication failed")
+	}
+	return c, nil
+}
+
+// Exchange redeems a code at the token endpoint and returns the principal to
+// issue tokens for, along with the OIDC nonce of the authorization request.
+// The user must still be allowed to sign in: accounts deleted, disabled,
+// moved to another tenant or put under a forced password reset since the
+// approval get invalid_grant. Granted scopes are the approved ones the user
+// still holds, plus the approved claim scopes; roles only come with "roles".
+func (uc *AuthorizationCodeUseCase) Exchange(ctx context.Context, in ExchangeInput) (domain.Principal, string, error) {
+	c, err := authenticateClient(uc.Clients, in.ClientID, in.ClientSecret)
+	if err != nil {
+		return domain.Principal{}, "", domain.NewOAuthError(domain.OAuthInvalidClient, "client authentication failed")
+	}
+
+	ac, err := uc.Codes.Consume(ctx, in.Code)
+	if errors.Is(err, domain.ErrAuthCodeNotFound) {
+		return domain.Principal{}, "", domain.NewOAuthError(domain.OAuthInvalidGrant, "invalid, expired or already used code")
+	}
+	if err != nil {
+		return domain.Principal{}, "", err
+	}
+	if ac.ClientID != c.ClientID {
+		return domain.Principal{}, "", domain.NewOAuthError(domain.OAuthInvalidGrant, "code was issued to another client")
+	}
+	if ac.TenantID != c.TenantID {
+		return domain.Principal{}, "", domain.NewOAuthError(domain.OAuthInvalidGrant, "code was issued for another tenant")
+	}
+	if ac.RedirectURI != in.RedirectURI {
+		return domain.Principal{}, "", domain.NewOAuthError(domain.OAuthInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if !verifyPKCE(in.CodeVerifier, ac.CodeChallenge) {
+		return domain.Principal{}, "", domain.NewOAuthError(domain.OAuthInvalidGrant, "code_verifier does not match code_challenge")
+	}
+
+	user, err := uc.Users.FindByID(ac.UserID)
+	if err != nil {
+		return domain.Principal{}, "", err
+	}
+	if user == nil || user.TenantID != ac.TenantID || signInAllowed(user) != nil {
+		return domain.Principal{}, "", domain.NewOAuthError(domain.OAuthInvalidGrant, "the user can no longer sign in")
+	}
+
+	roles, scopes, err := uc.Perms.ListUserScopesEffective(user.ID, time.Now())
+	if err != nil {
+		return domain.Principal{}, "", err
+	}
+	granted := append(intersect(ac.Scopes, scopes), intersect(ac.Scopes, claimScopes)...)
+	if !slices.Contains(ac.Scopes, ScopeRoles) {
+		roles = nil
+	}
+
+	return domain.Principal{
+		Type:     domain.PrincipalUser,
+		ID:       user.ID,
+		Email:    user.Email,
+		Roles:    roles,
+		Scopes:   unique(granted),
+		ClientID: c.ClientID,
+		Audience: trimAll(c.AllowedAudience),
+		TenantID: ac.TenantID,
+		AuthTime: ac.AuthTime,
+	}, ac.Nonce, nil
+}
+
+// verifyPKCE checks BASE64URL(SHA256(verifier)) == challenge (RFC 7636 section 4.6).
+func verifyPKCE(verifier, challenge string) bool {
+	if !pkceVerifier.MatchString(verifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
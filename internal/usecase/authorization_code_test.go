@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+type memAuthCodes map[string]domain.AuthorizationCode
+
+func (m memAuthCodes) Save(_ context.Context, code string, ac domain.AuthorizationCode, _ time.Duration) error {
+	m[code] = ac
+	return nil
+}
+
+func (m memAuthCodes) Consume(_ context.Context, code string) (*domain.AuthorizationCode, error) {
+	ac, ok := m[code]
+	if !ok {
+		return nil, domain.ErrAuthCodeNotFound
+	}
+	delete(m, code)
+	return &ac, nil
+}
+
+type stubClients struct {
+	domain.ClientRepository
+	clients map[string]*domain.Client
+}
+
+func (s stubClients) FindByClientID(clientID string) (*domain.Client, error) {
+	return s.clients[clientID], nil
+}
+
+type stubPerms struct {
+	domain.PermissionRepository
+	roles, scopes map[string][]string // by user ID, scopes also by client_id
+}
+
+func (s stubPerms) ListUserScopesEffective(userID string, _ time.Time) ([]string, []string, error) {
+	return s.roles[userID], s.scopes[userID], nil
+}
+
+func (s stubPerms) ListClientScopes(clientID string) ([]string, error) {
+	return s.scopes[clientID], nil
+}
+
+const testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func testChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func newTestAuthCodeUseCase() (*AuthorizationCodeUseCase, memAuthCodes) {
+	codes := memAuthCodes{}
+	uc := NewAuthorizationCodeUseCase(stubClients{clients: map[string]*domain.Client{
+		"platform-app": {ClientID: "platform-app", Active: true, Public: true, RedirectURIs: []string{"https://app/cb"}},
+		"org-app":      {ClientID: "org-app", TenantID: "org-1", Active: true, Public: true, RedirectURIs: []string{"https://org/cb"}},
+	}}, codes, stubUsers{users: map[string]*domain.User{
+		"admin":  {ID: "admin", Email: "admin@example.com"},
+		"member": {ID: "member", Email: "member@example.com", TenantID: "org-1"},
+	}}, stubPerms{
+		roles:  map[string][]string{"admin": {"admin"}, "member": {"editor"}},
+		scopes: map[string][]string{"admin": {"users:write"}, "member": {"docs:read"}},
+	}, time.Minute)
+	return uc, codes
+}
+
+func TestAuthorizationCodeTenants(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  string
+		user    domain.User
+		wantErr error
+	}{
+		{name: "platform user, platform client", client: "platform-app", user: domain.User{ID: "admin"}},
+		{name: "member, client of their organization", client: "org-app", user: domain.User{ID: "member", TenantID: "org-1"}},
+		{name: "platform user, organization client", client: "org-app", user: domain.User{ID: "admin"}, wantErr: domain.ErrCrossTenant},
+		{name: "member, platform client", client: "platform-app", user: domain.User{ID: "member", TenantID: "org-1"}, wantErr: domain.ErrCrossTenant},
+		{name: "member, client of another organization", client: "org-app", user: domain.User{ID: "member", TenantID: "org-2"}, wantErr: domain.ErrCrossTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _ := newTestAuthCodeUseCase()
+			c, _ := uc.Clients.FindByClientID(tt.client)
+			code, err := uc.Issue(context.Background(), c, nil, AuthorizeInput{CodeChallenge: testChallenge(testVerifier)}, &tt.user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Issue() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			p, _, err := uc.Exchange(context.Background(), ExchangeInput{ClientID: tt.client, Code: code, CodeVerifier: testVerifier})
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+			if p.TenantID != tt.user.TenantID {
+				t.Errorf("TenantID = %q, want %q", p.TenantID, tt.user.TenantID)
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeExchangeRejectsForeignTenantCode(t *testing.T) {
+	uc, codes := newTestAuthCodeUseCase()
+	// A code stored for a platform user that ends up at an organization's client.
+	codes["stolen"] = domain.AuthorizationCode{
+		ClientID:      "org-app",
+		UserID:        "admin",
+		CodeChallenge: testChallenge(testVerifier),
+	}
+	_, _, err := uc.Exchange(context.Background(), ExchangeInput{ClientID: "org-app", Code: "stolen", CodeVerifier: testVerifier})
+	var oe *domain.OAuthError
+	if !errors.As(err, &oe) || oe.Code != domain.OAuthInvalidGrant {
+		t.Fatalf("Exchange() error = %v, want invalid_grant", err)
+	}
+}
+
+func TestAuthorizationCodeExchange(t *testing.T) {
+	challenge := testChallenge(testVerifier)
+	approved := domain.AuthorizationCode{
+		ClientID:      "platform-app",
+		UserID:        "u1",
+		Email:         "old@example.com",
+		RedirectURI:   "https://app/cb",
+		Scopes:        []string{"docs:read", "docs:write", "openid"},
+		CodeChallenge: challenge,
+		Nonce:         "n-1",
+	}
+	in := ExchangeInput{ClientID: "platform-app", Code: "code", RedirectURI: "https://app/cb", CodeVerifier: testVerifier}
+	user := domain.User{ID: "u1", Email: "new@example.com"}
+
+	tests := []struct {
+		name       string
+		code       func(ac *domain.AuthorizationCode)
+		in         func(in *ExchangeInput)
+		user       func(u *domain.User) // changes since the approval
+		deleted    bool
+		wantErr    string // OAuth error code
+		wantScopes []string
+		wantRoles  []string
+	}{
+		{name: "valid", wantScopes: []string{"docs:read", "openid"}},
+		{name: "roles scope approved", code: func(ac *domain.AuthorizationCode) { ac.Scopes = append(ac.Scopes, ScopeRoles) },
+			wantScopes: []string{"docs:read", "openid", ScopeRoles}, wantRoles: []string{"editor"}},
+		{name: "wrong code_verifier", in: func(in *ExchangeInput) { in.CodeVerifier = "x" + testVerifier[1:] }, wantErr: domain.OAuthInvalidGrant},
+		{name: "malformed code_verifier", in: func(in *ExchangeInput) { in.CodeVerifier = "short" }, wantErr: domain.OAuthInvalidGrant},
+		{name: "missing code_verifier", in: func(in *ExchangeInput) { in.CodeVerifier = "" }, wantErr: domain.OAuthInvalidGrant},
+		{name: "challenge used as verifier", in: func(in *ExchangeInput) { in.CodeVerifier = challenge }, wantErr: domain.OAuthInvalidGrant},
+		{name: "other redirect_uri", in: func(in *ExchangeInput) { in.RedirectURI = "https://app/other" }, wantErr: domain.OAuthInvalidGrant},
+		{name: "code of another client", code: func(ac *domain.AuthorizationCode) { ac.ClientID = "org-app" }, wantErr: domain.OAuthInvalidGrant},
+		{name: "unknown code", in: func(in *ExchangeInput) { in.Code = "nope" }, wantErr: domain.OAuthInvalidGrant},
+		{name: "unknown client", in: func(in *ExchangeInput) { in.ClientID = "nope" }, wantErr: domain.OAuthInvalidClient},
+		{name: "user deleted", deleted: true, wantErr: domain.OAuthInvalidGrant},
+		{name: "user disabled", user: func(u *domain.User) { u.Disabled = true }, wantErr: domain.OAuthInvalidGrant},
+		{name: "password reset forced", user: func(u *domain.User) { u.PasswordResetRequired = true }, wantErr: domain.OAuthInvalidGrant},
+		{name: "user moved to an organization", user: func(u *domain.User) { u.TenantID = "org-1" }, wantErr: domain.OAuthInvalidGrant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, codes := newTestAuthCodeUseCase()
+			ac := approved
+			ac.Scopes = slices.Clone(approved.Scopes)
+			if tt.code != nil {
+				tt.code(&ac)
+			}
+			codes["code"] = ac
+			u := user
+			if tt.user != nil {
+				tt.user(&u)
+			}
+			users := map[string]*domain.User{}
+			if !tt.deleted {
+				users[u.ID] = &u
+			}
+			uc.Users = stubUsers{users: users}
+			uc.Perms = stubPerms{
+				roles:  map[string][]string{"u1": {"editor"}},
+				scopes: map[string][]string{"u1": {"docs:read"}}, // docs:write revoked since the approval
+			}
+			req := in
+			if tt.in != nil {
+				tt.in(&req)
+			}
+
+			p, nonce, err := uc.Exchange(context.Background(), req)
+			if tt.wantErr != "" {
+				var oe *domain.OAuthError
+				if !errors.As(err, &oe) || oe.Code != tt.wantErr {
+					t.Fatalf("Exchange() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+			slices.Sort(p.Scopes)
+			if !slices.Equal(p.Scopes, tt.wantScopes) {
+				t.Errorf("Scopes = %v, want %v", p.Scopes, tt.wantScopes)
+			}
+			if !slices.Equal(p.Roles, tt.wantRoles) {
+				t.Errorf("Roles = %v, want %v", p.Roles, tt.wantRoles)
+			}
+			if p.Email != "new@example.com" || p.ClientID != "platform-app" || nonce != "n-1" {
+				t.Errorf("principal = %+v, nonce = %q", p, nonce)
+			}
+			if _, ok := codes["code"]; ok {
+				t.Error("code not consumed")
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeSingleUse(t *testing.T) {
+	uc, _ := newTestAuthCodeUseCase()
+	c, _ := uc.Clients.FindByClientID("platform-app")
+	code, err := uc.Issue(context.Background(), c, nil, AuthorizeInput{CodeChallenge: testChallenge(testVerifier)}, &domain.User{ID: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := ExchangeInput{ClientID: "platform-app", Code: code, CodeVerifier: testVerifier}
+	if _, _, err := uc.Exchange(context.Background(), in); err != nil {
+		t.Fatalf("first Exchange() error = %v", err)
+	}
+	var oe *domain.OAuthError
+	if _, _, err := uc.Exchange(context.Background(), in); !errors.As(err, &oe) || oe.Code != domain.OAuthInvalidGrant {
+		t.Fatalf("second Exchange() error = %v, want invalid_grant", err)
+	}
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+// ErrSubjectRefForbidden is returned when a user names a subject by
+// reference; only services (resource servers) may ask about principals
+// whose token they don't hold.
+var ErrSubjectRefForbidden = errors.New("only services can name subjects by reference")
+
+// SubjectRef names a principal without a token of theirs: a user by ID, or
+// a service by client_id.
+type SubjectRef struct {
+	Type domain.PrincipalType
+	ID   string
+}
+
+// AuthzItem is one (action, resource) question of a batch.
+type AuthzItem struct {
+	Action   string
+	Resource map[string]string
+}
+
+// AuthzUseCase decides policy questions, one at a time or in batches about
+// one principal for resource servers filtering lists. It is the service's
+// domain.PolicyEngine.
+type AuthzUseCase struct {
+	Policies domain.PolicyRepository
+	Tokens   domain.TokenService
+	Users    domain.UserRepository
+	Clients  domain.ClientRepository
+	Perms    domain.PermissionRepository
+	Now      func() time.Time
+}
+
+func NewAuthzUseCase(policies domain.PolicyRepository, tokens domain.TokenService, users domain.UserRepository, clients domain.ClientRepository, perms domain.PermissionRepository) *AuthzUseCase {
+	return &AuthzUseCase{
+		Policies: policies,
+		Tokens:   tokens,
+		Users:    users,
+		Clients:  clients,
+		Perms:    perms,
+		Now:      time.Now,
+	}
+}
+
+// Subject resolves the principal a batch is about: the bearer of token, the
+// principal ref names, or the caller itself when both are empty. Its roles
+// and scopes are those it holds now, so grants revoked since a token was
+// issued no longer count.
+//
+// ok is false when there is no such principal to decide for: an inactive
+// token, an unknown, disabled or deactivated principal, or one of another
+// tenant than the caller's organization.
+func (uc *AuthzUseCase) Subject(caller domain.Principal, token string, ref *SubjectRef) (p domain.Principal, ok bool, err error) {
+	switch {
+	case token != "":
+		var active bool
+		var claims *domain.TokenClaims
+		if active, claims, err = uc.Tokens.Introspect(token); err != nil || !active || claims == nil {
+			return domain.Principal{}, false, err
+		}
+		p, ok, err = uc.current(claims.Principal())
+	case ref != nil:
+		if caller.Type != domain.PrincipalService {
+			return domain.Principal{}, false, ErrSubjectRefForbidden
+		}
+		p, ok, err = uc.lookup(*ref)
+	default:
+		p, ok, err = uc.current(caller)
+	}
+	if err != nil || !ok {
+		return domain.Principal{}, false, err
+	}
+	if caller.TenantID != domain.PlatformTenant && p.TenantID != caller.TenantID {
+		return domain.Principal{}, false, nil
+	}
+	return p, true, nil
+}
+
+// Decide evaluates every item for subject against the policies of its
+// tenant, loaded once. Decisions are in the order of items.
+func (uc *AuthzUseCase) Decide(ctx context.Context, subject domain.Principal, ip string, items []AuthzItem) ([]domain.Decision, error) {
+	policies, err := uc.Policies.ForTenant(subject.TenantID).List()
+	if err != nil {
+		return nil, err
+	}
+	attrs := principalAttributes(subject)
+	now := uc.Now()
+
+	out := make([]domain.Decision, len(items))
+	for i, it := range items {
+		d, err := decide(policies, attrs, domain.AccessRequest{
+			Subject:  subject,
+			Action:   it.Action,
+			Resource: it.Resource,
+			IP:       ip,
+			Time:     now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out[i] = d
+	}
+	return out, nil
+}
+
+// Evaluate implements domain.PolicyEngine with the rule of Subject: the
+// roles and scopes of req.Subject are narrowed to those it still holds, and
+// a principal that no longer resolves is denied. Deny policies win over allow
+// policies, and nothing is allowed without a matching allow policy.
+func (uc *AuthzUseCase) Evaluate(ctx context.Context, req domain.AccessRequest) (domain.Decision, error) {
+	subject, ok, err := uc.current(req.Subject)
+	if err != nil || !ok {
+		return domain.Decision{}, err
+	}
+	policies, err := uc.Policies.ForTenant(subject.TenantID).List()
+	if err != nil {
+		return domain.Decision{}, err
+	}
+	req.Subject = subject
+	if req.Time.IsZero() {
+		req.Time = uc.Now()
+	}
+	return decide(policies, principalAttributes(subject), req)
+}
+
+// current narrows the roles and scopes of a token's principal to those it
+// still holds. Tokens never gain grants here: they may have been issued for
+// fewer scopes than the principal has.
+func (uc *AuthzUseCase) current(p domain.Principal) (domain.Principal, bool, error) {
+	switch p.Type {
+	case domain.PrincipalUser:
+		u, err := uc.Users.FindByID(p.ID)
+		if err != nil || u == nil || u.Disabled {
+			return domain.Principal{}, false, err
+		}
+		roles, scopes, err := uc.Perms.ListUserScopesEffective(p.ID, uc.Now())
+		if err != nil {
+			return domain.Principal{}, false, err
+		}
+		p.Roles, p.Scopes = intersect(p.Roles, roles), intersect(p.Scopes, scopes)
+	case domain.PrincipalService:
+		c, err := uc.Clients.FindByClientID(p.ClientID)
+		if err != nil || c == nil || !c.Active {
+			return domain.Principal{}, false, err
+		}
+		scopes, err := uc.Perms.ListClientScopes(p.ClientID)
+		if err != nil {
+			return domain.Principal{}, false, err
+		}
+		p.Scopes = intersect(p.Scopes, scopes)
+	default:
+		return domain.Principal{}, false, nil
+	}
+	return p, true, nil
+}
+
+// lookup builds the principal ref names with everything it holds, as if it
+// had just signed in or run the client_credentials grant.
+func (uc *AuthzUseCase) lookup(ref SubjectRef) (domain.Principal, bool, error) {
+	switch ref.Type {
+	case domain.PrincipalUser:
+		u, err := uc.Users.FindByID(ref.ID)
+		if err != nil || u == nil || u.Disabled {
+			return domain.Principal{}, false, err
+		}
+		roles, scopes, err := uc.Perms.ListUserScopesEffective(u.ID, uc.Now())
+		if err != nil {
+			return domain.Principal{}, false, err
+		}
+		return domain.Principal{
+			Type:     domain.PrincipalUser,
+			ID:       u.ID,
+			Email:    u.Email,
+			Roles:    roles,
+			Scopes:   scopes,
+			TenantID: u.TenantID,
+		}, true, nil
+	case domain.PrincipalService:
+		c, err := uc.Clients.FindByClientID(ref.ID)
+		if err != nil || c == nil || !c.Active {
+			return domain.Principal{}, false, err
+		}
+		scopes, err := uc.Perms.ListClientScopes(c.ClientID)
+		if err != nil {
+			return domain.Principal{}, false, err
+		}
+		return domain.Principal{
+			Type:     domain.PrincipalService,
+			ID:       c.ID,
+			ClientID: c.ClientID,
+			Scopes:   unique(intersect(trimAll(scopes), trimAll(c.AllowedScopes))),
+			Audience: trimAll(c.AllowedAudience),
+			TenantID: c.TenantID,
+		}, true, nil
+	}
+	return domain.Principal{}, false, nil
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+type stubTokens struct {
+	domain.TokenService
+	active map[string]*domain.TokenClaims
+}
+
+func (s stubTokens) Introspect(token string) (bool, *domain.TokenClaims, error) {
+	c, ok := s.active[token]
+	return ok, c, nil
+}
+
+// newTestAuthz serves u1, an editor who was an admin when their token was
+// issued.
+func newTestAuthz(t *testing.T) *AuthzUseCase {
+	t.Helper()
+	repo := newMemPolicies()
+	policies := NewPolicyUseCase(repo)
+	for _, p := range []domain.Policy{
+		{Name: "own", Effect: domain.PolicyAllow, Actions: []string{"docs:edit"},
+			Subject: map[string][]string{"roles": {"editor"}}, Resource: map[string][]string{"owner": {"$subject.id"}}},
+		{Name: "admins", Effect: domain.PolicyAllow, Actions: []string{"*"}, Subject: map[string][]string{"roles": {"admin"}}},
+		{Name: "reporting", Effect: domain.PolicyAllow, Actions: []string{"reports:read"}, Subject: map[string][]string{"scopes": {"svc:a"}}},
+	} {
+		if _, err := policies.Create(context.Background(), domain.Actor{}, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewAuthzUseCase(repo, stubTokens{active: map[string]*domain.TokenClaims{
+		"stale": {SubjectType: domain.PrincipalUser, SubjectID: "u1", Roles: []string{"admin", "editor"}, Scopes: []string{"docs:read", "docs:purge"}},
+		"org":   {SubjectType: domain.PrincipalUser, SubjectID: "u3", TenantID: "org-1"},
+	}}, stubUsers{users: map[string]*domain.User{
+		"u1": {ID: "u1", Email: "u1@example.com"},
+		"u2": {ID: "u2", Disabled: true},
+		"u3": {ID: "u3", TenantID: "org-1"},
+	}}, stubClients{clients: map[string]*domain.Client{
+		"svc": {ID: "c1", ClientID: "svc", Active: true, AllowedScopes: []string{"svc:a"}, AllowedAudience: []string{"api"}},
+		"off": {ID: "c2", ClientID: "off"},
+	}}, stubPerms{
+		roles:  map[string][]string{"u1": {"editor"}},
+		scopes: map[string][]string{"u1": {"docs:read"}, "svc": {"svc:a", "svc:b"}},
+	})
+}
+
+func TestAuthzSubject(t *testing.T) {
+	service := domain.Principal{Type: domain.PrincipalService, ID: "c1", ClientID: "svc", Scopes: []string{"svc:a"}}
+	tests := []struct {
+		name       string
+		caller     domain.Principal
+		token      string
+		ref        *SubjectRef
+		wantOK     bool
+		wantErr    error
+		wantID     string
+		wantRoles  []string
+		wantScopes []string
+	}{
+		{name: "token narrowed to current grants", caller: service, token: "stale",
+			wantOK: true, wantID: "u1", wantRoles: []string{"editor"}, wantScopes: []string{"docs:read"}},
+		{name: "inactive token", caller: service, token: "expired"},
+		{name: "user by reference", caller: service, ref: &SubjectRef{Type: domain.PrincipalUser, ID: "u1"},
+			wantOK: true, wantID: "u1", wantRoles: []string{"editor"}, wantScopes: []string{"docs:read"}},
+		{name: "service by reference", caller: service, ref: &SubjectRef{Type: domain.PrincipalService, ID: "svc"},
+			wantOK: true, wantID: "c1", wantScopes: []string{"svc:a"}},
+		{name: "disabled user", caller: service, ref: &SubjectRef{Type: domain.PrincipalUser, ID: "u2"}},
+		{name: "unknown user", caller: service, ref: &SubjectRef{Type: domain.PrincipalUser, ID: "nobody"}},
+		{name: "deactivated service", caller: service, ref: &SubjectRef{Type: domain.PrincipalService, ID: "off"}},
+		{name: "user naming a subject", caller: domain.Principal{Type: domain.PrincipalUser, ID: "u1"},
+			ref: &SubjectRef{Type: domain.PrincipalUser, ID: "u1"}, wantErr: ErrSubjectRefForbidden},
+		{name: "token of the caller's organization", caller: domain.Principal{Type: domain.PrincipalService, ClientID: "svc", TenantID: "org-1"}, token: "org",
+			wantOK: true, wantID: "u3"},
+		{name: "token of another organization", caller: domain.Principal{Type: domain.PrincipalService, ClientID: "svc", TenantID: "org-2"}, token: "org"},
+		{name: "platform token asked by an organization", caller: domain.Principal{Type: domain.PrincipalService, ClientID: "svc", TenantID: "org-1"}, token: "stale"},
+		{name: "caller itself", caller: domain.Principal{Type: domain.PrincipalUser, ID: "u1", Roles: []string{"admin", "editor"}},
+			wantOK: true, wantID: "u1", wantRoles: []string{"editor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok, err := newTestAuthz(t).Subject(tt.caller, tt.token, tt.ref)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Subject() error = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("Subject() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if p.ID != tt.wantID || !slices.Equal(p.Roles, tt.wantRoles) || !slices.Equal(p.Scopes, tt.wantScopes) {
+				t.Errorf("Subject() = %+v, want ID %q, roles %v, scopes %v", p, tt.wantID, tt.wantRoles, tt.wantScopes)
+			}
+		})
+	}
+}
+
+// TestAuthzCheckAndBatchAgree asks /authz/check's question (Evaluate on the
+// caller of a token) and /authz/batch's (Subject, then Decide) about the same
+// principals: both must judge them on what they hold now.
+func TestAuthzCheckAndBatchAgree(t *testing.T) {
+	items := []AuthzItem{
+		{Action: "docs:edit", Resource: map[string]string{"owner": "u1"}},
+		{Action: "docs:edit", Resource: map[string]string{"owner": "u2"}},
+		{Action: "docs:delete", Resource: map[string]string{"owner": "u1"}}, // admins only
+		{Action: "reports:read"},
+	}
+	tests := []struct {
+		name      string
+		principal domain.Principal
+		want      []bool
+	}{
+		{name: "editor whose admin role was revoked",
+			principal: domain.Principal{Type: domain.PrincipalUser, ID: "u1", Roles: []string{"admin", "editor"}},
+			want:      []bool{true, false, false, false}},
+		{name: "disabled admin",
+			principal: domain.Principal{Type: domain.PrincipalUser, ID: "u2", Roles: []string{"admin"}},
+			want:      []bool{false, false, false, false}},
+		{name: "deleted admin",
+			principal: domain.Principal{Type: domain.PrincipalUser, ID: "gone", Roles: []string{"admin"}},
+			want:      []bool{false, false, false, false}},
+		{name: "service",
+			principal: domain.Principal{Type: domain.PrincipalService, ID: "c1", ClientID: "svc", Scopes: []string{"svc:a"}},
+			want:      []bool{false, false, false, true}},
+		{name: "deactivated service",
+			principal: domain.Principal{Type: domain.PrincipalService, ID: "c2", ClientID: "off", Scopes: []string{"svc:a"}},
+			want:      []bool{false, false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := newTestAuthz(t)
+			ctx := context.Background()
+
+			batch := make([]bool, len(items))
+			subject, ok, err := uc.Subject(tt.principal, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				decisions, err := uc.Decide(ctx, subject, "", items)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i, d := range decisions {
+					batch[i] = d.Allowed
+				}
+			}
+
+			for i, it := range items {
+				d, err := uc.Evaluate(ctx, domain.AccessRequest{Subject: tt.principal, Action: it.Action, Resource: it.Resource})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d.Allowed != tt.want[i] || batch[i] != tt.want[i] {
+					t.Errorf("%s: check allowed = %v, batch allowed = %v, want %v", it.Action, d.Allowed, batch[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
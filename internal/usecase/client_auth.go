@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errInvalidClient = errors.New("invalid client")
+
+// authenticateClient resolves an active client and checks its secret. Public
+// clients have no secret, so they authenticate by client_id alone.
+func authenticateClient(repo domain.ClientRepository, clientID, secret string) (*domain.Client, error) {
+	c, err := repo.FindByClientID(clientID)
+	if err != nil || c == nil || !c.Active {
+		return nil, errInvalidClient
+	}
+	if c.Public {
+		return c, nil
+	}
+	if !clientSecretMatches(c, secret, time.Now()) {
+		return nil, errInvalidClient
+	}
+	return c, nil
+}
+
+// clientSecretMatches checks secret against the current secret and, during
+// the grace period of a rotation, the previous one.
+func clientSecretMatches(c *domain.Client, secret string, now time.Time) bool {
+	if bcrypt.CompareHashAndPassword([]byte(c.SecretHash), []byte(secret)) == nil {
+		return true
+	}
+	if c.PreviousSecretHash == "" || c.PreviousSecretExpiresAt == nil || !now.Before(*c.PreviousSecretExpiresAt) {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(c.PreviousSecretHash), []byte(secret)) == nil
+}
+
+// ClientAuthUseCase authenticates clients calling the token management
+// endpoints (introspection, revocation).
+type ClientAuthUseCase struct {
+	Repo domain.ClientRepository
+}
+
+func NewClientAuthUseCase(repo domain.ClientRepository) *ClientAuthUseCase {
+	return &ClientAuthUseCase{Repo: repo}
+}
+
+// Authenticate checks the credentials of a client. Public clients pass with
+// their client_id alone; callers that need a confidential client check Public.
+func (uc *ClientAuthUseCase) Authenticate(clientID, secret string) (*domain.Client, error) {
+	if clientID == "" {
+		return nil, domain.NewOAuthError(domain.OAuthInvalidClient, "client authentication is required")
+	}
+	c, err := authenticateClient(uc.Repo, clientID, secret)
+	if err != nil {
+		return nil, domain.NewOAuthError(domain.OAuthInvalidClient, "client authentication failed")
+	}
+	return c, nil
+}
@@ -1,11 +1,9 @@
 package usecase
 
 import (
-	"errors"
 	"strings"
 
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type ClientCredentialsUseCase struct {
@@ -19,34 +17,51 @@ func NewClientCredentialsUseCase(repo domain.ClientRepository) *ClientCredential
 type ClientCredentialsInput struct {
 	ClientID string
 	Secret   string
-	Scopes   []string
+	Scopes   []string // scopes the client holds in the permission store
 	Audience []string
+
+	// RequestedScopes narrows the token to a subset of the granted scopes
+	// (the scope parameter). Empty means every granted scope.
+	RequestedScopes []string
 }
 
+// Execute authenticates a confidential client and resolves the principal of a
+// client_credentials token. Failures are *domain.OAuthError.
 func (uc *ClientCredentialsUseCase) Execute(in ClientCredentialsInput) (domain.Principal, error) {
-	c, err := uc.Repo.FindByClientID(in.ClientID)
-	if err != nil || c == nil || !c.Active {
-		return domain.Principal{}, errors.New("invalid client")
+	c, err := authenticateClient(uc.Repo, in.ClientID, in.Secret)
+	if err != nil {
+		return domain.Principal{}, domain.NewOAuthError(domain.OAuthInvalidClient, "client authentication failed")
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(c.SecretHash), []byte(in.Secret)); err != nil {
-		return domain.Principal{}, errors.New("invalid client")
+	if c.Public {
+		return domain.Principal{}, domain.NewOAuthError(domain.OAuthUnauthorizedClient, "public clients cannot use the client_credentials grant")
 	}
 
 	allowedScopes := trimAll(c.AllowedScopes)
 	allowedAud := trimAll(c.AllowedAudience)
 
-	if len(in.Audience) == 0 || !containsOne(allowedAud, trimAll(in.Audience)) {
-		return domain.Principal{}, errors.New("invalid audience")
+	audience := trimAll(in.Audience)
+	if len(audience) == 0 {
+		audience = allowedAud
+	}
+	if len(audience) == 0 || !containsOne(allowedAud, audience) {
+		return domain.Principal{}, domain.NewOAuthError(domain.OAuthInvalidTarget, "audience is not allowed for this client")
 	}
 
 	effScopes := unique(intersect(trimAll(in.Scopes), allowedScopes))
+	if requested := unique(in.RequestedScopes); len(requested) > 0 {
+		if !containsAll(effScopes, requested) {
+			return domain.Principal{}, domain.NewOAuthError(domain.OAuthInvalidScope, "requested scope exceeds the scopes granted to the client")
+		}
+		effScopes = requested
+	}
 
 	return domain.Principal{
 		Type:     domain.PrincipalService,
 		ID:       c.ID,
 		ClientID: c.ClientID,
 		Scopes:   effScopes,
-		Audience: trimAll(in.Audience),
+		Audience: audience,
+		TenantID: c.TenantID,
 	}, nil
 }
 
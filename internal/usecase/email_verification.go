@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"go.uber.org/zap"
+)
+
+type EmailVerificationUseCase struct {
+	UserRepo domain.UserRepository
+	Tokens   domain.ActionTokenService
+	Mailer   domain.Mailer
+	TTL      time.Duration
+
+	// LinkURL is the page that receives ?token=... and posts it to
+	// /auth/verify-email. Empty sends the bare token.
+	LinkURL string
+}
+
+func NewEmailVerificationUseCase(userRepo domain.UserRepository, tokens domain.ActionTokenService, mailer domain.Mailer, ttl time.Duration, linkURL string) *EmailVerificationUseCase {
+	return &EmailVerificationUseCase{
+		UserRepo: userRepo,
+		Tokens:   tokens,
+		Mailer:   mailer,
+		TTL:      ttl,
+		LinkURL:  linkURL,
+	}
+}
+
+// Send emails a verification token to the user. Verified users are skipped.
+func (uc *EmailVerificationUseCase) Send(ctx context.Context, user *domain.User) error {
+	if user.Verified {
+		return nil
+	}
+
+	token, err := uc.Tokens.IssueActionToken(domain.PurposeEmailVerification, user.ID, user.Email, uc.TTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Use this code to verify your email address:\n\n%s\n\nIt expires in %s.", token, uc.TTL)
+	if uc.LinkURL != "" {
+		link := uc.LinkURL + "?token=" + url.QueryEscape(token)
+		body = fmt.Sprintf("Open this link to verify your email address:\n\n%s\n\nIt expires in %s.", link, uc.TTL)
+	}
+
+	return uc.Mailer.Send(ctx, domain.Email{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body:    body,
+	})
+}
+
+// Resend sends a new token to the account registered with email, if any and
+// not yet verified. Like PasswordResetUseCase.Forgot it works in the
+// background, so callers can't probe addresses; failures are only logged.
+func (uc *EmailVerificationUseCase) Resend(ctx context.Context, email string) {
+	go func() {
+		if err := uc.resend(context.WithoutCancel(ctx), email); err != nil {
+			zap.L().Error("failed to resend verification email", zap.Error(err))
+		}
+	}()
+}
+
+func (uc *EmailVerificationUseCase) resend(ctx context.Context, email string) error {
+	user, err := uc.UserRepo.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	return uc.Send(ctx, user)
+}
+
+// Verify marks the user of a verification token as verified. The token only
+// counts for the address it was sent to.
+func (uc *EmailVerificationUseCase) Verify(ctx context.Context, token string) (*domain.User, error) {
+	claims, err := uc.Tokens.VerifyActionToken(domain.PurposeEmailVerification, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.UserRepo.FindByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Email != claims.Email {
+		return nil, domain.ErrInvalidActionToken
+	}
+	if user.Verified {
+		return user, nil
+	}
+
+	user.Verified = true
+	if err := uc.UserRepo.Update(user); err != nil {
+		return nil, errors.New("error updating user")
+	}
+	return user, nil
+}
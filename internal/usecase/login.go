@@ -1,34 +1,107 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type LoginUseCase struct {
 	UserRepo domain.UserRepository
+
+	// RequireVerified rejects users whose email is not verified yet.
+	RequireVerified bool
+
+	// Guard, when set, throttles failed logins.
+	Guard *LoginGuard
+
+	// Audit, when set, records every attempt.
+	Audit *Auditor
 }
 
-func NewLoginUseCase(userRepo domain.UserRepository) *LoginUseCase {
+func NewLoginUseCase(userRepo domain.UserRepository, requireVerified bool) *LoginUseCase {
 	return &LoginUseCase{
-		UserRepo: userRepo,
+		UserRepo:        userRepo,
+		RequireVerified: requireVerified,
 	}
 }
 
-func (uc *LoginUseCase) Execute(email string, password string) (*domain.User, error) {
+// Execute checks the credentials of a login attempt from ip (may be empty).
+func (uc *LoginUseCase) Execute(ctx context.Context, email string, password string, ip string) (*domain.User, error) {
+	actor := domain.Actor{IP: ip}
+	if uc.Guard != nil {
+		if err := uc.Guard.Check(ctx, email, ip); err != nil {
+			uc.audit(ctx, actor, email, "locked")
+			return nil, err
+		}
+	}
+
 	user, err := uc.UserRepo.FindByEmail(email)
 	if err != nil {
 		return nil, errors.New("error finding user")
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		uc.audit(ctx, actor, email, "unknown_user")
+		return nil, uc.failed(ctx, email, ip, errors.New("user not found"))
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, errors.New("invalid password")
+	actor.Type, actor.ID = domain.PrincipalUser, user.ID
+	if !passwordMatches(user.Password, password) {
+		uc.audit(ctx, actor, email, "invalid_password")
+		return nil, uc.failed(ctx, email, ip, errors.New("invalid password"))
+	}
+	if uc.Guard != nil {
+		if err := uc.Guard.Succeeded(ctx, email); err != nil {
+			return nil, err
+		}
+	}
+	if err := signInAllowed(user); err != nil {
+		uc.audit(ctx, actor, email, signInRefusal(err))
+		return nil, err
+	}
+	if uc.RequireVerified && !user.Verified {
+		uc.audit(ctx, actor, email, "email_not_verified")
+		return nil, domain.ErrEmailNotVerified
 	}
 
+	uc.audit(ctx, actor, email, "")
 	return user, nil
+}
+
+// audit records the attempt; an empty reason means the password was right.
+func (uc *LoginUseCase) audit(ctx context.Context, actor domain.Actor, email, reason string) {
+	outcome, details := domain.AuditSuccess, map[string]string{"email": email}
+	if reason != "" {
+		outcome, details["reason"] = domain.AuditFailure, reason
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditLogin, outcome, "user", actor.ID, details)
+}
+
+// signInAllowed tells whether a user who proved their identity may be issued
+// tokens.
+func signInAllowed(user *domain.User) error {
+	switch {
+	case user.Disabled:
+		return domain.ErrUserDisabled
+	case user.PasswordResetRequired:
+		return domain.ErrPasswordResetRequired
+	}
+	return nil
+}
+
+func signInRefusal(err error) string {
+	if errors.Is(err, domain.ErrUserDisabled) {
+		return "disabled"
+	}
+	return "password_reset_required"
+}
+
+func (uc *LoginUseCase) failed(ctx context.Context, email, ip string, cause error) error {
+	if uc.Guard != nil {
+		if err := uc.Guard.Failed(ctx, email, ip); err != nil {
+			return err
+		}
+	}
+	return cause
 }
\ No newline at end of file
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
+)
+
+// LoginGuard throttles password guessing. Failed logins are counted per
+// account (by email, whether it exists or not) and per client IP; past the
+// limit the account answers ErrAccountLocked and the IP
+// ErrTooManyLoginAttempts. Locks double for every repeated lockout.
+type LoginGuard struct {
+	Attempts domain.LoginAttemptStore
+
+	MaxFailures   int           // per account within Window
+	IPMaxFailures int           // per IP within Window
+	Window        time.Duration // failures older than this are forgotten
+	LockoutBase   time.Duration
+	LockoutMax    time.Duration
+}
+
+func NewLoginGuard(attempts domain.LoginAttemptStore, maxFailures, ipMaxFailures int, window, lockoutBase, lockoutMax time.Duration) *LoginGuard {
+	return &LoginGuard{
+		Attempts:      attempts,
+		MaxFailures:   maxFailures,
+		IPMaxFailures: ipMaxFailures,
+		Window:        window,
+		LockoutBase:   lockoutBase,
+		LockoutMax:    lockoutMax,
+	}
+}
+
+func accountKey(email string) string { return "acct:" + strings.ToLower(strings.TrimSpace(email)) }
+func ipKey(ip string) string         { return "ip:" + ip }
+func mfaKey(userID string) string    { return "mfa:" + userID }
+
+func (g *LoginGuard) check(ctx context.Context, key string, reason error) error {
+	left, err := g.Attempts.Locked(ctx, key)
+	if err != nil {
+		return err
+	}
+	if left > 0 {
+		return &domain.LockoutError{Err: reason, RetryAfter: left}
+	}
+	return nil
+}
+
+// Check rejects a login attempt while the account or the IP is locked.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) error {
+	if err := g.check(ctx, accountKey(email), domain.ErrAccountLocked); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return g.check(ctx, ipKey(ip), domain.ErrTooManyLoginAttempts)
+}
+
+// CheckMFA rejects a second-factor attempt while the user's codes are locked.
+// MFA failures are counted apart from password failures: a right password
+// clears the latter, and must not give a code guesser a fresh budget.
+func (g *LoginGuard) CheckMFA(ctx context.Context, userID string) error {
+	return g.check(ctx, mfaKey(userID), domain.ErrAccountLocked)
+}
+
+// Failed records a wrong password and locks whatever went over its limit.
+func (g *LoginGuard) Failed(ctx context.Context, email, ip string) error {
+	metrics.IncAuthLoginFailed()
+
+	if err := g.fail(ctx, accountKey(email), g.MaxFailures, "account"); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return g.fail(ctx, ipKey(ip), g.IPMaxFailures, "ip")
+}
+
+// FailedMFA records a wrong second-factor code.
+func (g *LoginGuard) FailedMFA(ctx context.Context, userID string) error {
+	metrics.IncAuthLoginFailed()
+	return g.fail(ctx, mfaKey(userID), g.MaxFailures, "account")
+}
+
+func (g *LoginGuard) fail(ctx context.Context, key string, limit int, scope string) error {
+	if limit <= 0 {
+		return nil
+	}
+	n, err := g.Attempts.Fail(ctx, key, g.Window)
+	if err != nil {
+		return err
+	}
+	if n < limit {
+		return nil
+	}
+	// Remember lockouts long enough for the backoff to reach its cap.
+	if _, err := g.Attempts.Lock(ctx, key, g.LockoutBase, g.LockoutMax, 2*g.LockoutMax); err != nil {
+		return err
+	}
+	metrics.IncAuthLockout(scope)
+	return nil
+}
+
+// Succeeded clears the failures of the account. The IP keeps its count: one
+// valid account must not reset the budget of an attacker.
+func (g *LoginGuard) Succeeded(ctx context.Context, email string) error {
+	return g.Attempts.Reset(ctx, accountKey(email))
+}
+
+// SucceededMFA clears the second-factor failures of the user.
+func (g *LoginGuard) SucceededMFA(ctx context.Context, userID string) error {
+	return g.Attempts.Reset(ctx, mfaKey(userID))
+}
+
+// Unlock lifts the password and second-factor locks of an account and forgets
+// their history.
+func (g *LoginGuard) Unlock(ctx context.Context, user *domain.User) error {
+	if err := g.Attempts.Reset(ctx, accountKey(user.Email)); err != nil {
+		return err
+	}
+	return g.Attempts.Reset(ctx, mfaKey(user.ID))
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
+)
+
+func TestLoginGuard(t *testing.T) {
+	metrics.MustRegister()
+	ctx := context.Background()
+
+	type check struct {
+		email, ip string
+		want      error
+	}
+	tests := []struct {
+		name   string
+		run    func(g *LoginGuard)
+		checks []check
+	}{
+		{
+			name: "below the threshold",
+			run: func(g *LoginGuard) {
+				g.Failed(ctx, "alice@example.com", "10.0.0.1")
+				g.Failed(ctx, "alice@example.com", "10.0.0.1")
+			},
+			checks: []check{{email: "alice@example.com", ip: "10.0.0.1"}},
+		},
+		{
+			name: "account over the threshold",
+			run: func(g *LoginGuard) {
+				for range 3 {
+					g.Failed(ctx, "alice@example.com", "10.0.0.1")
+				}
+			},
+			checks: []check{
+				{email: "alice@example.com", ip: "10.0.0.1", want: domain.ErrAccountLocked},
+				{email: " ALICE@example.com", ip: "10.0.0.2", want: domain.ErrAccountLocked},
+				{email: "bob@example.com", ip: "10.0.0.1"},
+			},
+		},
+		{
+			name: "failures spread over several IPs",
+			run: func(g *LoginGuard) {
+				g.Failed(ctx, "alice@example.com", "10.0.0.1")
+				g.Failed(ctx, "alice@example.com", "10.0.0.2")
+				g.Failed(ctx, "alice@example.com", "10.0.0.3")
+			},
+			checks: []check{{email: "alice@example.com", ip: "10.0.0.4", want: domain.ErrAccountLocked}},
+		},
+		{
+			name: "IP over its limit across accounts",
+			run: func(g *LoginGuard) {
+				for _, email := range []string{"a@x.com", "b@x.com", "c@x.com", "d@x.com", "e@x.com"} {
+					g.Failed(ctx, email, "10.0.0.1")
+				}
+			},
+			checks: []check{
+				{email: "alice@example.com", ip: "10.0.0.1", want: domain.ErrTooManyLoginAttempts},
+				{email: "a@x.com", ip: "10.0.0.2"},
+			},
+		},
+		{
+			name: "success resets the account",
+			run: func(g *LoginGuard) {
+				g.Failed(ctx, "alice@example.com", "10.0.0.1")
+				g.Failed(ctx, "alice@example.com", "10.0.0.1")
+				g.Succeeded(ctx, "alice@example.com")
+				g.Failed(ctx, "alice@example.com", "10.0.0.1")
+				g.Failed(ctx, "alice@example.com", "10.0.0.1")
+			},
+			checks: []check{{email: "alice@example.com", ip: "10.0.0.1"}},
+		},
+		{
+			name: "success leaves the IP count",
+			run: func(g *LoginGuard) {
+				for i := range 5 {
+					g.Failed(ctx, "alice@example.com", "10.0.0.1")
+					if i%2 == 0 {
+						g.Succeeded(ctx, "alice@example.com")
+					}
+				}
+			},
+			checks: []check{{email: "alice@example.com", ip: "10.0.0.1", want: domain.ErrTooManyLoginAttempts}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewLoginGuard(newMemLoginAttempts(), 3, 5, 15*time.Minute, time.Minute, time.Hour)
+			tt.run(g)
+			for _, c := range tt.checks {
+				err := g.Check(ctx, c.email, c.ip)
+				if !errors.Is(err, c.want) {
+					t.Errorf("Check(%q, %q) = %v, want %v", c.email, c.ip, err, c.want)
+				}
+				var lockout *domain.LockoutError
+				if c.want != nil && (!errors.As(err, &lockout) || lockout.RetryAfter <= 0) {
+					t.Errorf("Check(%q, %q) = %v, want a lockout with a Retry-After", c.email, c.ip, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoginGuardUnlock(t *testing.T) {
+	metrics.MustRegister()
+	ctx := context.Background()
+	user := &domain.User{ID: "u1", Email: "Alice@Example.com"}
+	g := NewLoginGuard(newMemLoginAttempts(), 3, 5, 15*time.Minute, time.Minute, time.Hour)
+
+	for range 3 {
+		g.Failed(ctx, "alice@example.com", "10.0.0.1")
+		g.FailedMFA(ctx, user.ID)
+	}
+	if err := g.Unlock(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check(ctx, "alice@example.com", "10.0.0.2"); err != nil {
+		t.Errorf("Check() after Unlock = %v", err)
+	}
+	if err := g.CheckMFA(ctx, user.ID); err != nil {
+		t.Errorf("CheckMFA() after Unlock = %v", err)
+	}
+	// Unlocking an account leaves the IP budget alone.
+	g.Failed(ctx, "bob@example.com", "10.0.0.1")
+	g.Failed(ctx, "bob@example.com", "10.0.0.1")
+	if err := g.Check(ctx, "carol@example.com", "10.0.0.1"); !errors.Is(err, domain.ErrTooManyLoginAttempts) {
+		t.Errorf("Check() from the failing IP = %v, want ErrTooManyLoginAttempts", err)
+	}
+}
+
+func TestLoginGuardMFAFailures(t *testing.T) {
+	metrics.MustRegister()
+	ctx := context.Background()
+	g := NewLoginGuard(newMemLoginAttempts(), 3, 5, 15*time.Minute, time.Minute, time.Hour)
+
+	for range 3 {
+		g.FailedMFA(ctx, "u1")
+	}
+	if err := g.CheckMFA(ctx, "u1"); !errors.Is(err, domain.ErrAccountLocked) {
+		t.Errorf("CheckMFA() = %v, want ErrAccountLocked", err)
+	}
+	// A right password does not clear the code failures.
+	g.Succeeded(ctx, "alice@example.com")
+	if err := g.CheckMFA(ctx, "u1"); !errors.Is(err, domain.ErrAccountLocked) {
+		t.Errorf("CheckMFA() after a password success = %v, want ErrAccountLocked", err)
+	}
+	g.SucceededMFA(ctx, "u1")
+	if err := g.CheckMFA(ctx, "u1"); err != nil {
+		t.Errorf("CheckMFA() after SucceededMFA = %v", err)
+	}
+}
+
+func TestLoginGuardDisabledLimits(t *testing.T) {
+	metrics.MustRegister()
+	ctx := context.Background()
+	g := NewLoginGuard(newMemLoginAttempts(), 0, 0, 15*time.Minute, time.Minute, time.Hour)
+	for range 50 {
+		g.Failed(ctx, "alice@example.com", "10.0.0.1")
+	}
+	if err := g.Check(ctx, "alice@example.com", "10.0.0.1"); err != nil {
+		t.Errorf("Check() with limits disabled = %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/service/totp"
+)
+
+const (
+	// totpSkew accepts the previous and next code too (clock drift).
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+)
+
+type MFAUseCase struct {
+	UserRepo   domain.UserRepository
+	Repo       domain.MFARepository
+	Challenges domain.MFAChallengeStore
+
+	// Issuer labels the account in authenticator apps.
+	Issuer       string
+	ChallengeTTL time.Duration
+
+	// MaxAttempts bounds the codes tried against one challenge; past it the
+	// user has to sign in again.
+	MaxAttempts int
+
+	// Guard, when set, locks the second factor of a user after too many wrong
+	// codes, so a known password doesn't allow unlimited challenges.
+	Guard *LoginGuard
+}
+
+func NewMFAUseCase(userRepo domain.UserRepository, repo domain.MFARepository, challenges domain.MFAChallengeStore, issuer string, challengeTTL time.Duration, maxAttempts int) *MFAUseCase {
+	return &MFAUseCase{
+		UserRepo:     userRepo,
+		Repo:         repo,
+		Challenges:   challenges,
+		Issuer:       issuer,
+		ChallengeTTL: challengeTTL,
+		MaxAttempts:  maxAttempts,
+	}
+}
+
+// TOTPEnrollment is what the user needs to add the account to an
+// authenticator app.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string // otpauth:// key URI
+}
+
+// Enroll starts (or restarts) a TOTP enrollment. MFA is only enabled once a
+// code is confirmed.
+func (uc *MFAUseCase) Enroll(userID string) (TOTPEnrollment, error) {
+	user, err := uc.UserRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return TOTPEnrollment{}, errors.New("error finding user")
+	}
+	current, err := uc.Repo.FindMFA(userID)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if current != nil && current.Enabled {
+		return TOTPEnrollment{}, domain.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if err := uc.Repo.SaveMFA(&domain.MFASettings{UserID: userID, TOTPSecret: secret}); err != nil {
+		return TOTPEnrollment{}, err
+	}
+	return TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(uc.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables MFA with a code from the pending enrollment and returns the
+// recovery codes. They are only stored hashed: this is the one time they can be
+// shown.
+func (uc *MFAUseCase) Confirm(userID, code string) ([]string, error) {
+	s, err := uc.Repo.FindMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	if s.Enabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+	step, ok := totp.Validate(s.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.Repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	s.Enabled = true
+	s.EnabledAt = &now
+	s.LastUsedStep = step
+	if err := uc.Repo.SaveMFA(s); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns MFA off. It takes a current TOTP or recovery code, so a stolen
+// session alone can't do it; wrong codes count towards the same lockout as
+// those of Verify.
+func (uc *MFAUseCase) Disable(ctx context.Context, userID, code string) error {
+	s, err := uc.Repo.FindMFA(userID)
+	if err != nil {
+		return err
+	}
+	if s == nil || !s.Enabled {
+		return domain.ErrMFANotEnabled
+	}
+	if err := uc.guardedCheckCode(ctx, s, code); err != nil {
+		return err
+	}
+	return uc.Repo.DeleteMFA(userID)
+}
+
+// Enabled reports whether logins of the user need a second factor.
+func (uc *MFAUseCase) Enabled(userID string) (bool, error) {
+	s, err := uc.Repo.FindMFA(userID)
+	if err != nil {
+		return false, err
+	}
+	return s != nil && s.Enabled, nil
+}
+
+// Challenge opens the second step of a login whose password was right.
+func (uc *MFAUseCase) Challenge(ctx context.Context, userID string) (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	if err := uc.Challenges.Save(ctx, token, userID, uc.ChallengeTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Verify completes a challenge with a TOTP or recovery code and returns the
+// signed-in user. A challenge is single-use.
+func (uc *MFAUseCase) Verify(ctx context.Context, challenge, code string) (*domain.User, error) {
+	userID, attempts, err := uc.Challenges.Attempt(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if uc.MaxAttempts > 0 && attempts > uc.MaxAttempts {
+		_ = uc.Challenges.Delete(ctx, challenge)
+		return nil, domain.ErrMFAChallengeNotFound
+	}
+
+	s, err := uc.Repo.FindMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || !s.Enabled {
+		// MFA was disabled meanwhile; the password step alone is not enough
+		// to trust this challenge.
+		_ = uc.Challenges.Delete(ctx, challenge)
+		return nil, domain.ErrMFAChallengeNotFound
+	}
+
+	if err := uc.guardedCheckCode(ctx, s, code); err != nil {
+		var le *domain.LockoutError
+		if errors.As(err, &le) {
+			_ = uc.Challenges.Delete(ctx, challenge)
+		}
+		return nil, err
+	}
+	if err := uc.Challenges.Delete(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.UserRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("error finding user")
+	}
+	// The account may have been disabled while the challenge was pending.
+	if err := signInAllowed(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// guardedCheckCode is checkCode behind the second-factor lockout of the user:
+// codes are refused while it is locked, and every wrong one is counted.
+func (uc *MFAUseCase) guardedCheckCode(ctx context.Context, s *domain.MFASettings, code string) error {
+	if uc.Guard == nil {
+		return uc.checkCode(s, code)
+	}
+	if err := uc.Guard.CheckMFA(ctx, s.UserID); err != nil {
+		return err
+	}
+	if err := uc.checkCode(s, code); err != nil {
+		if errors.Is(err, domain.ErrInvalidMFACode) {
+			if gerr := uc.Guard.FailedMFA(ctx, s.UserID); gerr != nil {
+				return gerr
+			}
+		}
+		return err
+	}
+	return uc.Guard.SucceededMFA(ctx, s.UserID)
+}
+
+// checkCode accepts a TOTP code (each at most once) or an unused recovery code.
+func (uc *MFAUseCase) checkCode(s *domain.MFASettings, code string) error {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(s.TOTPSecret, code, time.Now(), totpSkew)
+		if !ok {
+			return domain.ErrInvalidMFACode
+		}
+		recorded, err := uc.Repo.UseTOTPStep(s.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !recorded {
+			return domain.ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := uc.Repo.UseRecoveryCode(s.UserID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns n codes formatted for display (xxxx-xxxx-xxxx-xxxx,
+// 80 random bits) and their hashes. The entropy makes a plain SHA-256 enough.
+func newRecoveryCodes(n int) (codes, hashes []string, err error) {
+	for i := 0; i < n; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12]+"-"+raw[12:16])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, dashes and spaces, so codes can be typed
+// loosely.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
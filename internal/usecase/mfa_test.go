@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
+)
+
+// memLoginAttempts locks for base, with no backoff.
+type memLoginAttempts struct {
+	failures map[string]int
+	locked   map[string]time.Duration
+}
+
+func newMemLoginAttempts() *memLoginAttempts {
+	return &memLoginAttempts{failures: map[string]int{}, locked: map[string]time.Duration{}}
+}
+
+func (m *memLoginAttempts) Locked(_ context.Context, key string) (time.Duration, error) {
+	return m.locked[key], nil
+}
+
+func (m *memLoginAttempts) Fail(_ context.Context, key string, _ time.Duration) (int, error) {
+	m.failures[key]++
+	return m.failures[key], nil
+}
+
+func (m *memLoginAttempts) Lock(_ context.Context, key string, base, _, _ time.Duration) (time.Duration, error) {
+	delete(m.failures, key)
+	m.locked[key] = base
+	return base, nil
+}
+
+func (m *memLoginAttempts) Reset(_ context.Context, key string) error {
+	delete(m.failures, key)
+	delete(m.locked, key)
+	return nil
+}
+
+// memMFA holds one enabled enrollment whose recovery codes are the hashes
+// given.
+type memMFA struct {
+	domain.MFARepository
+	settings *domain.MFASettings
+	recovery map[string]bool
+}
+
+func (m *memMFA) FindMFA(string) (*domain.MFASettings, error) { return m.settings, nil }
+
+func (m *memMFA) DeleteMFA(string) error {
+	m.settings = nil
+	return nil
+}
+
+func (m *memMFA) UseRecoveryCode(_, hash string) (bool, error) {
+	if !m.recovery[hash] {
+		return false, nil
+	}
+	delete(m.recovery, hash)
+	return true, nil
+}
+
+func TestMFADisableLockout(t *testing.T) {
+	metrics.MustRegister()
+	const good = "aaaa-bbbb-cccc-dddd"
+
+	tests := []struct {
+		name        string
+		codes       []string // tried in order
+		lastErr     error
+		wantEnabled bool
+	}{
+		{name: "right code", codes: []string{good}, wantEnabled: false},
+		{name: "wrong code", codes: []string{"zzzz-zzzz-zzzz-zzzz"}, lastErr: domain.ErrInvalidMFACode, wantEnabled: true},
+		{name: "right code after a few wrong ones", codes: []string{"x", "y", good}, wantEnabled: false},
+		{name: "right code once locked", codes: []string{"x", "y", "z", good}, lastErr: domain.ErrAccountLocked, wantEnabled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memMFA{
+				settings: &domain.MFASettings{UserID: "u1", Enabled: true},
+				recovery: map[string]bool{hashRecoveryCode(good): true},
+			}
+			guard := NewLoginGuard(newMemLoginAttempts(), 3, 10, time.Hour, time.Minute, time.Hour)
+			uc := NewMFAUseCase(nil, repo, nil, "test", time.Minute, 5)
+			uc.Guard = guard
+
+			var err error
+			for _, code := range tt.codes {
+				err = uc.Disable(context.Background(), "u1", code)
+			}
+			if !errors.Is(err, tt.lastErr) {
+				t.Fatalf("last Disable() error = %v, want %v", err, tt.lastErr)
+			}
+			if enabled := repo.settings != nil; enabled != tt.wantEnabled {
+				t.Errorf("MFA enabled = %v, want %v", enabled, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestMFADisableSharesVerifyBudget(t *testing.T) {
+	metrics.MustRegister()
+	guard := NewLoginGuard(newMemLoginAttempts(), 2, 10, time.Hour, time.Minute, time.Hour)
+	uc := NewMFAUseCase(nil, &memMFA{settings: &domain.MFASettings{UserID: "u1", Enabled: true}}, nil, "test", time.Minute, 5)
+	uc.Guard = guard
+
+	for range 2 {
+		_ = uc.Disable(context.Background(), "u1", "wrong")
+	}
+	// Sign-in challenges of the user are locked too.
+	var le *domain.LockoutError
+	if err := guard.CheckMFA(context.Background(), "u1"); !errors.As(err, &le) {
+		t.Fatalf("CheckMFA() error = %v, want a lockout", err)
+	}
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+// ErrInvalidSlug is returned for slugs that aren't lowercase letters and
+// digits separated by single hyphens.
+var ErrInvalidSlug = errors.New("invalid organization slug")
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type OrganizationUseCase struct {
+	Orgs domain.OrganizationRepository
+
+	// Audit, when set, records every change with its actor.
+	Audit *Auditor
+}
+
+func NewOrganizationUseCase(orgs domain.OrganizationRepository) *OrganizationUseCase {
+	return &OrganizationUseCase{Orgs: orgs}
+}
+
+// Create registers an organization. Its ID is the tenant of everything
+// created in it.
+func (uc *OrganizationUseCase) Create(ctx context.Context, actor domain.Actor, slug, name string) (*domain.Organization, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !slugPattern.MatchString(slug) {
+		return nil, ErrInvalidSlug
+	}
+	existing, err := uc.Orgs.FindBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, domain.ErrSlugInUse
+	}
+
+	org := &domain.Organization{Slug: slug, Name: strings.TrimSpace(name)}
+	if err := uc.Orgs.Create(org); err != nil {
+		return nil, err
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditOrgCreate, domain.AuditSuccess, "organization", org.ID, map[string]string{"slug": org.Slug})
+	return org, nil
+}
+
+func (uc *OrganizationUseCase) List() ([]*domain.Organization, error) {
+	return uc.Orgs.List()
+}
+
+func (uc *OrganizationUseCase) Get(id string) (*domain.Organization, error) {
+	org, err := uc.Orgs.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	return org, nil
+}
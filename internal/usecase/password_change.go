@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+var (
+	ErrInvalidCurrentPassword = errors.New("current password is incorrect")
+	ErrPasswordUnchanged      = errors.New("new password must differ from the current one")
+)
+
+type PasswordChangeUseCase struct {
+	UserRepo domain.UserRepository
+	Sessions domain.SessionManager
+	Policy   PasswordPolicy
+
+	// Guard, when set, counts wrong current passwords as failed logins, so a
+	// stolen access token doesn't buy extra guesses at the password.
+	Guard *LoginGuard
+}
+
+func NewPasswordChangeUseCase(userRepo domain.UserRepository, sessions domain.SessionManager, policy PasswordPolicy) *PasswordChangeUseCase {
+	return &PasswordChangeUseCase{
+		UserRepo: userRepo,
+		Sessions: sessions,
+		Policy:   policy,
+	}
+}
+
+type PasswordChangeInput struct {
+	UserID          string
+	CurrentPassword string
+	NewPassword     string
+	IP              string // of the caller, may be empty
+
+	// RevokeOtherSessions ends every session but CurrentSessionID.
+	RevokeOtherSessions bool
+	CurrentSessionID    string
+}
+
+func (uc *PasswordChangeUseCase) Execute(ctx context.Context, in PasswordChangeInput) error {
+	user, err := uc.UserRepo.FindByID(in.UserID)
+	if err != nil {
+		return errors.New("error finding user")
+	}
+	if user == nil {
+		return ErrInvalidCurrentPassword
+	}
+	if uc.Guard != nil {
+		if err := uc.Guard.Check(ctx, user.Email, in.IP); err != nil {
+			return err
+		}
+	}
+	if !passwordMatches(user.Password, in.CurrentPassword) {
+		if uc.Guard != nil {
+			if err := uc.Guard.Failed(ctx, user.Email, in.IP); err != nil {
+				return err
+			}
+		}
+		return ErrInvalidCurrentPassword
+	}
+	if uc.Guard != nil {
+		if err := uc.Guard.Succeeded(ctx, user.Email); err != nil {
+			return err
+		}
+	}
+	if in.NewPassword == in.CurrentPassword {
+		return ErrPasswordUnchanged
+	}
+
+	hash, err := uc.Policy.hashPassword(in.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := uc.UserRepo.UpdatePassword(user.ID, hash); err != nil {
+		return errors.New("error updating password")
+	}
+
+	if in.RevokeOtherSessions {
+		return uc.Sessions.RevokeOtherSessions(ctx, user.ID, in.CurrentSessionID)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/infra/metrics"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordUsers keeps the password hashes written.
+type passwordUsers struct {
+	stubUsers
+}
+
+func (s passwordUsers) UpdatePassword(id, hash string) error {
+	s.users[id].Password = hash
+	return nil
+}
+
+type nopSessions struct {
+	domain.SessionManager
+}
+
+func (nopSessions) RevokeOtherSessions(context.Context, string, string) error { return nil }
+
+func newTestPasswordChange(t *testing.T, guard *LoginGuard) (*PasswordChangeUseCase, *domain.User) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("current-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &domain.User{ID: "u1", Email: "user@example.com", Password: string(hash)}
+	uc := NewPasswordChangeUseCase(passwordUsers{stubUsers{users: map[string]*domain.User{"u1": user}}}, nopSessions{}, PasswordPolicy{})
+	uc.Guard = guard
+	return uc, user
+}
+
+func TestPasswordChangeLockout(t *testing.T) {
+	metrics.MustRegister()
+
+	tests := []struct {
+		name        string
+		current     []string // tried in order
+		lastErr     error
+		wantChanged bool
+	}{
+		{name: "right password", current: []string{"current-pass"}, wantChanged: true},
+		{name: "wrong password", current: []string{"guess-1"}, lastErr: ErrInvalidCurrentPassword},
+		{name: "right password after a few wrong ones", current: []string{"guess-1", "guess-2", "current-pass"}, wantChanged: true},
+		{name: "right password once locked", current: []string{"guess-1", "guess-2", "guess-3", "current-pass"}, lastErr: domain.ErrAccountLocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, user := newTestPasswordChange(t, NewLoginGuard(newMemLoginAttempts(), 3, 10, time.Hour, time.Minute, time.Hour))
+			before := user.Password
+
+			var err error
+			for _, current := range tt.current {
+				err = uc.Execute(context.Background(), PasswordChangeInput{
+					UserID:          "u1",
+					CurrentPassword: current,
+					NewPassword:     "brand-new-pass",
+					IP:              "10.0.0.1",
+				})
+			}
+			if !errors.Is(err, tt.lastErr) {
+				t.Fatalf("last Execute() error = %v, want %v", err, tt.lastErr)
+			}
+			if changed := user.Password != before; changed != tt.wantChanged {
+				t.Errorf("password changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestPasswordChangeSharesLoginBudget(t *testing.T) {
+	metrics.MustRegister()
+	guard := NewLoginGuard(newMemLoginAttempts(), 2, 10, time.Hour, time.Minute, time.Hour)
+	uc, _ := newTestPasswordChange(t, guard)
+
+	for range 2 {
+		_ = uc.Execute(context.Background(), PasswordChangeInput{UserID: "u1", CurrentPassword: "guess", NewPassword: "brand-new-pass"})
+	}
+	// Logins to the account are locked too.
+	var le *domain.LockoutError
+	if err := guard.Check(context.Background(), "User@Example.com", ""); !errors.As(err, &le) {
+		t.Fatalf("Check() error = %v, want a lockout", err)
+	}
+}
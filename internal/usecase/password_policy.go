@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrWeakPassword wraps every password policy violation.
+var ErrWeakPassword = errors.New("password does not meet the policy")
+
+// bcryptMaxLength is the number of bytes bcrypt actually hashes.
+const bcryptMaxLength = 72
+
+// PasswordPolicy is applied whenever a password is set.
+type PasswordPolicy struct {
+	MinLength int // in characters
+}
+
+func NewPasswordPolicy(minLength int) PasswordPolicy {
+	return PasswordPolicy{MinLength: minLength}
+}
+
+// Check returns an ErrWeakPassword-wrapped error describing the violation.
+func (p PasswordPolicy) Check(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+	if len(password) > bcryptMaxLength {
+		return fmt.Errorf("%w: must be at most %d bytes", ErrWeakPassword, bcryptMaxLength)
+	}
+	return nil
+}
+
+// hashPassword checks the policy and returns the bcrypt hash.
+func (p PasswordPolicy) hashPassword(password string) (string, error) {
+	if err := p.Check(password); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.New("error generating password hash")
+	}
+	return string(hash), nil
+}
+
+// passwordMatches is the bcrypt check used wherever a user proves their password.
+func passwordMatches(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
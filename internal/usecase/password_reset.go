@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"go.uber.org/zap"
+)
+
+type PasswordResetUseCase struct {
+	UserRepo domain.UserRepository
+	Resets   domain.PasswordResetStore
+	Mailer   domain.Mailer
+	Sessions domain.SessionManager
+	Policy   PasswordPolicy
+	TTL      time.Duration
+
+	// LinkURL is the page that receives ?token=... and posts the new password
+	// to /auth/password/reset. Empty sends the bare token.
+	LinkURL string
+}
+
+func NewPasswordResetUseCase(userRepo domain.UserRepository, resets domain.PasswordResetStore, mailer domain.Mailer, sessions domain.SessionManager, policy PasswordPolicy, ttl time.Duration, linkURL string) *PasswordResetUseCase {
+	return &PasswordResetUseCase{
+		UserRepo: userRepo,
+		Resets:   resets,
+		Mailer:   mailer,
+		Sessions: sessions,
+		Policy:   policy,
+		TTL:      ttl,
+		LinkURL:  linkURL,
+	}
+}
+
+// newOpaqueToken returns 256 random bits, base64url-encoded.
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Forgot emails a reset token to the account registered with email. It works
+// in the background and unknown addresses are ignored, so callers can't probe
+// them from the outcome or the response time; failures are only logged.
+func (uc *PasswordResetUseCase) Forgot(ctx context.Context, email string) {
+	go func() {
+		if err := uc.forgot(context.WithoutCancel(ctx), email); err != nil {
+			zap.L().Error("failed to send password reset email", zap.Error(err))
+		}
+	}()
+}
+
+func (uc *PasswordResetUseCase) forgot(ctx context.Context, email string) error {
+	user, err := uc.UserRepo.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	return uc.Send(ctx, user)
+}
+
+// Send emails a reset token to the user. Requesting again invalidates the
+// previous token.
+func (uc *PasswordResetUseCase) Send(ctx context.Context, user *domain.User) error {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	if err := uc.Resets.Save(ctx, token, user.ID, uc.TTL); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Use this code to choose a new password:\n\n%s\n\nIt expires in %s. If you did not ask for a password reset, ignore this email.", token, uc.TTL)
+	if uc.LinkURL != "" {
+		link := uc.LinkURL + "?token=" + url.QueryEscape(token)
+		body = fmt.Sprintf("Open this link to choose a new password:\n\n%s\n\nIt expires in %s. If you did not ask for a password reset, ignore this email.", link, uc.TTL)
+	}
+
+	return uc.Mailer.Send(ctx, domain.Email{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    body,
+	})
+}
+
+// Reset sets a new password with a reset token and ends every session of the
+// user. The token proves control of the mailbox, so the email counts as
+// verified too.
+func (uc *PasswordResetUseCase) Reset(ctx context.Context, token, newPassword string) error {
+	// Check before burning the token so the user can pick another password.
+	if err := uc.Policy.Check(newPassword); err != nil {
+		return err
+	}
+
+	userID, err := uc.Resets.Consume(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	user, err := uc.UserRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return domain.ErrPasswordResetNotFound
+	}
+
+	hashedPassword, err := uc.Policy.hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	user.Verified = true
+	user.PasswordResetRequired = false
+	if err := uc.UserRepo.Update(user); err != nil {
+		return errors.New("error updating user")
+	}
+
+	return uc.Sessions.RevokeAllSessions(ctx, user.ID)
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+type stubUsers struct {
+	domain.UserRepository
+	users map[string]*domain.User // by ID
+}
+
+func (s stubUsers) FindByID(id string) (*domain.User, error) {
+	return s.users[id], nil
+}
+
+func (s stubUsers) FindByEmail(email string) (*domain.User, error) {
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+// chanMailer hands every email to the test, which waits for the background
+// sends.
+type chanMailer struct {
+	sent chan domain.Email
+	err  error
+}
+
+func newChanMailer(err error) chanMailer {
+	return chanMailer{sent: make(chan domain.Email, 1), err: err}
+}
+
+func (m chanMailer) Send(_ context.Context, msg domain.Email) error {
+	m.sent <- msg
+	return m.err
+}
+
+// expectEmail reports the email sent within a short wait, if any.
+func (m chanMailer) expectEmail(t *testing.T, want bool) {
+	t.Helper()
+	select {
+	case msg := <-m.sent:
+		if !want {
+			t.Errorf("unexpected email to %s", msg.To)
+		}
+	case <-time.After(100 * time.Millisecond):
+		if want {
+			t.Error("no email sent")
+		}
+	}
+}
+
+type memResets map[string]string
+
+func (m memResets) Save(_ context.Context, token, userID string, _ time.Duration) error {
+	m[token] = userID
+	return nil
+}
+
+func (m memResets) Consume(_ context.Context, token string) (string, error) {
+	id, ok := m[token]
+	if !ok {
+		return "", domain.ErrPasswordResetNotFound
+	}
+	delete(m, token)
+	return id, nil
+}
+
+type stubActionTokens struct {
+	domain.ActionTokenService
+}
+
+func (stubActionTokens) IssueActionToken(_, userID, _ string, _ time.Duration) (string, error) {
+	return "token-" + userID, nil
+}
+
+func TestForgotAndResendDoNotRevealAccounts(t *testing.T) {
+	users := stubUsers{users: map[string]*domain.User{
+		"u1": {ID: "u1", Email: "known@example.com"},
+	}}
+	tests := []struct {
+		name     string
+		email    string
+		mailErr  error
+		wantMail bool
+	}{
+		{name: "registered address", email: "known@example.com", wantMail: true},
+		{name: "unknown address", email: "nobody@example.com"},
+		{name: "mailer failing", email: "known@example.com", mailErr: errors.New("smtp down"), wantMail: true},
+	}
+	for _, tt := range tests {
+		t.Run("forgot: "+tt.name, func(t *testing.T) {
+			mailer := newChanMailer(tt.mailErr)
+			uc := NewPasswordResetUseCase(users, memResets{}, mailer, nil, PasswordPolicy{}, time.Hour, "")
+			// Returns before the email goes out, with nothing to tell either way.
+			uc.Forgot(context.Background(), tt.email)
+			mailer.expectEmail(t, tt.wantMail)
+		})
+		t.Run("resend: "+tt.name, func(t *testing.T) {
+			mailer := newChanMailer(tt.mailErr)
+			uc := NewEmailVerificationUseCase(users, stubActionTokens{}, mailer, time.Hour, "")
+			uc.Resend(context.Background(), tt.email)
+			mailer.expectEmail(t, tt.wantMail)
+		})
+	}
+}
+
+func TestForgotOutlivesTheRequest(t *testing.T) {
+	mailer := newChanMailer(nil)
+	resets := memResets{}
+	uc := NewPasswordResetUseCase(stubUsers{users: map[string]*domain.User{
+		"u1": {ID: "u1", Email: "known@example.com"},
+	}}, resets, mailer, nil, PasswordPolicy{}, time.Hour, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	uc.Forgot(ctx, "known@example.com")
+	cancel() // the handler has answered 202
+
+	mailer.expectEmail(t, true)
+	if len(resets) != 1 {
+		t.Errorf("saved tokens = %d, want 1", len(resets))
+	}
+}
@@ -1,6 +1,9 @@
 package usecase
 
 import (
+	"context"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
@@ -9,51 +12,166 @@ import (
 type PermAdminUseCase struct {
 	Repo domain.PermissionRepository
 	Now  func() time.Time
+
+	// Audit, when set, records every change with its actor.
+	Audit *Auditor
+
+	tenant *string // see ForTenant
 }
 
 func NewPermAdminUseCase(repo domain.PermissionRepository) *PermAdminUseCase {
 	return &PermAdminUseCase{Repo: repo, Now: time.Now}
 }
 
-func (uc *PermAdminUseCase) CreateRole(key string, desc string) (domain.Role, error) {
-	return uc.Repo.CreateRole(key, desc)
+// ForTenant returns a copy of the use case that only sees and creates roles,
+// scopes and groups of one tenant, and only assigns them within it.
+func (uc *PermAdminUseCase) ForTenant(tenantID string) *PermAdminUseCase {
+	v := *uc
+	v.tenant = &tenantID
+	return &v
 }
-func (uc *PermAdminUseCase) CreateScope(key string, desc string) (domain.Scope, error) {
-	return uc.Repo.CreateScope(key, desc)
+
+func (uc *PermAdminUseCase) repo() domain.PermissionRepository {
+	if uc.tenant == nil {
+		return uc.Repo
+	}
+	return uc.Repo.ForTenant(*uc.tenant)
 }
-func (uc *PermAdminUseCase) AddScopesToRole(roleID string, scopeIDs []string) error {
-	return uc.Repo.AddScopesToRole(roleID, scopeIDs)
+
+func (uc *PermAdminUseCase) record(ctx context.Context, actor domain.Actor, action, targetType, targetID string, details map[string]string, err error) {
+	outcome := domain.AuditSuccess
+	if err != nil {
+		outcome = domain.AuditFailure
+	}
+	uc.Audit.Record(ctx, actor, action, outcome, targetType, targetID, details)
 }
-func (uc *PermAdminUseCase) AddRolesToUser(userID string, roleIDs []string) error {
-	return uc.Repo.AddRolesToUser(userID, roleIDs)
+
+func (uc *PermAdminUseCase) CreateRole(ctx context.Context, actor domain.Actor, key string, desc string) (domain.Role, error) {
+	role, err := uc.repo().CreateRole(key, desc)
+	uc.record(ctx, actor, domain.AuditRoleCreate, "role", role.ID, map[string]string{"key": key}, err)
+	return role, err
+}
+func (uc *PermAdminUseCase) CreateScope(ctx context.Context, actor domain.Actor, key string, desc string) (domain.Scope, error) {
+	scope, err := uc.repo().CreateScope(key, desc)
+	uc.record(ctx, actor, domain.AuditScopeCreate, "scope", scope.ID, map[string]string{"key": key}, err)
+	return scope, err
+}
+func (uc *PermAdminUseCase) AddScopesToRole(ctx context.Context, actor domain.Actor, roleID string, scopeIDs []string) error {
+	err := uc.repo().AddScopesToRole(roleID, scopeIDs)
+	uc.record(ctx, actor, domain.AuditRoleScopesAdd, "role", roleID, map[string]string{"scope_ids": strings.Join(scopeIDs, ",")}, err)
+	return err
+}
+func (uc *PermAdminUseCase) AddRolesToUser(ctx context.Context, actor domain.Actor, userID string, roleIDs []string) error {
+	err := uc.repo().AddRolesToUser(userID, roleIDs)
+	uc.record(ctx, actor, domain.AuditUserRolesAdd, "user", userID, map[string]string{"role_ids": strings.Join(roleIDs, ",")}, err)
+	return err
+}
+func (uc *PermAdminUseCase) AddScopesToClient(ctx context.Context, actor domain.Actor, clientID string, scopeIDs []string) error {
+	err := uc.repo().AddScopesToClient(clientID, scopeIDs)
+	uc.record(ctx, actor, domain.AuditClientScopesAdd, "client", clientID, map[string]string{"scope_ids": strings.Join(scopeIDs, ",")}, err)
+	return err
 }
-func (uc *PermAdminUseCase) AddScopesToClient(clientID string, scopeIDs []string) error {
-	return uc.Repo.AddScopesToClient(clientID, scopeIDs)
+
+// DeleteRole deletes the role and takes it away from every user holding it.
+func (uc *PermAdminUseCase) DeleteRole(ctx context.Context, actor domain.Actor, roleID string) error {
+	err := uc.repo().DeleteRole(roleID)
+	uc.record(ctx, actor, domain.AuditRoleDelete, "role", roleID, nil, err)
+	return err
 }
-func (uc *PermAdminUseCase) GrantUserScope(userID string, scopeID string, grantedBy string, expiresAt *time.Time) error {
-	return uc.Repo.GrantUserScope(userID, scopeID, grantedBy, expiresAt)
+
+// DeleteScope deletes the scope and detaches it from every role, user and
+// client.
+func (uc *PermAdminUseCase) DeleteScope(ctx context.Context, actor domain.Actor, scopeID string) error {
+	err := uc.repo().DeleteScope(scopeID)
+	uc.record(ctx, actor, domain.AuditScopeDelete, "scope", scopeID, nil, err)
+	return err
+}
+func (uc *PermAdminUseCase) RemoveScopesFromRole(ctx context.Context, actor domain.Actor, roleID string, scopeIDs []string) error {
+	err := uc.repo().RemoveScopesFromRole(roleID, scopeIDs)
+	uc.record(ctx, actor, domain.AuditRoleScopesRemove, "role", roleID, map[string]string{"scope_ids": strings.Join(scopeIDs, ",")}, err)
+	return err
+}
+func (uc *PermAdminUseCase) RemoveRolesFromUser(ctx context.Context, actor domain.Actor, userID string, roleIDs []string) error {
+	err := uc.repo().RemoveRolesFromUser(userID, roleIDs)
+	uc.record(ctx, actor, domain.AuditUserRolesRemove, "user", userID, map[string]string{"role_ids": strings.Join(roleIDs, ",")}, err)
+	return err
+}
+func (uc *PermAdminUseCase) RemoveScopesFromClient(ctx context.Context, actor domain.Actor, clientID string, scopeIDs []string) error {
+	err := uc.repo().RemoveScopesFromClient(clientID, scopeIDs)
+	uc.record(ctx, actor, domain.AuditClientScopesRemove, "client", clientID, map[string]string{"scope_ids": strings.Join(scopeIDs, ",")}, err)
+	return err
 }
-func (uc *PermAdminUseCase) RevokeUserScope(userID string, scopeID string) error {
-	return uc.Repo.RevokeUserScope(userID, scopeID)
+
+// AddParentRoles makes roleID inherit the scopes of parentIDs. It fails with
+// domain.ErrRoleCycle when roleID would end up inheriting from itself.
+func (uc *PermAdminUseCase) AddParentRoles(ctx context.Context, actor domain.Actor, roleID string, parentIDs []string) error {
+	err := uc.repo().AddParentRoles(roleID, parentIDs)
+	uc.record(ctx, actor, domain.AuditRoleParentsAdd, "role", roleID, map[string]string{"parent_ids": strings.Join(parentIDs, ",")}, err)
+	return err
+}
+func (uc *PermAdminUseCase) RemoveParentRoles(ctx context.Context, actor domain.Actor, roleID string, parentIDs []string) error {
+	err := uc.repo().RemoveParentRoles(roleID, parentIDs)
+	uc.record(ctx, actor, domain.AuditRoleParentsRemove, "role", roleID, map[string]string{"parent_ids": strings.Join(parentIDs, ",")}, err)
+	return err
 }
-func (uc *PermAdminUseCase) InvalidateClient(clientID string) error {
-	return uc.Repo.InvalidateClient(clientID)
+
+// RoleTree returns the inheritance tree of a role and the scopes it resolves
+// to (its own and every inherited one).
+func (uc *PermAdminUseCase) RoleTree(roleID string) (domain.RoleTree, []string, error) {
+	tree, err := uc.repo().GetRoleTree(roleID)
+	if err != nil {
+		return domain.RoleTree{}, nil, err
+	}
+	var scopes []string
+	var walk func(t domain.RoleTree)
+	walk = func(t domain.RoleTree) {
+		scopes = append(scopes, t.Scopes...)
+		for _, p := range t.Parents {
+			walk(p)
+		}
+	}
+	walk(tree)
+	slices.Sort(scopes)
+	return tree, slices.Compact(scopes), nil
 }
-func (uc *PermAdminUseCase) InvalidateUser(userID string) error {
-	return uc.Repo.InvalidateUser(userID)
+
+// GrantUserScope grants a direct scope; the grant is attributed to actor.
+func (uc *PermAdminUseCase) GrantUserScope(ctx context.Context, actor domain.Actor, userID string, scopeID string, expiresAt *time.Time) error {
+	err := uc.repo().GrantUserScope(userID, scopeID, actor.ID, expiresAt)
+	details := map[string]string{"scope_id": scopeID}
+	if expiresAt != nil {
+		details["expires_at"] = expiresAt.UTC().Format(time.RFC3339)
+	}
+	uc.record(ctx, actor, domain.AuditUserScopeGrant, "user", userID, details, err)
+	return err
+}
+func (uc *PermAdminUseCase) RevokeUserScope(ctx context.Context, actor domain.Actor, userID string, scopeID string) error {
+	err := uc.repo().RevokeUserScope(userID, scopeID)
+	uc.record(ctx, actor, domain.AuditUserScopeRevoke, "user", userID, map[string]string{"scope_id": scopeID}, err)
+	return err
+}
+func (uc *PermAdminUseCase) InvalidateClient(ctx context.Context, actor domain.Actor, clientID string) error {
+	err := uc.repo().InvalidateClient(clientID)
+	uc.record(ctx, actor, domain.AuditClientInvalidate, "client", clientID, nil, err)
+	return err
+}
+func (uc *PermAdminUseCase) InvalidateUser(ctx context.Context, actor domain.Actor, userID string) error {
+	err := uc.repo().InvalidateUser(userID)
+	uc.record(ctx, actor, domain.AuditUserInvalidate, "user", userID, nil, err)
+	return err
 }
 func (uc *PermAdminUseCase) ListClientScopes(clientID string) ([]string, error) {
-	return uc.Repo.ListClientScopes(clientID)
+	return uc.repo().ListClientScopes(clientID)
 }
 func (uc *PermAdminUseCase) ListUserRoles(userID string) ([]string, error) {
-	return uc.Repo.ListUserRoles(userID)
+	return uc.repo().ListUserRoles(userID)
 }
 func (uc *PermAdminUseCase) ListUserScopesEffective(userID string) (roles []string, scopes []string, err error) {
-	return uc.Repo.ListUserScopesEffective(userID, uc.Now())
+	return uc.repo().ListUserScopesEffective(userID, uc.Now())
 }
 func (uc *PermAdminUseCase) ListRoles() ([]domain.Role, error) {
-	return uc.Repo.ListRoles()
+	return uc.repo().ListRoles()
 }
 func (uc *PermAdminUseCase) ListScopes() ([]domain.Scope, error) {
-	return uc.Repo.ListScopes()
+	return uc.repo().ListScopes()
 }
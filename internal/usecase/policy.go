@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+// ErrInvalidPolicy is returned, wrapped with the reason, for policies that
+// could never be evaluated.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// subjectRef prefixes resource values that stand for a subject attribute.
+const subjectRef = "$subject."
+
+// subjectAttributes are the attributes policies can match the subject on.
+var subjectAttributes = []string{"id", "type", "email", "client_id", "tenant_id", "roles", "scopes", "audience"}
+
+// PolicyUseCase manages the policies of the attribute-based authorization;
+// AuthzUseCase evaluates them.
+type PolicyUseCase struct {
+	Policies domain.PolicyRepository
+
+	// Audit, when set, records every change with its actor.
+	Audit *Auditor
+
+	tenant *string // see ForTenant
+}
+
+func NewPolicyUseCase(policies domain.PolicyRepository) *PolicyUseCase {
+	return &PolicyUseCase{Policies: policies}
+}
+
+// ForTenant returns a copy of the use case that only manages the policies of
+// one tenant.
+func (uc *PolicyUseCase) ForTenant(tenantID string) *PolicyUseCase {
+	v := *uc
+	v.tenant = &tenantID
+	return &v
+}
+
+func (uc *PolicyUseCase) policies() domain.PolicyRepository {
+	if uc.tenant == nil {
+		return uc.Policies
+	}
+	return uc.Policies.ForTenant(*uc.tenant)
+}
+
+// Create validates and stores a policy.
+func (uc *PolicyUseCase) Create(ctx context.Context, actor domain.Actor, in domain.Policy) (*domain.Policy, error) {
+	p := &domain.Policy{
+		Name:        strings.TrimSpace(in.Name),
+		Description: strings.TrimSpace(in.Description),
+		Effect:      in.Effect,
+		Actions:     unique(in.Actions),
+		Subject:     in.Subject,
+		Resource:    in.Resource,
+		Conditions:  in.Conditions,
+	}
+	if err := validatePolicy(p); err != nil {
+		return nil, err
+	}
+	if err := uc.policies().Create(p); err != nil {
+		return nil, err
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditPolicyCreate, domain.AuditSuccess, "policy", p.ID, map[string]string{
+		"name":    p.Name,
+		"effect":  string(p.Effect),
+		"actions": strings.Join(p.Actions, " "),
+	})
+	return p, nil
+}
+
+func (uc *PolicyUseCase) List() ([]*domain.Policy, error) {
+	return uc.policies().List()
+}
+
+func (uc *PolicyUseCase) Get(id string) (*domain.Policy, error) {
+	p, err := uc.policies().FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, domain.ErrPolicyNotFound
+	}
+	return p, nil
+}
+
+func (uc *PolicyUseCase) Delete(ctx context.Context, actor domain.Actor, id string) error {
+	if err := uc.policies().Delete(id); err != nil {
+		return err
+	}
+	uc.Audit.Record(ctx, actor, domain.AuditPolicyDelete, domain.AuditSuccess, "policy", id, nil)
+	return nil
+}
+
+func decide(policies []*domain.Policy, subject map[string][]string, req domain.AccessRequest) (domain.Decision, error) {
+	var d domain.Decision
+	for _, p := range policies {
+		ok, err := applies(p, subject, req)
+		if err != nil {
+			return domain.Decision{}, fmt.Errorf("policy %s: %w", p.ID, err)
+		}
+		if !ok {
+			continue
+		}
+		if p.Effect == domain.PolicyDeny {
+			return domain.Decision{PolicyID: p.ID}, nil
+		}
+		if !d.Allowed {
+			d = domain.Decision{Allowed: true, PolicyID: p.ID}
+		}
+	}
+	return d, nil
+}
+
+func principalAttributes(p domain.Principal) map[string][]string {
+	attrs := map[string][]string{
+		"id":        {p.ID},
+		"type":      {string(p.Type)},
+		"tenant_id": {p.TenantID},
+		"roles":     p.Roles,
+		"scopes":    p.Scopes,
+		"audience":  p.Audience,
+	}
+	if p.Email != "" {
+		attrs["email"] = []string{p.Email}
+	}
+	if p.ClientID != "" {
+		attrs["client_id"] = []string{p.ClientID}
+	}
+	return attrs
+}
+
+// applies reports whether p matches the request. The error is for
+// conditions that can't be evaluated, which must not silently skip a deny.
+func applies(p *domain.Policy, subject map[string][]string, req domain.AccessRequest) (bool, error) {
+	if !actionMatches(p.Actions, req.Action) {
+		return false, nil
+	}
+	for attr, accepted := range p.Subject {
+		if !slices.ContainsFunc(subject[attr], func(v string) bool { return slices.Contains(accepted, v) }) {
+			return false, nil
+		}
+	}
+	for attr, accepted := range p.Resource {
+		v, ok := req.Resource[attr]
+		if !ok || !slices.ContainsFunc(accepted, func(a string) bool {
+			if ref, isRef := strings.CutPrefix(a, subjectRef); isRef {
+				return slices.Contains(subject[ref], v)
+			}
+			return a == v
+		}) {
+			return false, nil
+		}
+	}
+	return conditionsHold(p.Conditions, req)
+}
+
+// actionMatches accepts exact actions, "*" and prefixes such as "documents:*".
+func actionMatches(patterns []string, action string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(action, prefix) {
+			return true
+		}
+		if p == action {
+			return true
+		}
+	}
+	return false
+}
+
+func conditionsHold(c domain.PolicyConditions, req domain.AccessRequest) (bool, error) {
+	if c.TimeFrom != "" || c.TimeTo != "" {
+		from, to, loc, err := timeWindow(c)
+		if err != nil {
+			return false, err
+		}
+		t := req.Time.In(loc)
+		m := t.Hour()*60 + t.Minute()
+		in := from <= m && m < to
+		if from > to {
+			in = m >= from || m < to
+		}
+		if !in {
+			return false, nil
+		}
+	}
+	if len(c.IPRanges) > 0 {
+		ip := net.ParseIP(req.IP)
+		if ip == nil {
+			return false, nil
+		}
+		in := false
+		for _, r := range c.IPRanges {
+			_, n, err := net.ParseCIDR(r)
+			if err != nil {
+				return false, err
+			}
+			in = in || n.Contains(ip)
+		}
+		if !in {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// timeWindow returns the bounds of the time-of-day condition, in minutes
+// since midnight.
+func timeWindow(c domain.PolicyConditions) (from, to int, loc *time.Location, err error) {
+	loc, err = time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	minutes := func(s string) (int, error) {
+		t, err := time.Parse("15:04", s)
+		return t.Hour()*60 + t.Minute(), err
+	}
+	if from, err = minutes(c.TimeFrom); err != nil {
+		return 0, 0, nil, err
+	}
+	if to, err = minutes(c.TimeTo); err != nil {
+		return 0, 0, nil, err
+	}
+	return from, to, loc, nil
+}
+
+func validatePolicy(p *domain.Policy) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: "+format, append([]any{ErrInvalidPolicy}, args...)...)
+	}
+	if p.Name == "" {
+		return invalid("name is required")
+	}
+	if p.Effect != domain.PolicyAllow && p.Effect != domain.PolicyDeny {
+		return invalid("effect must be %q or %q", domain.PolicyAllow, domain.PolicyDeny)
+	}
+	if len(p.Actions) == 0 {
+		return invalid("at least one action is required")
+	}
+	for attr, accepted := range p.Subject {
+		if !slices.Contains(subjectAttributes, attr) {
+			return invalid("unknown subject attribute %q", attr)
+		}
+		if len(accepted) == 0 {
+			return invalid("subject attribute %q accepts no value", attr)
+		}
+	}
+	for attr, accepted := range p.Resource {
+		if len(accepted) == 0 {
+			return invalid("resource attribute %q accepts no value", attr)
+		}
+		for _, a := range accepted {
+			if ref, ok := strings.CutPrefix(a, subjectRef); ok && !slices.Contains(subjectAttributes, ref) {
+				return invalid("unknown subject attribute %q in resource attribute %q", ref, attr)
+			}
+		}
+	}
+
+	c := p.Conditions
+	if c.TimeFrom != "" || c.TimeTo != "" || c.TimeZone != "" {
+		if c.TimeFrom == "" || c.TimeTo == "" {
+			return invalid("time_from and time_to go together")
+		}
+		from, to, _, err := timeWindow(c)
+		if err != nil {
+			return invalid("time window: %v", err)
+		}
+		if from == to {
+			return invalid("time window is empty")
+		}
+	}
+	for _, r := range c.IPRanges {
+		if _, _, err := net.ParseCIDR(r); err != nil {
+			return invalid("ip range %q is not a CIDR", r)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+)
+
+// memPolicies shares one list between its tenant views.
+type memPolicies struct {
+	all    *[]*domain.Policy
+	tenant *string
+}
+
+func newMemPolicies() *memPolicies { return &memPolicies{all: &[]*domain.Policy{}} }
+
+func (m *memPolicies) ForTenant(tenantID string) domain.PolicyRepository {
+	return &memPolicies{all: m.all, tenant: &tenantID}
+}
+
+func (m *memPolicies) Create(p *domain.Policy) error {
+	if m.tenant != nil {
+		p.TenantID = *m.tenant
+	}
+	p.ID = "p" + strconv.Itoa(len(*m.all)+1)
+	*m.all = append(*m.all, p)
+	return nil
+}
+
+func (m *memPolicies) List() ([]*domain.Policy, error) {
+	var out []*domain.Policy
+	for _, p := range *m.all {
+		if m.tenant == nil || p.TenantID == *m.tenant {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *memPolicies) FindByID(id string) (*domain.Policy, error) {
+	ps, _ := m.List()
+	for _, p := range ps {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memPolicies) Delete(string) error { return domain.ErrPolicyNotFound }
+
+func TestDecide(t *testing.T) {
+	own := &domain.Policy{ID: "own", Effect: domain.PolicyAllow, Actions: []string{"documents:*"},
+		Subject: map[string][]string{"roles": {"editor"}}, Resource: map[string][]string{"owner": {"$subject.id"}}}
+	night := &domain.Policy{ID: "night", Effect: domain.PolicyDeny, Actions: []string{"*"},
+		Conditions: domain.PolicyConditions{TimeFrom: "22:00", TimeTo: "06:00", TimeZone: "America/Sao_Paulo"}}
+	office := &domain.Policy{ID: "office", Effect: domain.PolicyAllow, Actions: []string{"reports:read"},
+		Conditions: domain.PolicyConditions{IPRanges: []string{"10.0.0.0/8"}}}
+	services := &domain.Policy{ID: "services", Effect: domain.PolicyAllow, Actions: []string{"reports:export"},
+		Subject: map[string][]string{"type": {"service"}, "scopes": {"reports:export"}}}
+	policies := []*domain.Policy{own, night, office, services}
+
+	editor := domain.Principal{Type: domain.PrincipalUser, ID: "u1", Roles: []string{"user", "editor"}}
+	reader := domain.Principal{Type: domain.PrincipalUser, ID: "u1", Roles: []string{"user"}}
+	service := domain.Principal{Type: domain.PrincipalService, ID: "c1", ClientID: "reports", Scopes: []string{"reports:export"}}
+	noon := time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC) // 12:00 in São Paulo
+	midnight := noon.Add(12 * time.Hour)
+
+	tests := []struct {
+		name     string
+		policies []*domain.Policy
+		req      domain.AccessRequest
+		want     domain.Decision
+		wantErr  bool
+	}{
+		{name: "own document", req: domain.AccessRequest{Subject: editor, Action: "documents:edit", Resource: map[string]string{"owner": "u1"}, Time: noon},
+			want: domain.Decision{Allowed: true, PolicyID: "own"}},
+		{name: "someone else's document", req: domain.AccessRequest{Subject: editor, Action: "documents:edit", Resource: map[string]string{"owner": "u2"}, Time: noon}},
+		{name: "resource without the attribute", req: domain.AccessRequest{Subject: editor, Action: "documents:edit", Time: noon}},
+		{name: "subject without the role", req: domain.AccessRequest{Subject: reader, Action: "documents:edit", Resource: map[string]string{"owner": "u1"}, Time: noon}},
+		{name: "action outside the prefix", req: domain.AccessRequest{Subject: editor, Action: "documentsx", Resource: map[string]string{"owner": "u1"}, Time: noon}},
+		{name: "deny wins over allow", req: domain.AccessRequest{Subject: editor, Action: "documents:edit", Resource: map[string]string{"owner": "u1"}, Time: midnight},
+			want: domain.Decision{PolicyID: "night"}},
+		{name: "time window end is exclusive", req: domain.AccessRequest{Subject: editor, Action: "documents:edit", Resource: map[string]string{"owner": "u1"}, Time: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)},
+			want: domain.Decision{Allowed: true, PolicyID: "own"}},
+		{name: "IP in range", req: domain.AccessRequest{Subject: reader, Action: "reports:read", IP: "10.1.2.3", Time: noon},
+			want: domain.Decision{Allowed: true, PolicyID: "office"}},
+		{name: "IP out of range", req: domain.AccessRequest{Subject: reader, Action: "reports:read", IP: "192.0.2.1", Time: noon}},
+		{name: "no IP", req: domain.AccessRequest{Subject: reader, Action: "reports:read", Time: noon}},
+		{name: "every subject attribute must match", req: domain.AccessRequest{Subject: service, Action: "reports:export", Time: noon},
+			want: domain.Decision{Allowed: true, PolicyID: "services"}},
+		{name: "user with the service's scope", req: domain.AccessRequest{Subject: domain.Principal{Type: domain.PrincipalUser, ID: "u1", Scopes: []string{"reports:export"}}, Action: "reports:export", Time: noon}},
+		{name: "no policies", policies: []*domain.Policy{}, req: domain.AccessRequest{Subject: editor, Action: "documents:edit", Time: noon}},
+		{name: "deny that can't be evaluated", policies: []*domain.Policy{{ID: "broken", Effect: domain.PolicyDeny, Actions: []string{"*"},
+			Conditions: domain.PolicyConditions{TimeFrom: "01:00", TimeTo: "02:00", TimeZone: "Mars/Olympus"}}},
+			req: domain.AccessRequest{Subject: editor, Action: "documents:edit", Time: noon}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := policies
+			if tt.policies != nil {
+				ps = tt.policies
+			}
+			got, err := decide(ps, principalAttributes(tt.req.Subject), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decide() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("decide() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCreateRejects(t *testing.T) {
+	valid := domain.Policy{Name: "p", Effect: domain.PolicyAllow, Actions: []string{"a"}}
+	tests := []struct {
+		name   string
+		policy func(p *domain.Policy)
+	}{
+		{name: "blank name", policy: func(p *domain.Policy) { p.Name = "  " }},
+		{name: "unknown effect", policy: func(p *domain.Policy) { p.Effect = "maybe" }},
+		{name: "no actions", policy: func(p *domain.Policy) { p.Actions = nil }},
+		{name: "unknown subject attribute", policy: func(p *domain.Policy) { p.Subject = map[string][]string{"shoe_size": {"42"}} }},
+		{name: "subject attribute accepting nothing", policy: func(p *domain.Policy) { p.Subject = map[string][]string{"roles": {}} }},
+		{name: "resource attribute accepting nothing", policy: func(p *domain.Policy) { p.Resource = map[string][]string{"owner": nil} }},
+		{name: "unknown subject reference", policy: func(p *domain.Policy) { p.Resource = map[string][]string{"owner": {"$subject.nope"}} }},
+		{name: "hour out of range", policy: func(p *domain.Policy) {
+			p.Conditions = domain.PolicyConditions{TimeFrom: "25:00", TimeTo: "01:00"}
+		}},
+		{name: "window without an end", policy: func(p *domain.Policy) { p.Conditions = domain.PolicyConditions{TimeFrom: "01:00"} }},
+		{name: "time zone without a window", policy: func(p *domain.Policy) { p.Conditions = domain.PolicyConditions{TimeZone: "UTC"} }},
+		{name: "empty window", policy: func(p *domain.Policy) { p.Conditions = domain.PolicyConditions{TimeFrom: "08:00", TimeTo: "08:00"} }},
+		{name: "unknown time zone", policy: func(p *domain.Policy) {
+			p.Conditions = domain.PolicyConditions{TimeFrom: "01:00", TimeTo: "02:00", TimeZone: "Mars/Olympus"}
+		}},
+		{name: "IP instead of a CIDR", policy: func(p *domain.Policy) { p.Conditions = domain.PolicyConditions{IPRanges: []string{"10.0.0.1"}} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemPolicies()
+			p := valid
+			tt.policy(&p)
+			if _, err := NewPolicyUseCase(repo).Create(context.Background(), domain.Actor{}, p); !errors.Is(err, ErrInvalidPolicy) {
+				t.Fatalf("Create() error = %v, want ErrInvalidPolicy", err)
+			}
+			if len(*repo.all) != 0 {
+				t.Error("invalid policy stored")
+			}
+		})
+	}
+}
+
+func TestPolicyCreateInTenant(t *testing.T) {
+	repo := newMemPolicies()
+	uc := NewPolicyUseCase(repo)
+	p, err := uc.ForTenant("org-1").Create(context.Background(), domain.Actor{}, domain.Policy{
+		Name: " org ", Effect: domain.PolicyAllow, Actions: []string{"a", "a", "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.TenantID != "org-1" || p.Name != "org" || len(p.Actions) != 2 {
+		t.Errorf("Create() = %+v", p)
+	}
+	if got, _ := uc.ForTenant("org-2").Get(p.ID); got != nil {
+		t.Error("policy visible to another tenant")
+	}
+}
@@ -1,42 +1,65 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 
 	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"go.uber.org/zap"
 )
 
 type SignupUseCase struct {
-	UserRepo domain.UserRepository
+	UserRepo     domain.UserRepository
+	Verification *EmailVerificationUseCase
+	Policy       PasswordPolicy
+
+	// Audit, when set, records every signup.
+	Audit *Auditor
 }
 
-func NewSignupUseCase(userRepo domain.UserRepository) *SignupUseCase {
+func NewSignupUseCase(userRepo domain.UserRepository, verification *EmailVerificationUseCase, policy PasswordPolicy) *SignupUseCase {
 	return &SignupUseCase{
-		UserRepo: userRepo,
+		UserRepo:     userRepo,
+		Verification: verification,
+		Policy:       policy,
 	}
 }
 	
-func (uc *SignupUseCase) Execute(email, password string) (*domain.User, error) {
+// Execute registers a user signing up from ip (may be empty).
+func (uc *SignupUseCase) Execute(ctx context.Context, email, password, ip string) (*domain.User, error) {
 	existingUser, _ := uc.UserRepo.FindByEmail(email)
 	if existingUser != nil {
+		uc.Audit.Record(ctx, domain.Actor{IP: ip}, domain.AuditSignup, domain.AuditFailure, "user", existingUser.ID,
+			map[string]string{"email": email, "reason": "email_in_use"})
 		return nil, errors.New("Email already in use")
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := uc.Policy.hashPassword(password)
 	if err != nil {
-		return nil, errors.New("error generating password hash")
+		return nil, err
 	}
 
 	newUser := &domain.User{
 		ID:       generateID(),
 		Email:    email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 		Verified: false,
 	}
 
-	return newUser, uc.UserRepo.Create(newUser)
+	if err := uc.UserRepo.Create(newUser); err != nil {
+		return newUser, err
+	}
+	uc.Audit.Record(ctx, domain.Actor{Type: domain.PrincipalUser, ID: newUser.ID, IP: ip}, domain.AuditSignup, domain.AuditSuccess,
+		"user", newUser.ID, map[string]string{"email": email})
+
+	// The account exists either way; a lost email can be sent again.
+	if uc.Verification != nil {
+		if err := uc.Verification.Send(ctx, newUser); err != nil {
+			zap.L().Warn("failed to send verification email", zap.String("user_id", newUser.ID), zap.Error(err))
+		}
+	}
+	return newUser, nil
 }
 
 func generateID() string {
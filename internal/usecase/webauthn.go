@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/domain"
+	"github.com/YuriGarciaRibeiro/auth-microservice-go/internal/service/webauthn"
+)
+
+const (
+	ceremonyRegistration = "registration"
+	ceremonyLogin        = "login"
+)
+
+type WebAuthnUseCase struct {
+	UserRepo    domain.UserRepository
+	Credentials domain.WebAuthnCredentialRepository
+	Sessions    domain.WebAuthnSessionStore
+	RP          *webauthn.RelyingParty
+
+	// Timeout is given to the browser and bounds the ceremony on our side.
+	Timeout time.Duration
+}
+
+func NewWebAuthnUseCase(userRepo domain.UserRepository, credentials domain.WebAuthnCredentialRepository, sessions domain.WebAuthnSessionStore, rp *webauthn.RelyingParty, timeout time.Duration) *WebAuthnUseCase {
+	return &WebAuthnUseCase{
+		UserRepo:    userRepo,
+		Credentials: credentials,
+		Sessions:    sessions,
+		RP:          rp,
+		Timeout:     timeout,
+	}
+}
+
+// startCeremony stores a fresh challenge and returns the session ID the
+// client sends back with the response.
+func (uc *WebAuthnUseCase) startCeremony(ctx context.Context, ceremony, userID string) (string, []byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", nil, err
+	}
+	id, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	s := domain.WebAuthnSession{Ceremony: ceremony, Challenge: challenge, UserID: userID}
+	if err := uc.Sessions.Save(ctx, id, s, uc.Timeout); err != nil {
+		return "", nil, err
+	}
+	return id, challenge, nil
+}
+
+// finishCeremony consumes a session started for the same ceremony.
+func (uc *WebAuthnUseCase) finishCeremony(ctx context.Context, id, ceremony string) (domain.WebAuthnSession, error) {
+	s, err := uc.Sessions.Consume(ctx, id)
+	if err != nil {
+		return domain.WebAuthnSession{}, err
+	}
+	if s.Ceremony != ceremony {
+		return domain.WebAuthnSession{}, domain.ErrWebAuthnSessionNotFound
+	}
+	return s, nil
+}
+
+func credentialIDs(creds []domain.WebAuthnCredential) [][]byte {
+	ids := make([][]byte, len(creds))
+	for i, c := range creds {
+		ids[i] = c.CredentialID
+	}
+	return ids
+}
+
+// BeginRegistration starts adding a credential to the account of userID.
+func (uc *WebAuthnUseCase) BeginRegistration(ctx context.Context, userID string) (string, webauthn.CreationOptions, error) {
+	user, err := uc.UserRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return "", webauthn.CreationOptions{}, errors.New("error finding user")
+	}
+	existing, err := uc.Credentials.ListCredentials(userID)
+	if err != nil {
+		return "", webauthn.CreationOptions{}, err
+	}
+
+	id, challenge, err := uc.startCeremony(ctx, ceremonyRegistration, userID)
+	if err != nil {
+		return "", webauthn.CreationOptions{}, err
+	}
+	// The user handle is the user ID: stable and free of personal data.
+	opts := uc.RP.CreationOptions(challenge, []byte(user.ID), user.Email, credentialIDs(existing), uc.Timeout)
+	return id, opts, nil
+}
+
+// FinishRegistration verifies the authenticator response and stores the new
+// credential.
+func (uc *WebAuthnUseCase) FinishRegistration(ctx context.Context, userID, sessionID, name string, resp webauthn.RegistrationResponse) (*domain.WebAuthnCredential, error) {
+	s, err := uc.finishCeremony(ctx, sessionID, ceremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if s.UserID != userID {
+		return nil, domain.ErrWebAuthnSessionNotFound
+	}
+
+	cred, err := uc.RP.VerifyRegistration(s.Challenge, resp.Response.ClientDataJSON, resp.Response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := uc.Credentials.FindCredential(cred.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, domain.ErrWebAuthnCredentialExists
+	}
+
+	c := &domain.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: cred.ID,
+		PublicKey:    cred.PublicKey,
+		SignCount:    cred.SignCount,
+		AAGUID:       cred.AAGUID,
+		Name:         name,
+	}
+	if err := uc.Credentials.CreateCredential(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// BeginLogin starts a sign-in. With an email the browser is pointed at the
+// credentials of that account; without one it offers discoverable
+// credentials. An unknown email gets the discoverable variant too, so
+// accounts can't be probed.
+func (uc *WebAuthnUseCase) BeginLogin(ctx context.Context, email string) (string, webauthn.RequestOptions, error) {
+	var (
+		userID string
+		allow  [][]byte
+	)
+	if email != "" {
+		user, err := uc.UserRepo.FindByEmail(email)
+		if err != nil {
+			return "", webauthn.RequestOptions{}, errors.New("error finding user")
+		}
+		if user != nil {
+			creds, err := uc.Credentials.ListCredentials(user.ID)
+			if err != nil {
+				return "", webauthn.RequestOptions{}, err
+			}
+			if len(creds) > 0 {
+				userID, allow = user.ID, credentialIDs(creds)
+			}
+		}
+	}
+
+	id, challenge, err := uc.startCeremony(ctx, ceremonyLogin, userID)
+	if err != nil {
+		return "", webauthn.RequestOptions{}, err
+	}
+	return id, uc.RP.RequestOptions(challenge, allow, uc.Timeout), nil
+}
+
+// FinishLogin verifies an assertion and returns the signed-in user.
+func (uc *WebAuthnUseCase) FinishLogin(ctx context.Context, sessionID string, resp webauthn.AssertionResponse) (*domain.User, error) {
+	s, err := uc.finishCeremony(ctx, sessionID, ceremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := uc.Credentials.FindCredential(resp.RawID)
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, domain.ErrWebAuthnCredentialUnknown
+	}
+	// A login started for an account only accepts that account's credentials;
+	// a discoverable one must name its owner in the user handle.
+	if s.UserID != "" && cred.UserID != s.UserID {
+		return nil, domain.ErrWebAuthnCredentialUnknown
+	}
+	handle := string(resp.Response.UserHandle)
+	if (s.UserID == "" && handle == "") || (handle != "" && handle != cred.UserID) {
+		return nil, domain.ErrWebAuthnCredentialUnknown
+	}
+
+	count, err := uc.RP.VerifyAssertion(
+		s.Challenge,
+		cred.PublicKey,
+		cred.SignCount,
+		resp.Response.ClientDataJSON,
+		resp.Response.AuthenticatorData,
+		resp.Response.Signature,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.Credentials.UpdateSignCount(cred.ID, count, time.Now()); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.UserRepo.FindByID(cred.UserID)
+	if err != nil || user == nil {
+		return nil, errors.New("error finding user")
+	}
+	if err := signInAllowed(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}